@@ -0,0 +1,108 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View, validate and edit backdrop's configuration.",
+	Long: `View, validate and edit backdrop's configuration. Keys are written like "Processing.Crop" or
+"overlay.size", without case. Every change is validated before it is written, so an invalid value
+never ends up in the config file.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show every setting, where its value comes from and where images are listed from.",
+	Long: `Show every setting with the origin of its value: the config file, an environment variable named
+like the key or the default. Then every library root in order of precedence:
+
+  1. BACKDROP_IMAGE_PATH, several paths are separated by ":" (";" on Windows).
+  2. WallpapersPath and LibraryRoots in the config file. WallpapersPath is set with --path,
+     LibraryRoots is a list of paths, written as "path" or "name=path".
+  3. $XDG_DATA_HOME/backdrop/wallpapers (%APPDATA%\Backdrop on Windows), then $HOME/Pictures/wallpapers.
+
+The first of these with an existing directory is used, the roots of BACKDROP_IMAGE_PATH and of the
+config file are merged into one library. With several roots image names start with the name of their
+root, e.g. "nas/forest.jpg". Downloaded and generated images are saved to the first root.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ShowConfig(os.Stdout, cmd.Flags().Changed("config"))
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.GetConfig(os.Stdout, args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Change a setting, lists such as LibraryRoots take several values.",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.SetConfig(os.Stdout, args[0], args[1:])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Put a setting, or every setting under it, back to its default.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.UnsetConfig(os.Stdout, args[0])
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the config file in $EDITOR, it is only saved once it is valid.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.EditConfig(os.Stdout)
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ConfigPath(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd, configGetCmd, configSetCmd, configUnsetCmd, configEditCmd, configPathCmd)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// ctlCmd represents the ctl command
+var ctlCmd = &cobra.Command{
+	Use:   "ctl next|prev|pause|resume|status|reload|set <image>",
+	Short: "Control a running backdrop daemon.",
+	Long: `Sends a command to the daemon started with 'backdrop daemon' through its control socket,
+then prints the current image, playlist position and next change time of every output.
+Bind these commands to window manager shortcuts to skip images instantly.`,
+	ValidArgs: internal.ControlCommands,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 || !slices.Contains(internal.ControlCommands, args[0]) {
+			return fmt.Errorf("expected one of %v", internal.ControlCommands)
+		}
+		if args[0] == "set" && len(args) != 2 {
+			return fmt.Errorf("set expects exactly one image")
+		}
+		if args[0] != "set" && len(args) != 1 {
+			return fmt.Errorf("%s does not take arguments", args[0])
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.SendControlCommand(os.Stdout, args[0], args[1:])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ctlCmd)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// currentCmd represents the current command
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show the wallpaper on every output and what backdrop knows about it.",
+	Long: `Show the wallpaper on every output with the backend, its fit mode, whether it
+is in the library, its tags, the URL it was downloaded from and its size.
+
+Processed copies are reported as the library image they were made from.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pathOnly, err := cmd.Flags().GetBool("path-only")
+		if err != nil {
+			return err
+		}
+		open, err := cmd.Flags().GetBool("open")
+		if err != nil {
+			return err
+		}
+
+		if open {
+			return internal.OpenCurrent(os.Stdout)
+		}
+		return internal.ShowCurrent(os.Stdout, pathOnly)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(currentCmd)
+	currentCmd.Flags().Bool("path-only", false, "Only print the image path of every output, one per line.")
+	currentCmd.Flags().Bool("open", false, "Show the image in the file manager.")
+	currentCmd.MarkFlagsMutuallyExclusive("path-only", "open")
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Rotate wallpapers in the background on desktops without native slideshows.",
+	Long: `Runs in the foreground and rotates wallpapers through the active desktop backend
+(GNOME, MATE, XFCE, KDE, swww or feh), one rotation per output.
+Send SIGHUP to reload the playlist, SIGINT or SIGTERM to stop.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slideShow, err := cmd.Flags().GetString("slideshow")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		shuffle, err := cmd.Flags().GetBool("shuffle")
+		if err != nil {
+			return err
+		}
+
+		return internal.RunDaemon(os.Stdout, &internal.DaemonOptions{
+			SlideShow: slideShow,
+			Interval:  interval,
+			Shuffle:   shuffle,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+
+	daemonCmd.Flags().String("slideshow", "", "Name of a saved slideshow to rotate through. Defaults to every image in the wallpapers path.")
+	daemonCmd.Flags().DurationP("interval", "i", 30*time.Minute, "Time each image stays on screen, e.g. 90s, 30m or 2h.")
+	daemonCmd.Flags().Bool("shuffle", false, "Shuffle the playlist every time it is loaded.")
+}
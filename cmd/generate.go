@@ -0,0 +1,135 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate solid color, gradient and pattern wallpapers.",
+	Long: `Renders a PNG at the display resolution into the wallpapers path. With --apply it is also set as
+wallpaper; GNOME, MATE and XFCE draw solid colors and horizontal or vertical gradients themselves,
+so no image is generated for them. Colors are given as rrggbb, the leading "#" is optional.`,
+}
+
+var generateSolidCmd = &cobra.Command{
+	Use:   "solid <color>",
+	Short: "Generate a single color wallpaper, e.g. 'backdrop generate solid 1e1e2e'.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := generateOptionsFromFlags(cmd, "solid")
+		if err != nil {
+			return err
+		}
+		opts.From = args[0]
+		return internal.Generate(os.Stdout, opts)
+	},
+}
+
+func newGenerateCmd(kind, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   kind,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := generateOptionsFromFlags(cmd, kind)
+			if err != nil {
+				return err
+			}
+			return internal.Generate(os.Stdout, opts)
+		},
+	}
+}
+
+var (
+	generateGradientCmd = newGenerateCmd("gradient", "Generate a two color gradient wallpaper.")
+	generateNoiseCmd    = newGenerateCmd("noise", "Generate a smooth noise wallpaper blending two colors.")
+	generateStripesCmd  = newGenerateCmd("stripes", "Generate a striped wallpaper in two colors.")
+	generateDotsCmd     = newGenerateCmd("dots", "Generate a wallpaper with dots of one color on another.")
+)
+
+func generateOptionsFromFlags(cmd *cobra.Command, kind string) (internal.GenerateOptions, error) {
+	opts := internal.GenerateOptions{Kind: kind}
+	var err error
+	if opts.Resolution, err = cmd.Flags().GetString("resolution"); err != nil {
+		return opts, err
+	}
+	if opts.Name, err = cmd.Flags().GetString("name"); err != nil {
+		return opts, err
+	}
+	if opts.Apply, err = cmd.Flags().GetBool("apply"); err != nil {
+		return opts, err
+	}
+	if kind == "solid" {
+		return opts, nil
+	}
+
+	if opts.From, err = cmd.Flags().GetString("from"); err != nil {
+		return opts, err
+	}
+	if opts.To, err = cmd.Flags().GetString("to"); err != nil {
+		return opts, err
+	}
+	if cmd.Flags().Lookup("angle") != nil {
+		if opts.Angle, err = cmd.Flags().GetInt("angle"); err != nil {
+			return opts, err
+		}
+	}
+	if cmd.Flags().Lookup("size") != nil {
+		if opts.Size, err = cmd.Flags().GetInt("size"); err != nil {
+			return opts, err
+		}
+	}
+	if cmd.Flags().Lookup("seed") != nil {
+		if opts.Seed, err = cmd.Flags().GetInt64("seed"); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateSolidCmd, generateGradientCmd, generateNoiseCmd, generateStripesCmd, generateDotsCmd)
+
+	generateCmd.PersistentFlags().String("resolution", "", `Size of the image, e.g. "2560x1440". Detected from the display if not provided.`)
+	generateCmd.PersistentFlags().String("name", "", "File name in the wallpapers path. Named after the colors and pattern if not provided.")
+	generateCmd.PersistentFlags().Bool("apply", false, "Set the generated wallpaper right away.")
+
+	for _, patternCmd := range []*cobra.Command{generateGradientCmd, generateNoiseCmd, generateStripesCmd, generateDotsCmd} {
+		patternCmd.Flags().String("from", "#1e1e2e", "First color.")
+		patternCmd.Flags().String("to", "#89b4fa", "Second color.")
+	}
+	for _, angleCmd := range []*cobra.Command{generateGradientCmd, generateStripesCmd} {
+		angleCmd.Flags().Int("angle", 90, "Direction in degrees clockwise, 0 runs from left to right and 90 from top to bottom.")
+	}
+	generateNoiseCmd.Flags().Int("size", 96, "Size in pixels of the blotches.")
+	generateNoiseCmd.Flags().Int64("seed", 1, "Generating with the same seed draws the same pattern.")
+	generateStripesCmd.Flags().Int("size", 48, "Width in pixels of every stripe.")
+	generateDotsCmd.Flags().Int("size", 48, "Distance in pixels between dots.")
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// addCmd represents the add command
+var addCmd = &cobra.Command{
+	Use:   "add <file>...",
+	Short: "Copy images into the wallpapers path, images already in the library are skipped.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		link, err := cmd.Flags().GetBool("link")
+		if err != nil {
+			return err
+		}
+		return internal.AddImages(os.Stdout, args, link)
+	},
+}
+
+// rmCmd represents the rm command
+var rmCmd = &cobra.Command{
+	Use:   "rm <image>...",
+	Short: "Move library images to the trash.",
+	Long: `Move library images to the trash, from where 'backdrop trash restore' or the file
+manager can restore them. Their tags, history and places in slideshows are removed
+with them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.RemoveImages(os.Stdout, args)
+	},
+}
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:     "mv <image> <destination>",
+	Aliases: []string{"rename"},
+	Short:   "Move or rename a library image, keeping its tags, history and slideshows.",
+	Long: `Move or rename a library image. Relative destinations are in the wallpapers path,
+a destination ending in "/" or naming a folder keeps the file name and one without an
+extension keeps the image's extension.`,
+	Example: `  backdrop mv forest.jpg nature/
+  backdrop rename forest.jpg misty-forest`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.MoveImage(os.Stdout, args[0], args[1])
+	},
+}
+
+// organizeCmd represents the organize command
+var organizeCmd = &cobra.Command{
+	Use:   "organize",
+	Short: "Sort the library into folders by orientation, resolution or dominant color.",
+	Long: `Sort the images of every library root into folders of the root named after their
+orientation (landscape, portrait, square), resolution (8k, 5k, 4k, 1440p, 1080p, 720p,
+smaller) or dominant color. Tags, history and slideshows follow the images.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, err := cmd.Flags().GetString("by")
+		if err != nil {
+			return err
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+		return internal.OrganizeLibrary(os.Stdout, by, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd, rmCmd, mvCmd, organizeCmd)
+	addCmd.Flags().Bool("link", false, "Link the images instead of copying them.")
+	organizeCmd.Flags().String("by", "orientation", fmt.Sprintf("How to sort the images, one of %s.", strings.Join(internal.OrganizeKeys, ", ")))
+	organizeCmd.Flags().Bool("dry-run", false, "Only show where images would be moved.")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the images of the library without opening the finder.",
+	Long: fmt.Sprintf(`List the images of the library, limited to the active profile, as a table.
+
+Images are sorted by name, every other sort key lists the largest, newest or most
+used images first, --reverse turns the order around.
+
+Columns: %s
+Structured output (--output json|yaml) has every column.`, strings.Join(internal.ListColumns, ", ")),
+	Example: `  backdrop list --sort last-used --columns name,last-used,use-count
+  backdrop list --orientation portrait --min-width 1440 --tag nature
+  backdrop list --since 7d --ext jpg,png -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var opts internal.ListOptions
+		var err error
+		flags := cmd.Flags()
+		if opts.Sort, err = flags.GetString("sort"); err != nil {
+			return err
+		}
+		if opts.Reverse, err = flags.GetBool("reverse"); err != nil {
+			return err
+		}
+		if opts.MinWidth, err = flags.GetInt("min-width"); err != nil {
+			return err
+		}
+		if opts.Orientation, err = flags.GetString("orientation"); err != nil {
+			return err
+		}
+		if opts.Tags, err = flags.GetStringSlice("tag"); err != nil {
+			return err
+		}
+		if opts.Since, err = flags.GetString("since"); err != nil {
+			return err
+		}
+		if opts.Extensions, err = flags.GetStringSlice("ext"); err != nil {
+			return err
+		}
+		if opts.Columns, err = flags.GetStringSlice("columns"); err != nil {
+			return err
+		}
+		return internal.ListImages(os.Stdout, opts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().String("sort", "name", fmt.Sprintf("Sort by one of %s.", strings.Join(internal.ListSortKeys, ", ")))
+	listCmd.Flags().BoolP("reverse", "r", false, "Reverse the sort order.")
+	listCmd.Flags().Int("min-width", 0, "Only list images at least this many pixels wide.")
+	listCmd.Flags().String("orientation", "", "Only list landscape, portrait or square images.")
+	listCmd.Flags().StringSlice("tag", nil, "Only list images with any of these tags, repeat or separate with commas.")
+	listCmd.Flags().String("since", "", "Only list images modified since a date like 2024-01-31 or a duration like 36h, 7d or 2w.")
+	listCmd.Flags().StringSlice("ext", nil, "Only list images with these extensions, e.g. jpg,png.")
+	listCmd.Flags().StringSlice("columns", internal.DefaultListColumns, "Columns to show, in order.")
+}
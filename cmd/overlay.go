@@ -0,0 +1,130 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// overlayCmd represents the overlay command
+var overlayCmd = &cobra.Command{
+	Use:   "overlay",
+	Short: "Draw the hostname, a quote, a calendar or your own text onto wallpapers.",
+	Long: `The overlay is drawn onto a copy of every wallpaper before it is applied, the originals are left
+untouched. Text is drawn with fonts built into backdrop, so it works on machines without fonts or
+network access. Enabling an overlay applies the current wallpaper again with it.`,
+}
+
+var overlayHostnameCmd = &cobra.Command{
+	Use:   "hostname",
+	Short: "Show the name of this machine.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return enableOverlay(cmd, internal.OverlayOptions{Kind: "hostname"})
+	},
+}
+
+var overlayQuoteCmd = &cobra.Command{
+	Use:   "quote <file>",
+	Short: "Show a quote of the day from a file.",
+	Long: `Show a different quote from the file every day. Quotes are separated by lines with a single "%",
+like fortune files, or are one per line.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return enableOverlay(cmd, internal.OverlayOptions{Kind: "quote", Quotes: args[0]})
+	},
+}
+
+var overlayCalendarCmd = &cobra.Command{
+	Use:   "calendar",
+	Short: "Show the current month.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return enableOverlay(cmd, internal.OverlayOptions{Kind: "calendar"})
+	},
+}
+
+var overlayTextCmd = &cobra.Command{
+	Use:   "text <template>",
+	Short: "Show your own text.",
+	Long: `Show your own text, written as a Go template. It can use {{.Hostname}}, {{.User}}, {{.Wallpaper}}
+and the time the wallpaper was applied, e.g.
+
+  backdrop overlay text '{{.User}}@{{.Hostname}} {{.Now.Format "Monday 2 January"}}'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return enableOverlay(cmd, internal.OverlayOptions{Kind: "text", Text: args[0]})
+	},
+}
+
+var overlayDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Apply wallpapers without an overlay.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.DisableOverlay(os.Stdout)
+	},
+}
+
+var overlayStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the overlay drawn onto wallpapers.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.OverlayStatus(os.Stdout)
+	},
+}
+
+func enableOverlay(cmd *cobra.Command, opts internal.OverlayOptions) error {
+	var err error
+	if opts.Corner, err = cmd.Flags().GetString("corner"); err != nil {
+		return err
+	}
+	if opts.Font, err = cmd.Flags().GetString("font"); err != nil {
+		return err
+	}
+	if opts.Size, err = cmd.Flags().GetFloat64("size"); err != nil {
+		return err
+	}
+	if opts.Color, err = cmd.Flags().GetString("color"); err != nil {
+		return err
+	}
+
+	return internal.EnableOverlay(os.Stdout, opts)
+}
+
+func init() {
+	rootCmd.AddCommand(overlayCmd)
+	overlayCmd.AddCommand(overlayHostnameCmd, overlayQuoteCmd, overlayCalendarCmd, overlayTextCmd, overlayDisableCmd, overlayStatusCmd)
+
+	for _, cmd := range []*cobra.Command{overlayHostnameCmd, overlayQuoteCmd, overlayCalendarCmd, overlayTextCmd} {
+		cmd.Flags().String("corner", "bottom-right", fmt.Sprintf("Where the text is drawn: %s.", strings.Join(internal.OverlayCorners, ", ")))
+		cmd.Flags().String("font", "regular", fmt.Sprintf("Font of the text: %s. Calendars always use mono.", strings.Join(internal.OverlayFonts, ", ")))
+		cmd.Flags().Float64("size", 24, "Size of the text in pixels on a 1080 pixel tall screen, it scales with the display.")
+		cmd.Flags().String("color", "ffffff", `Color of the text, e.g. "ffffff" or "#ffffff".`)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// pairCmd represents the pair command
+var pairCmd = &cobra.Command{
+	Use:   "pair",
+	Short: "Manage light and dark mode wallpaper pairs.",
+	Long: `A pair shows one image while the desktop uses a light color scheme and another one in dark mode.
+Pick a pair with 'backdrop --pair' or 'backdrop --light <image> --dark <image>'.
+GNOME switches between the images itself, on other desktops the daemon or 'backdrop pair watch'
+follow the color scheme of the XDG desktop portal.`,
+}
+
+var pairListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved pairs as light and dark image.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ListPairs(os.Stdout)
+	},
+}
+
+var pairRemoveCmd = &cobra.Command{
+	Use:   "remove <image>",
+	Short: "Forget the pair an image belongs to, the images are kept.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.RemovePair(os.Stdout, args[0])
+	},
+}
+
+var pairWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Switch the current pair whenever the color scheme changes.",
+	Long: `Follows the color scheme of the XDG desktop portal and shows the matching image of the
+current pair. Not needed on GNOME, or while 'backdrop daemon' is running.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.WatchPairs(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pairCmd)
+	pairCmd.AddCommand(pairListCmd, pairRemoveCmd, pairWatchCmd)
+}
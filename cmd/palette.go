@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// paletteCmd represents the palette command
+var paletteCmd = &cobra.Command{
+	Use:   "palette",
+	Short: "Export a terminal color palette picked from the wallpaper.",
+	Long: `When palettes are enabled, 16 colors are picked from every wallpaper applied and written as
+colors.sh, colors.json, colors.Xresources, colors-kitty.conf, colors-alacritty.toml, colors-foot.ini
+and colors.css, in the same layout as pywal. Source or include them from your terminal and desktop
+configuration, and reload those programs with a hook.`,
+}
+
+var paletteEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Write a palette every time a wallpaper is applied.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hook, err := cmd.Flags().GetString("hook")
+		if err != nil {
+			return err
+		}
+
+		return internal.EnablePalette(os.Stdout, hook)
+	},
+}
+
+var paletteDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop writing palettes.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.DisablePalette(os.Stdout)
+	},
+}
+
+var paletteShowCmd = &cobra.Command{
+	Use:   "show [image]",
+	Short: "Show the palette of an image, or of the current wallpaper.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ShowPalette(os.Stdout, paletteImageArg(args))
+	},
+}
+
+var paletteGenerateCmd = &cobra.Command{
+	Use:   "generate [image]",
+	Short: "Write the palette of an image, or of the current wallpaper, and run the hook.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ExportPalette(os.Stdout, paletteImageArg(args))
+	},
+}
+
+func paletteImageArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+func init() {
+	rootCmd.AddCommand(paletteCmd)
+	paletteCmd.AddCommand(paletteEnableCmd, paletteDisableCmd, paletteShowCmd, paletteGenerateCmd)
+
+	paletteEnableCmd.Flags().String("hook", "", `Command run after a palette is written, e.g. "pkill -USR1 kitty". It gets the
+    directory of the palette in $BACKDROP_PALETTE_DIR and the wallpaper in $BACKDROP_WALLPAPER.`)
+}
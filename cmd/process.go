@@ -0,0 +1,86 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// processCmd represents the process command
+var processCmd = &cobra.Command{
+	Use:   "process",
+	Short: "Crop and scale images to the display resolution before applying them.",
+	Long: `Large photos and images with a different aspect ratio than the display are cropped and scaled
+to the resolution of each output before they are applied. The originals are left untouched, the
+processed copies are cached and reused until the original changes.`,
+}
+
+var processEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Process images before they are applied.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		crop, err := cmd.Flags().GetString("crop")
+		if err != nil {
+			return err
+		}
+		resolution, err := cmd.Flags().GetString("resolution")
+		if err != nil {
+			return err
+		}
+
+		return internal.EnableProcessing(os.Stdout, internal.ProcessingOptions{Crop: crop, Resolution: resolution})
+	},
+}
+
+var processDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Apply images as they are.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.DisableProcessing(os.Stdout)
+	},
+}
+
+var processStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the crop mode and the resolution images are scaled to.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ProcessingStatus(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(processCmd)
+	processCmd.AddCommand(processEnableCmd, processDisableCmd, processStatusCmd)
+
+	processEnableCmd.Flags().String("crop", internal.CropModes[0], fmt.Sprintf(`Which part of the image is kept: %s.
+    center keeps the middle, thirds puts the detailed part of the image on a third line and
+    entropy keeps the part with the most detail.`, strings.Join(internal.CropModes, ", ")))
+	processEnableCmd.Flags().String("resolution", "auto", `Resolution to scale images to, e.g. "2560x1440". "auto" uses the resolution of each output.`)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Switch between named sets of library paths, tags, fit, effects, slideshow and schedule.",
+	Long: `Profiles are defined under "Profiles" in the config file, e.g.:
+
+  Profiles:
+    work:
+      Paths: [~/Pictures/company]
+      Tags: [neutral]
+      Fit: {mode: zoom}
+      Effects: dim=0.2
+      SlideShow: office
+      Schedule: {Every: 30m, Mode: random}
+
+Paths replace WallpapersPath and LibraryRoots, Tags limit the library to images with one of
+them and Fit and Effects are used for images without their own. Every field is optional.
+Use a profile for every command with 'backdrop profile use', or for a single command with
+the --profile flag.`,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Use the profile from now on, installing its schedule and showing its slideshow or one of its images.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.UseProfile(os.Stdout, args[0])
+	},
+}
+
+var profileClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Stop using profiles and remove the active profile's schedule.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ClearProfile(os.Stdout)
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles, the one in use is marked with '*'.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ListProfiles(os.Stdout)
+	},
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show the settings of a profile, by default the one in use.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		return internal.ShowProfile(os.Stdout, name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileUseCmd, profileClearCmd, profileListCmd, profileShowCmd)
+}
@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/janmichaelse/backdrop/internal"
@@ -36,8 +37,12 @@ var rootCmd = &cobra.Command{
 	Long: `backdrop is a command-line utility for managing wallpapers on your desktop.
 It allows you to set a new wallpaper, revert to a previous wallpaper, 
 and specify the directory where your wallpaper images are stored.`,
-	Version:      "2.1.0",
-	SilenceUsage: true,
+	Version:       "2.1.0",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return internal.SetOutputFormat(output)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		path, err := cmd.Flags().GetString("path")
@@ -56,7 +61,43 @@ and specify the directory where your wallpaper images are stored.`,
 			return err
 		}
 
+		fit, err := fitFromFlags(cmd)
+		if err != nil {
+			cmd.Usage()
+			return err
+		}
+
+		isPair, err := cmd.Flags().GetBool("pair")
+		if err != nil {
+			cmd.Usage()
+			return err
+		}
+		light, err := cmd.Flags().GetString("light")
+		if err != nil {
+			cmd.Usage()
+			return err
+		}
+		dark, err := cmd.Flags().GetString("dark")
+		if err != nil {
+			cmd.Usage()
+			return err
+		}
+
+		effectSpec, err := cmd.Flags().GetString("effect")
+		if err != nil {
+			cmd.Usage()
+			return err
+		}
+		effects, err := internal.ParseEffects(effectSpec)
+		if err != nil {
+			cmd.Usage()
+			return err
+		}
+
 		config := internal.NewConfig(path, imageUrl, isSlideShow)
+		config.SetFit(fit)
+		config.SetEffects(effects)
+		config.SetPair(isPair, light, dark)
 		return internal.BackdropAction(os.Stdout, config, args)
 	},
 }
@@ -66,38 +107,78 @@ and specify the directory where your wallpaper images are stored.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		internal.WriteError(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-var cfgFile string
+var (
+	cfgFile string
+	profile string
+	output  string
+)
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.backdrop.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "text", `how results and errors are written: text, json or yaml.
+json and yaml share one schema, errors are written to stderr as {"error": {"code", "message"}}`)
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "use the profile for this command only, instead of the one chosen with 'backdrop profile use'")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/backdrop/config.yaml, %APPDATA%\\Backdrop\\config.yaml on Windows)")
 
 	rootCmd.Flags().StringP("path", "p", "", "Set a custom path to find wallpaper images. If not provided, a default path will be used.")
 	rootCmd.Flags().BoolP("slideshow", "s", false, "Will configure and set a custom slideshow of images you select with fzf.\nTo select multiple images hit 'Tab' on the images you desire to select, then hit 'Enter' to confirm.")
 	rootCmd.Flags().BoolP("url", "u", false, `You will be prompted to provide an image url to be set as wallpaper. The image will be downloaded and previewed. 
     If confirmed, the image will be downloaded to the directory were all images are found (check "IMAGES" section). If image is NOT accepted by user, 
     the image gets deleted and previous wallpaper is set.`)
+	rootCmd.Flags().Bool("pair", false, `Select two images with fzf to use as a light and dark mode pair, the darker image is shown in dark mode.
+    To select the images hit 'Tab' on both of them, then hit 'Enter' to confirm.`)
+	rootCmd.Flags().String("light", "", "Image shown in light mode, used together with --dark. Either a path or the name of an image in the wallpapers path.")
+	rootCmd.Flags().String("dark", "", "Image shown in dark mode, used together with --light. Either a path or the name of an image in the wallpapers path.")
+	rootCmd.MarkFlagsRequiredTogether("light", "dark")
+	rootCmd.MarkFlagsMutuallyExclusive("pair", "light")
+	rootCmd.Flags().String("effect", "", `Comma separated effects drawn over a copy of the image, e.g. "blur=8,dim=0.3". The original is left untouched.
+    blur=<radius in pixels>, dim=<0-1>, grayscale[=<0-1>], tint=<#rrggbb>[:<0-1>] and vignette[=<0-1>] are applied in order.
+    Once the change is saved they become the image's effects, "none" removes them.`)
+	addFitFlags(rootCmd)
+}
+
+func addFitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("fit", "", `How the image is scaled: none, wallpaper, centered, scaled, stretched, zoom or spanned.
+    Once the change is saved it becomes the image's default fit. If not provided, the image's default fit is used.`)
+	cmd.Flags().String("fit-color", "", `Color shown around images that do not fill the screen, e.g. "#000000".
+    Two comma separated colors draw a vertical gradient.`)
+}
+
+func fitFromFlags(cmd *cobra.Command) (internal.Fit, error) {
+	mode, err := cmd.Flags().GetString("fit")
+	if err != nil {
+		return internal.Fit{}, err
+	}
+	colors, err := cmd.Flags().GetString("fit-color")
+	if err != nil {
+		return internal.Fit{}, err
+	}
+	return internal.ParseFit(mode, colors)
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	// Moving files must happen before the config is read from its new place.
+	if err := internal.MigrateLegacyLayout(os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not move backdrop's files to the XDG base directories: %v\n", err)
+	}
+
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
-		// Find home directory.
-		home, err := os.UserHomeDir()
+		// $XDG_CONFIG_HOME/backdrop/config.yaml, or %APPDATA%\Backdrop\config.yaml on Windows.
+		configFile, err := internal.DefaultConfigFilePath()
 		cobra.CheckErr(err)
 
-		// Search config in home directory with name ".backdrop" (without extension).
-		viper.AddConfigPath(home)
+		viper.SetConfigFile(configFile)
 		viper.SetConfigType("yaml")
-		viper.SetConfigName(".backdrop")
 	}
 
 	viper.AutomaticEnv() // read in environment variables that match
@@ -106,4 +187,6 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		// fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
+
+	internal.SetProfile(profile)
 }
@@ -0,0 +1,76 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// rulesCmd represents the rules command
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Switch profiles or slideshows by Wi-Fi network, connected displays, weekday or time.",
+	Long: `Rules are defined under "Rules" in the config file and the first rule whose conditions
+all hold is used, e.g.:
+
+  Rules:
+    - Name: office
+      When: {SSID: CorpWiFi}
+      Use: profile work
+    - When: {Outputs: 3}
+      Use: slideshow desk
+    - When: {Weekday: "sat,sun", Time: "09:00-22:00"}
+      Use: profile home
+
+SSID is the connected Wi-Fi network, read from NetworkManager. Outputs is the number of
+connected displays. Weekday is a day, a list or a range such as mon-fri, and Time a range of
+the day such as 22:00-06:00. Use is "profile <name>" or "slideshow <name>".`,
+}
+
+var rulesWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Apply a rule whenever it starts to match, until interrupted.",
+	Long: `Applies a rule whenever it starts to match. A rule is applied once, so changes made by
+hand stay until another rule matches. Run it from your desktop's autostart to keep it in
+the background.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.WatchRules(os.Stdout)
+	},
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Show the current network, displays and time, and which rule would be used right now.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.CheckRules(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesWatchCmd, rulesTestCmd)
+}
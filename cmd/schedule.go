@@ -0,0 +1,129 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+var modesUsage = strings.Join(internal.ScheduleModes, ", ")
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Change wallpapers on a schedule without a running daemon.",
+	Long: `Installs systemd user timers under ~/.config/systemd/user that change the wallpaper
+through the active desktop backend. When systemd is not available a crontab entry is
+installed instead.`,
+}
+
+var scheduleInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a schedule, e.g. --every 30m --mode random or --daily 08:00 --mode daily.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, err := cmd.Flags().GetString("name")
+		if err != nil {
+			return err
+		}
+		every, err := cmd.Flags().GetDuration("every")
+		if err != nil {
+			return err
+		}
+		daily, err := cmd.Flags().GetString("daily")
+		if err != nil {
+			return err
+		}
+		mode, err := cmd.Flags().GetString("mode")
+		if err != nil {
+			return err
+		}
+		slideShow, err := cmd.Flags().GetString("slideshow")
+		if err != nil {
+			return err
+		}
+
+		return internal.InstallSchedule(os.Stdout, &internal.ScheduleOptions{
+			Name:      name,
+			Every:     every,
+			Daily:     daily,
+			Mode:      mode,
+			SlideShow: slideShow,
+		})
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed schedules.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ListSchedules(os.Stdout)
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Stop and remove an installed schedule.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.RemoveSchedule(os.Stdout, args[0])
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Change the wallpaper once, this is what installed schedules run.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, err := cmd.Flags().GetString("mode")
+		if err != nil {
+			return err
+		}
+		slideShow, err := cmd.Flags().GetString("slideshow")
+		if err != nil {
+			return err
+		}
+
+		return internal.RunSchedule(os.Stdout, mode, slideShow)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleInstallCmd, scheduleListCmd, scheduleRemoveCmd, scheduleRunCmd)
+
+	scheduleInstallCmd.Flags().String("name", "", "Name of the schedule. Defaults to the mode.")
+	scheduleInstallCmd.Flags().Duration("every", 0, "Change the wallpaper at this interval, e.g. 30m or 2h.")
+	scheduleInstallCmd.Flags().String("daily", "", "Change the wallpaper every day at this time, e.g. 08:00.")
+	scheduleInstallCmd.Flags().String("mode", "random", "How the next wallpaper is picked: "+modesUsage+".")
+	scheduleInstallCmd.Flags().String("slideshow", "", "Pick images from a saved slideshow instead of the whole wallpapers path.")
+	scheduleInstallCmd.MarkFlagsMutuallyExclusive("every", "daily")
+	scheduleInstallCmd.MarkFlagsOneRequired("every", "daily")
+
+	scheduleRunCmd.Flags().String("mode", "random", "How the next wallpaper is picked: "+modesUsage+".")
+	scheduleRunCmd.Flags().String("slideshow", "", "Pick images from a saved slideshow instead of the whole wallpapers path.")
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local web gallery and JSON API to manage wallpapers.",
+	Long: `Starts an HTTP server with a web gallery of the wallpapers path and a JSON API under /api/
+to list images, set or randomize the wallpaper, read the history and manage saved slideshows.
+Every API request needs the printed token, either as "Authorization: Bearer <token>" or as
+a "token" query parameter. A new token is generated on every start unless --token is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			return err
+		}
+		token, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+
+		return internal.Serve(os.Stdout, &internal.ServeOptions{
+			Listen: listen,
+			Token:  token,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("listen", "127.0.0.1:8080", "Address to listen on. Keep it on localhost unless you trust your network.")
+	serveCmd.Flags().String("token", "", "Token required by the API. Defaults to a random token printed on start.")
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// slideshowCmd represents the slideshow command
+var slideshowCmd = &cobra.Command{
+	Use:   "slideshow",
+	Short: "Manage saved slideshows and GNOME slideshows installed on your system.",
+	Long: `Manage saved slideshows and GNOME slideshows installed on your system.
+Installed slideshows are read from the "gnome-background-properties" lists in your
+user and system data directories. Saved slideshows are registered in backdrop's config file.`,
+}
+
+// slideshowListCmd represents the slideshow list command
+var slideshowListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved and installed slideshows, the active one is marked with '*'.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.ListSlideShows(os.Stdout)
+	},
+}
+
+// slideshowEditCmd represents the slideshow edit command
+var slideshowEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Add or remove images from the active slideshow.",
+	Long: `Opens the library in the fuzzy finder with the images of the active slideshow selected.
+Hit 'Tab' on images to add or remove them, then hit 'Enter' to confirm.
+Slideshows not created by backdrop are imported into backdrop's own slideshow file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.EditSlideShow(os.Stdout)
+	},
+}
+
+// slideshowSaveCmd represents the slideshow save command
+var slideshowSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Create a named slideshow with images you select with fzf.",
+	Long: `Create a named slideshow with images you select with fzf. Saving with an existing name replaces it.
+To select multiple images hit 'Tab' on the images you desire to select, then hit 'Enter' to confirm.
+On Linux the slideshow also shows up with its name in the GNOME Settings background picker.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fit, err := fitFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		config := internal.NewConfig("", false, true)
+		config.SetFit(fit)
+		return internal.SaveSlideShow(os.Stdout, config, args[0])
+	},
+}
+
+// slideshowUseCmd represents the slideshow use command
+var slideshowUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set a saved slideshow as wallpaper.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.UseSlideShow(os.Stdout, args[0])
+	},
+}
+
+// slideshowDeleteCmd represents the slideshow delete command
+var slideshowDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved slideshow and its generated files.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.DeleteSlideShow(os.Stdout, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(slideshowCmd)
+	slideshowCmd.AddCommand(slideshowListCmd)
+	slideshowCmd.AddCommand(slideshowEditCmd)
+	slideshowCmd.AddCommand(slideshowSaveCmd)
+	slideshowCmd.AddCommand(slideshowUseCmd)
+	slideshowCmd.AddCommand(slideshowDeleteCmd)
+	addFitFlags(slideshowSaveCmd)
+}
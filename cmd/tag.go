@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Jan Montalvo
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/janmichaelse/backdrop/internal"
+	"github.com/spf13/cobra"
+)
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Tag images so profiles can pick images by tag.",
+	Long: `Tags are single words saved with the image's metadata. Images are given as a path or as
+their name in the wallpapers path.`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <image> <tag>...",
+	Short: "Add tags to an image.",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.TagImage(os.Stdout, args[0], args[1:])
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "rm <image> <tag>...",
+	Short: "Remove tags from an image.",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return internal.UntagImage(os.Stdout, args[0], args[1:])
+	},
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list [image]",
+	Short: "List the tags of an image, or every tag with the number of images tagged with it.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		image := ""
+		if len(args) == 1 {
+			image = args[0]
+		}
+		return internal.ListTags(os.Stdout, image)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagAddCmd, tagRemoveCmd, tagListCmd)
+}
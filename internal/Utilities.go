@@ -1,10 +1,68 @@
 package internal
 
 import (
+	"bytes"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 )
 
 func commandExist(cmd string) bool {
 	_, err := exec.LookPath(cmd)
 	return err == nil
 }
+
+func commandOutput(name string, args ...string) (string, error) {
+	if !commandExist(name) {
+		return "", fmt.Errorf("%w : %s", ErrCommandNotFound, name)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// writeFileAtomic writes to a temporary file first so readers never see a partial file.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmpFile.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set permissions for %s: %w", path, err)
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// movePath renames a file or directory, copying it when rename fails between file
+// systems, e.g. a home with a separate .local.
+func movePath(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	if err := copyTree(from, to); err != nil {
+		os.RemoveAll(to)
+		return fmt.Errorf("failed to move %s to %s: %w", from, to, err)
+	}
+	if err := os.RemoveAll(from); err != nil {
+		return fmt.Errorf("failed to remove %s after copying it: %w", from, err)
+	}
+	return nil
+}
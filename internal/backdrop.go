@@ -13,12 +13,29 @@ type Config struct {
 	isImageUrl  bool
 	isFuzzy     bool
 	isSlideShow bool
+	isPair      bool
+	light       string
+	dark        string
+	fit         Fit
+	effects     Effects
 }
 
 type SelectionOptions struct {
 	Prompt         string
 	SuccessMessage string
 	Cleanup        func()
+	// Wallpaper and Source are recorded in the history once the change is saved.
+	Wallpaper string
+	Source    string
+	// Fit becomes the wallpaper's default fit once the change is saved.
+	Fit Fit
+	// Dark becomes the wallpaper's dark mode variant once the change is saved.
+	Dark string
+	// Effects become the wallpaper's saved effects once the change is saved, nil
+	// keeps the saved ones.
+	Effects Effects
+	// SourceURL is remembered as where the wallpaper came from once the change is saved.
+	SourceURL string
 }
 
 func NewConfig(path string, isImageUrl, isSlideShow bool) *Config {
@@ -29,9 +46,28 @@ func NewConfig(path string, isImageUrl, isSlideShow bool) *Config {
 	}
 }
 
+// SetFit makes the wallpaper use the fit instead of its default one.
+func (c *Config) SetFit(fit Fit) {
+	c.fit = fit
+}
+
+// SetEffects draws the effects over the wallpaper instead of its saved ones.
+func (c *Config) SetEffects(effects Effects) {
+	c.effects = effects
+}
+
+// SetPair picks a light and dark pair, either with the finder or from the given
+// image files.
+func (c *Config) SetPair(isPair bool, light, dark string) {
+	c.isPair = isPair
+	c.light = light
+	c.dark = dark
+}
+
 var (
-	getSelector       GetFuzzySelector = getFuzzySelector
-	inputConfirmation io.Reader        = os.Stdin
+	getSelector            GetFuzzySelector          = getFuzzySelector
+	getPreselectedSelector PreselectedFuzzySelection = preselectedFuzzySelection
+	inputConfirmation      io.Reader                 = os.Stdin
 )
 
 func BackdropAction(out io.Writer, config *Config, args []string) error {
@@ -47,26 +83,41 @@ func BackdropAction(out io.Writer, config *Config, args []string) error {
 		return err
 	}
 
-	wallpapers, err := getWallpapers(wallpapersPath)
+	wallpapers, err := getLibraryWallpapers()
 	if err != nil {
 		return err
 	}
 
+	if config.isSlideShow && config.effects != nil {
+		return fmt.Errorf("%w : effects can't be drawn over slideshows", ErrInvalidEffect)
+	}
+
 	switch {
 	case config.isSlideShow:
 		imageSelection := getSelector(config)
-		err := handleSlideshow(out, wallpapersPath, wallpapers, imageSelection)
+		err := handleSlideshow(out, wallpapersPath, wallpapers, imageSelection, config.fit)
 		if err != nil {
 			return err
 		}
 	case config.isImageUrl:
-		err := handleImageUrl(out, wallpapersPath)
+		err := handleImageUrl(out, wallpapersPath, config.fit, config.effects)
+		if err != nil {
+			return err
+		}
+	case config.light != "" || config.dark != "":
+		err := handlePairFiles(out, wallpapersPath, config.light, config.dark, config.fit, config.effects)
+		if err != nil {
+			return err
+		}
+	case config.isPair:
+		imageSelection := getSelector(config)
+		err := handlePairSelection(out, wallpapersPath, wallpapers, imageSelection, config.fit, config.effects)
 		if err != nil {
 			return err
 		}
 	default:
 		imageSelection := getSelector(config)
-		err := handleFuzzySearch(out, wallpapersPath, wallpapers, imageSelection)
+		err := handleFuzzySearch(out, wallpapersPath, wallpapers, imageSelection, config.fit, config.effects)
 		if err != nil {
 			return err
 		}
@@ -75,7 +126,7 @@ func BackdropAction(out io.Writer, config *Config, args []string) error {
 	return nil
 }
 
-func handleSelectionConfirmation(previousWallpaper string, out io.Writer, opts *SelectionOptions) (bool, error) {
+func handleSelectionConfirmation(previousState *WallpaperState, out io.Writer, opts *SelectionOptions) (bool, error) {
 	prompt := opts.Prompt
 	if prompt == "" {
 		prompt = "Want to save this change? [y/N]: "
@@ -86,7 +137,7 @@ func handleSelectionConfirmation(previousWallpaper string, out io.Writer, opts *
 		successMessage = "Successfully changed background image!"
 	}
 	for {
-		userInput, err := userConfirmationWithPrompt(inputConfirmation, prompt)
+		userInput, err := userConfirmationWithPrompt(inputConfirmation, out, prompt)
 		if err != nil {
 			return false, err
 		}
@@ -94,9 +145,46 @@ func handleSelectionConfirmation(previousWallpaper string, out io.Writer, opts *
 		switch userInput {
 		case "y":
 			fmt.Fprintln(out, successMessage)
+			if opts.Wallpaper != "" {
+				if err := recordHistory(opts.Wallpaper, opts.Source); err != nil {
+					fmt.Fprintf(out, "Could not record history: %v\n", err)
+				}
+			}
+			if opts.Wallpaper != "" && !opts.Fit.IsZero() {
+				err := updateImageMetadata(opts.Wallpaper, func(metadata *ImageMetadata) {
+					metadata.Fit = opts.Fit
+				})
+				if err != nil {
+					fmt.Fprintf(out, "Could not save fit as the image default: %v\n", err)
+				}
+			}
+			if opts.Wallpaper != "" && opts.Effects != nil {
+				err := updateImageMetadata(opts.Wallpaper, func(metadata *ImageMetadata) {
+					metadata.Effects = opts.Effects.String()
+				})
+				if err != nil {
+					fmt.Fprintf(out, "Could not save the image effects: %v\n", err)
+				}
+			}
+			if opts.Wallpaper != "" && opts.Dark != "" {
+				err := updateImageMetadata(opts.Wallpaper, func(metadata *ImageMetadata) {
+					metadata.Dark = opts.Dark
+				})
+				if err != nil {
+					fmt.Fprintf(out, "Could not save the dark mode variant: %v\n", err)
+				}
+			}
+			if opts.Wallpaper != "" && opts.SourceURL != "" {
+				err := updateImageMetadata(opts.Wallpaper, func(metadata *ImageMetadata) {
+					metadata.SourceURL = opts.SourceURL
+				})
+				if err != nil {
+					fmt.Fprintf(out, "Could not save where the image came from: %v\n", err)
+				}
+			}
 			return true, nil
 		case "n", "":
-			if err := setWallpaper(previousWallpaper); err != nil {
+			if err := previousState.Restore(); err != nil {
 				return false, err
 			}
 
@@ -108,9 +196,9 @@ func handleSelectionConfirmation(previousWallpaper string, out io.Writer, opts *
 	}
 }
 
-func userConfirmationWithPrompt(r io.Reader, prompt string) (string, error) {
+func userConfirmationWithPrompt(r io.Reader, out io.Writer, prompt string) (string, error) {
 	reader := bufio.NewReader(r)
-	fmt.Print(prompt)
+	fmt.Fprint(out, prompt)
 
 	input, err := reader.ReadString('\n')
 	if err != nil {
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// allOutputs targets every output of a backend.
+const allOutputs = ""
+
+// wallpaperBackend applies wallpapers through a desktop specific mechanism.
+// Backends that cannot address outputs individually report a single output
+// and apply every image to the whole desktop. SetFit only changes the non empty
+// fields of the fit, CurrentFit leaves unknown fields empty.
+type wallpaperBackend interface {
+	Name() string
+	Outputs() ([]string, error)
+	SetWallpaper(output, wallpaper string) error
+	CurrentWallpaper() (string, error)
+	SetFit(output string, fit Fit) error
+	CurrentFit() (Fit, error)
+}
+
+// outputWallpapersBackend is implemented by backends that can show a different image
+// on every output.
+type outputWallpapersBackend interface {
+	CurrentWallpapers() (map[string]string, error)
+}
+
+var getBackend = detectBackend
+
+func detectBackend() (wallpaperBackend, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return windowsBackend{}, nil
+	case "linux":
+		return detectLinuxBackend()
+	}
+	return nil, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+}
+
+func detectLinuxBackend() (wallpaperBackend, error) {
+	schemas, schemasErr := listSchemas()
+	if schemasErr == nil {
+		if strings.Contains(schemas.String(), gnomeSchema) {
+			return gsettingsBackend{schema: gnomeSchema}, nil
+		}
+
+		if strings.Contains(schemas.String(), mateSchema) {
+			return gsettingsBackend{schema: mateSchema}, nil
+		}
+	}
+
+	desktop := strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP"))
+	switch {
+	case strings.Contains(desktop, "XFCE") && commandExist("xfconf-query"):
+		return xfceBackend{}, nil
+	case strings.Contains(desktop, "KDE") && commandExist("plasma-apply-wallpaperimage"):
+		return kdeBackend{}, nil
+	case os.Getenv("WAYLAND_DISPLAY") != "" && commandExist("swww"):
+		return swwwBackend{}, nil
+	case os.Getenv("DISPLAY") != "" && commandExist("feh"):
+		return fehBackend{}, nil
+	}
+
+	if schemasErr != nil {
+		return nil, schemasErr
+	}
+	return nil, ErrNoCompatibleDesktopEnvironment
+}
+
+type gsettingsBackend struct {
+	schema string
+}
+
+func (b gsettingsBackend) Name() string {
+	if b.schema == mateSchema {
+		return "mate"
+	}
+	return "gnome"
+}
+
+func (b gsettingsBackend) Outputs() ([]string, error) {
+	return []string{allOutputs}, nil
+}
+
+func (b gsettingsBackend) SetWallpaper(output, wallpaper string) error {
+	return setGsettingsWallpaper(b.schema, wallpaper)
+}
+
+// HasDarkVariant reports whether GNOME switches between the images of a pair itself,
+// MATE has a single picture key.
+func (b gsettingsBackend) HasDarkVariant() bool {
+	return b.schema == gnomeSchema
+}
+
+func (b gsettingsBackend) SetWallpaperPair(output, light, dark string) error {
+	return setGsettingsWallpaperPair(b.schema, light, dark)
+}
+
+// DrawsColors is true for GNOME and MATE, both draw primary-color and
+// secondary-color when picture-options is 'none'.
+func (gsettingsBackend) DrawsColors() bool {
+	return true
+}
+
+func (b gsettingsBackend) CurrentWallpaper() (string, error) {
+	return getGsettingsWallpaper(b.schema)
+}
+
+func (b gsettingsBackend) SetFit(output string, fit Fit) error {
+	return setGsettingsFit(b.schema, fit)
+}
+
+func (b gsettingsBackend) CurrentFit() (Fit, error) {
+	return getGsettingsFit(b.schema)
+}
+
+func (b gsettingsBackend) CaptureSettings() (map[string]string, error) {
+	return captureGsettings(b.schema)
+}
+
+func (b gsettingsBackend) RestoreSettings(settings map[string]string) error {
+	return restoreGsettings(b.schema, settings)
+}
+
+type windowsBackend struct{}
+
+func (windowsBackend) Name() string {
+	return "windows"
+}
+
+func (windowsBackend) Outputs() ([]string, error) {
+	return []string{allOutputs}, nil
+}
+
+func (windowsBackend) SetWallpaper(output, wallpaper string) error {
+	return setWallpaperWindows(wallpaper)
+}
+
+func (windowsBackend) CurrentWallpaper() (string, error) {
+	return getPreviousWallpaperWindows()
+}
+
+func (windowsBackend) SetFit(output string, fit Fit) error {
+	return setWindowsFit(fit)
+}
+
+func (windowsBackend) CurrentFit() (Fit, error) {
+	return getWindowsFit()
+}
@@ -0,0 +1,652 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+const xfceChannel = "xfce4-desktop"
+
+// xfceBackend sets the "last-image" property of every XFCE monitor and workspace.
+type xfceBackend struct{}
+
+func (xfceBackend) Name() string {
+	return "xfce"
+}
+
+func (b xfceBackend) Outputs() ([]string, error) {
+	properties, err := b.imageProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var outputs []string
+	for _, property := range properties {
+		output := xfceMonitorName(property)
+		if output != "" && !seen[output] {
+			seen[output] = true
+			outputs = append(outputs, output)
+		}
+	}
+
+	if len(outputs) == 0 {
+		return []string{allOutputs}, nil
+	}
+	return outputs, nil
+}
+
+func (b xfceBackend) SetWallpaper(output, wallpaper string) error {
+	properties, err := b.imageProperties()
+	if err != nil {
+		return err
+	}
+
+	for _, property := range properties {
+		if output != allOutputs && xfceMonitorName(property) != output {
+			continue
+		}
+
+		cmd := exec.Command("xfconf-query", "-c", xfceChannel, "-p", property, "-s", wallpaper)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+		}
+	}
+
+	return nil
+}
+
+// DrawsColors is true as XFCE draws rgba1 and rgba2 with the image-style "none".
+func (xfceBackend) DrawsColors() bool {
+	return true
+}
+
+func (b xfceBackend) CurrentWallpaper() (string, error) {
+	properties, err := b.imageProperties()
+	if err != nil {
+		return "", err
+	}
+
+	if len(properties) == 0 {
+		return "", nil
+	}
+
+	wallpaper, err := commandOutput("xfconf-query", "-c", xfceChannel, "-p", properties[0])
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(wallpaper), nil
+}
+
+// CurrentWallpapers reads the image of every monitor, from its first workspace.
+func (b xfceBackend) CurrentWallpapers() (map[string]string, error) {
+	properties, err := b.imageProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	wallpapers := make(map[string]string)
+	for _, property := range properties {
+		output := xfceMonitorName(property)
+		if _, ok := wallpapers[output]; ok {
+			continue
+		}
+
+		wallpaper, err := commandOutput("xfconf-query", "-c", xfceChannel, "-p", property)
+		if err != nil {
+			return nil, err
+		}
+		wallpapers[output] = strings.TrimSpace(wallpaper)
+	}
+	return wallpapers, nil
+}
+
+// xfceImageStyles and xfceColorStyles are indexed by XFCE's image-style and
+// color-style values.
+var (
+	xfceImageStyles = []string{"none", "centered", "wallpaper", "stretched", "scaled", "zoom", "spanned"}
+	xfceColorStyles = []string{"solid", "horizontal", "vertical"}
+)
+
+func (b xfceBackend) SetFit(output string, fit Fit) error {
+	properties, err := b.imageProperties()
+	if err != nil {
+		return err
+	}
+
+	for _, property := range properties {
+		if output != allOutputs && xfceMonitorName(property) != output {
+			continue
+		}
+
+		base := strings.TrimSuffix(property, "/last-image")
+		var settings [][]string
+		if index := slices.Index(xfceImageStyles, fit.Mode); index >= 0 {
+			settings = append(settings, []string{"-p", base + "/image-style", "-n", "-t", "int", "-s", strconv.Itoa(index)})
+		}
+		if index := slices.Index(xfceColorStyles, fit.Shading); index >= 0 {
+			settings = append(settings, []string{"-p", base + "/color-style", "-n", "-t", "int", "-s", strconv.Itoa(index)})
+		}
+		for property, color := range map[string]string{"/rgba1": fit.PrimaryColor, "/rgba2": fit.SecondaryColor} {
+			if color == "" {
+				continue
+			}
+			args, err := xfceColorArgs(base+property, color)
+			if err != nil {
+				return err
+			}
+			settings = append(settings, args)
+		}
+
+		for _, args := range settings {
+			cmd := exec.Command("xfconf-query", append([]string{"-c", xfceChannel}, args...)...)
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (b xfceBackend) CurrentFit() (Fit, error) {
+	properties, err := b.imageProperties()
+	if err != nil || len(properties) == 0 {
+		return Fit{}, err
+	}
+
+	base := strings.TrimSuffix(properties[0], "/last-image")
+	var fit Fit
+	if index, err := b.intProperty(base + "/image-style"); err == nil && index >= 0 && index < len(xfceImageStyles) {
+		fit.Mode = xfceImageStyles[index]
+	}
+	if index, err := b.intProperty(base + "/color-style"); err == nil && index >= 0 && index < len(xfceColorStyles) {
+		fit.Shading = xfceColorStyles[index]
+	}
+	fit.PrimaryColor = b.colorProperty(base + "/rgba1")
+	fit.SecondaryColor = b.colorProperty(base + "/rgba2")
+	return fit, nil
+}
+
+func (xfceBackend) intProperty(property string) (int, error) {
+	value, err := commandOutput("xfconf-query", "-c", xfceChannel, "-p", property)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(value))
+}
+
+// colorProperty reads an rgba array, printed by xfconf-query as a header followed
+// by one component per line.
+func (xfceBackend) colorProperty(property string) string {
+	value, err := commandOutput("xfconf-query", "-c", xfceChannel, "-p", property)
+	if err != nil {
+		return ""
+	}
+
+	var components []float64
+	for _, line := range strings.Split(value, "\n") {
+		if component, err := strconv.ParseFloat(strings.TrimSpace(line), 64); err == nil {
+			components = append(components, component)
+		}
+	}
+	if len(components) < 3 {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", uint8(components[0]*255+0.5), uint8(components[1]*255+0.5), uint8(components[2]*255+0.5))
+}
+
+func xfceColorArgs(property, color string) ([]string, error) {
+	r, g, b, err := os_Specifics.ParseHexColor(color)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-p", property, "-n"}
+	for _, component := range []float64{float64(r) / 255, float64(g) / 255, float64(b) / 255, 1} {
+		args = append(args, "-t", "double", "-s", strconv.FormatFloat(component, 'f', 6, 64))
+	}
+	return args, nil
+}
+
+func (xfceBackend) imageProperties() ([]string, error) {
+	list, err := commandOutput("xfconf-query", "-c", xfceChannel, "-l")
+	if err != nil {
+		return nil, err
+	}
+
+	var properties []string
+	for _, property := range strings.Fields(list) {
+		if strings.HasSuffix(property, "/last-image") {
+			properties = append(properties, property)
+		}
+	}
+	return properties, nil
+}
+
+// xfceMonitorName extracts "eDP-1" from "/backdrop/screen0/monitoreDP-1/workspace0/last-image".
+func xfceMonitorName(property string) string {
+	for _, segment := range strings.Split(property, "/") {
+		if strings.HasPrefix(segment, "monitor") {
+			return strings.TrimPrefix(segment, "monitor")
+		}
+	}
+	return ""
+}
+
+// kdeBackend uses the Plasma wallpaper tool, which applies to every screen.
+type kdeBackend struct{}
+
+func (kdeBackend) Name() string {
+	return "kde"
+}
+
+func (kdeBackend) Outputs() ([]string, error) {
+	return []string{allOutputs}, nil
+}
+
+func (kdeBackend) SetWallpaper(output, wallpaper string) error {
+	if err := exec.Command("plasma-apply-wallpaperimage", wallpaper).Run(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	}
+	return nil
+}
+
+func (b kdeBackend) CurrentWallpaper() (string, error) {
+	image, err := b.appletsValue("Image")
+	return strings.TrimPrefix(image, "file://"), err
+}
+
+// kdeFillModes is indexed by Plasma's FillMode values, the names are what
+// plasma-apply-wallpaperimage accepts for --fill-mode.
+var kdeFillModes = []string{"stretch", "preserveAspectFit", "preserveAspectCrop", "tile", "tileVertically", "tileHorizontally", "pad"}
+
+// SetFit only supports the mode, Plasma's wallpaper tool cannot change the color.
+func (b kdeBackend) SetFit(output string, fit Fit) error {
+	var fillMode string
+	switch fit.Mode {
+	case "":
+		return nil
+	case "stretched":
+		fillMode = "stretch"
+	case "scaled":
+		fillMode = "preserveAspectFit"
+	case "wallpaper":
+		fillMode = "tile"
+	case "none", "centered":
+		fillMode = "pad"
+	default:
+		fillMode = "preserveAspectCrop"
+	}
+
+	wallpaper, err := b.CurrentWallpaper()
+	if err != nil {
+		return err
+	}
+
+	if err := exec.Command("plasma-apply-wallpaperimage", "--fill-mode", fillMode, wallpaper).Run(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	}
+	return nil
+}
+
+func (b kdeBackend) CurrentFit() (Fit, error) {
+	value, err := b.appletsValue("FillMode")
+	if err != nil || value == "" {
+		return Fit{}, err
+	}
+
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 || index >= len(kdeFillModes) {
+		return Fit{}, nil
+	}
+
+	mode := map[string]string{
+		"stretch":            "stretched",
+		"preserveAspectFit":  "scaled",
+		"preserveAspectCrop": "zoom",
+		"tile":               "wallpaper",
+		"tileVertically":     "wallpaper",
+		"tileHorizontally":   "wallpaper",
+		"pad":                "centered",
+	}[kdeFillModes[index]]
+	return Fit{Mode: mode}, nil
+}
+
+// appletsValue returns the first value of the key in Plasma's desktop configuration.
+func (kdeBackend) appletsValue(key string) (string, error) {
+	configPath, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filepath.Join(configPath, "plasma-org.kde.plasma.desktop-appletsrc"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, found := strings.CutPrefix(line, key+"="); found {
+			return value, nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// swwwBackend drives the swww daemon found on wlroots based Wayland compositors.
+type swwwBackend struct{}
+
+func (swwwBackend) Name() string {
+	return "swww"
+}
+
+func (b swwwBackend) Outputs() ([]string, error) {
+	outputs, err := b.query()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		names = append(names, output[0])
+	}
+	return names, nil
+}
+
+func (swwwBackend) SetWallpaper(output, wallpaper string) error {
+	args := []string{"img", wallpaper}
+	if output != allOutputs {
+		args = append(args, "--outputs", output)
+	}
+
+	if err := exec.Command("swww", args...).Run(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	}
+	return nil
+}
+
+func (b swwwBackend) CurrentWallpaper() (string, error) {
+	outputs, err := b.query()
+	if err != nil {
+		return "", err
+	}
+
+	if len(outputs) == 0 {
+		return "", nil
+	}
+	return outputs[0][1], nil
+}
+
+func (b swwwBackend) CurrentWallpapers() (map[string]string, error) {
+	outputs, err := b.query()
+	if err != nil {
+		return nil, err
+	}
+
+	wallpapers := make(map[string]string, len(outputs))
+	for _, output := range outputs {
+		wallpapers[output[0]] = output[1]
+	}
+	return wallpapers, nil
+}
+
+// SetFit shows the current images again, swww takes the resize mode and fill color
+// along with the image and cannot report them afterwards.
+func (b swwwBackend) SetFit(output string, fit Fit) error {
+	outputs, err := b.query()
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	switch fit.Mode {
+	case "":
+	case "scaled":
+		args = append(args, "--resize", "fit")
+	case "stretched":
+		args = append(args, "--resize", "stretch")
+	case "none", "centered":
+		args = append(args, "--resize", "no")
+	default:
+		args = append(args, "--resize", "crop")
+	}
+	if fit.PrimaryColor != "" {
+		args = append(args, "--fill-color", strings.TrimPrefix(fit.PrimaryColor, "#"))
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	for _, current := range outputs {
+		if (output != allOutputs && current[0] != output) || current[1] == "" {
+			continue
+		}
+
+		cmdArgs := append([]string{"img", current[1], "--outputs", current[0]}, args...)
+		if err := exec.Command("swww", cmdArgs...).Run(); err != nil {
+			return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+		}
+	}
+	return nil
+}
+
+func (swwwBackend) CurrentFit() (Fit, error) {
+	return Fit{}, nil
+}
+
+// CaptureSettings keeps the image of every output, outputs can show different images.
+func (b swwwBackend) CaptureSettings() (map[string]string, error) {
+	outputs, err := b.query()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]string, len(outputs))
+	for _, output := range outputs {
+		if output[1] != "" {
+			settings[output[0]] = output[1]
+		}
+	}
+	return settings, nil
+}
+
+func (b swwwBackend) RestoreSettings(settings map[string]string) error {
+	for output, wallpaper := range settings {
+		if err := b.SetWallpaper(output, wallpaper); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutputResolution reads the size swww reports for the output, the largest one for
+// all outputs.
+func (swwwBackend) OutputResolution(output string) (image.Point, error) {
+	out, err := commandOutput("swww", "query")
+	if err != nil {
+		return image.Point{}, err
+	}
+
+	var largest image.Point
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), ": ")
+		name, details, found := strings.Cut(line, ":")
+		if !found || (output != allOutputs && strings.TrimSpace(name) != output) {
+			continue
+		}
+
+		resolution, _, _ := strings.Cut(strings.TrimSpace(details), ",")
+		size, err := parseResolution(resolution)
+		if err != nil {
+			continue
+		}
+		if size.X*size.Y > largest.X*largest.Y {
+			largest = size
+		}
+	}
+
+	if largest == (image.Point{}) {
+		return image.Point{}, fmt.Errorf("swww reports no resolution for output '%s'", output)
+	}
+	return largest, nil
+}
+
+// query returns the output name and displayed image for every line of "swww query",
+// e.g. "eDP-1: 1920x1080, scale: 1, currently displaying: image: /path/to/image.jpg".
+func (swwwBackend) query() ([][2]string, error) {
+	out, err := commandOutput("swww", "query")
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs [][2]string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), ": ")
+		name, details, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		var image string
+		if _, displayed, found := strings.Cut(details, "image: "); found {
+			image = strings.TrimSpace(displayed)
+		}
+		outputs = append(outputs, [2]string{strings.TrimSpace(name), image})
+	}
+	return outputs, nil
+}
+
+// fehBackend is the fallback for X11 window managers without a desktop of their own.
+type fehBackend struct{}
+
+func (fehBackend) Name() string {
+	return "feh"
+}
+
+func (fehBackend) Outputs() ([]string, error) {
+	return []string{allOutputs}, nil
+}
+
+// fehModes maps fit modes to feh's background options.
+var fehModes = map[string]string{
+	"none":      "--bg-center",
+	"centered":  "--bg-center",
+	"wallpaper": "--bg-tile",
+	"scaled":    "--bg-max",
+	"stretched": "--bg-scale",
+	"zoom":      "--bg-fill",
+	"spanned":   "--bg-fill",
+}
+
+var fehOptionModes = map[string]string{
+	"--bg-center": "centered",
+	"--bg-tile":   "wallpaper",
+	"--bg-max":    "scaled",
+	"--bg-scale":  "stretched",
+	"--bg-fill":   "zoom",
+}
+
+// SetWallpaper keeps the mode and color of ~/.fehbg, feh resets them on every call.
+func (b fehBackend) SetWallpaper(output, wallpaper string) error {
+	fit, _ := b.CurrentFit()
+	return b.run(wallpaper, fit)
+}
+
+func (b fehBackend) CurrentWallpaper() (string, error) {
+	fields, err := b.fehbg()
+	if err != nil || len(fields) == 0 {
+		return "", err
+	}
+	return fields[len(fields)-1], nil
+}
+
+func (b fehBackend) SetFit(output string, fit Fit) error {
+	wallpaper, err := b.CurrentWallpaper()
+	if err != nil {
+		return err
+	}
+
+	current, _ := b.CurrentFit()
+	if fit.Mode == "" {
+		fit.Mode = current.Mode
+	}
+	if fit.PrimaryColor == "" {
+		fit.PrimaryColor = current.PrimaryColor
+	}
+	return b.run(wallpaper, fit)
+}
+
+func (b fehBackend) CurrentFit() (Fit, error) {
+	fields, err := b.fehbg()
+	if err != nil {
+		return Fit{}, err
+	}
+
+	var fit Fit
+	for i, field := range fields {
+		if mode, found := fehOptionModes[field]; found {
+			fit.Mode = mode
+		}
+		if field == "--image-bg" && i+1 < len(fields) {
+			fit.PrimaryColor = fields[i+1]
+		}
+	}
+	return fit, nil
+}
+
+func (fehBackend) run(wallpaper string, fit Fit) error {
+	option, found := fehModes[fit.Mode]
+	if !found {
+		option = "--bg-fill"
+	}
+
+	args := []string{option}
+	if fit.PrimaryColor != "" {
+		args = append(args, "--image-bg", fit.PrimaryColor)
+	}
+	args = append(args, wallpaper)
+
+	if err := exec.Command("feh", args...).Run(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	}
+	return nil
+}
+
+// fehbg returns the unquoted arguments of the feh command in the "~/.fehbg" script
+// feh writes, the last one is the image.
+func (fehBackend) fehbg() ([]string, error) {
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(homePath, ".fehbg"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "feh ") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			fields[i] = strings.Trim(field, "'\"")
+		}
+		return fields, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalServiceName       = "org.freedesktop.portal.Desktop"
+	portalObjectPath        = "/org/freedesktop/portal/desktop"
+	portalSettingsInterface = "org.freedesktop.portal.Settings"
+	appearanceNamespace     = "org.freedesktop.appearance"
+	colorSchemeKey          = "color-scheme"
+)
+
+// ColorScheme is the appearance preference published by the XDG desktop portal.
+type ColorScheme uint32
+
+const (
+	ColorSchemeDefault ColorScheme = iota
+	ColorSchemeDark
+	ColorSchemeLight
+)
+
+func (c ColorScheme) String() string {
+	switch c {
+	case ColorSchemeDark:
+		return "dark"
+	case ColorSchemeLight:
+		return "light"
+	default:
+		return "default"
+	}
+}
+
+var getColorScheme = readPortalColorScheme
+
+func readPortalColorScheme() (ColorScheme, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return ColorSchemeDefault, err
+	}
+
+	portal := conn.Object(portalServiceName, portalObjectPath)
+	var value dbus.Variant
+	err = portal.Call(portalSettingsInterface+".ReadOne", 0, appearanceNamespace, colorSchemeKey).Store(&value)
+	if err != nil {
+		// Portals older than version 2 only have the deprecated Read method.
+		if readErr := portal.Call(portalSettingsInterface+".Read", 0, appearanceNamespace, colorSchemeKey).Store(&value); readErr != nil {
+			return ColorSchemeDefault, fmt.Errorf("failed to read the color scheme from the desktop portal: %w", err)
+		}
+	}
+
+	return colorSchemeFromVariant(value)
+}
+
+// colorSchemeFromVariant unwraps the value, Read nests it in a second variant.
+func colorSchemeFromVariant(value dbus.Variant) (ColorScheme, error) {
+	for {
+		switch v := value.Value().(type) {
+		case dbus.Variant:
+			value = v
+		case uint32:
+			return ColorScheme(v), nil
+		default:
+			return ColorSchemeDefault, fmt.Errorf("unexpected color scheme value %s", value)
+		}
+	}
+}
+
+// watchColorScheme calls onChange with every new color scheme until the context is
+// canceled.
+func watchColorScheme(ctx context.Context, conn *dbus.Conn, onChange func(ColorScheme)) error {
+	options := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(portalObjectPath),
+		dbus.WithMatchInterface(portalSettingsInterface),
+		dbus.WithMatchMember("SettingChanged"),
+		dbus.WithMatchArg(0, appearanceNamespace),
+	}
+	if err := conn.AddMatchSignal(options...); err != nil {
+		return err
+	}
+	defer conn.RemoveMatchSignal(options...)
+
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case signal := <-signals:
+			if signal.Name != portalSettingsInterface+".SettingChanged" || len(signal.Body) != 3 {
+				continue
+			}
+
+			namespace, _ := signal.Body[0].(string)
+			key, _ := signal.Body[1].(string)
+			value, _ := signal.Body[2].(dbus.Variant)
+			if namespace != appearanceNamespace || key != colorSchemeKey {
+				continue
+			}
+
+			if colorScheme, err := colorSchemeFromVariant(value); err == nil {
+				onChange(colorScheme)
+			}
+		}
+	}
+}
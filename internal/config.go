@@ -0,0 +1,558 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const wallpapersPathConfigKey = "WallpapersPath"
+
+// Settings is the schema of the config file, every key backdrop reads is a field.
+// Keys are matched without case, viper writes them in lower case.
+type Settings struct {
+	WallpapersPath string             `mapstructure:"WallpapersPath" yaml:"WallpapersPath,omitempty" json:"WallpapersPath,omitempty"`
+	LibraryRoots   []string           `mapstructure:"LibraryRoots" yaml:"LibraryRoots,omitempty" json:"LibraryRoots,omitempty"`
+	SlideShows     []SavedSlideShow   `mapstructure:"SlideShows" yaml:"SlideShows,omitempty" json:"SlideShows,omitempty"`
+	Processing     ProcessingOptions  `mapstructure:"Processing" yaml:"Processing,omitempty" json:"Processing,omitempty"`
+	Palette        PaletteOptions     `mapstructure:"Palette" yaml:"Palette,omitempty" json:"Palette,omitempty"`
+	Overlay        OverlayOptions     `mapstructure:"Overlay" yaml:"Overlay,omitempty" json:"Overlay,omitempty"`
+	ActiveProfile  string             `mapstructure:"ActiveProfile" yaml:"ActiveProfile,omitempty" json:"ActiveProfile,omitempty"`
+	Profiles       map[string]Profile `mapstructure:"Profiles" yaml:"Profiles,omitempty" json:"Profiles,omitempty"`
+	Rules          []Rule             `mapstructure:"Rules" yaml:"Rules,omitempty" json:"Rules,omitempty"`
+}
+
+func (s Settings) validate() error {
+	for _, root := range s.LibraryRoots {
+		if strings.TrimSpace(root) == "" {
+			return fmt.Errorf("%w : LibraryRoots can't contain empty paths", ErrInvalidConfig)
+		}
+	}
+
+	for _, slideShow := range s.SlideShows {
+		if os_Specifics.SlideShowFileName(slideShow.Name) == "" {
+			return fmt.Errorf("%w : slideshow '%s' : %v", ErrInvalidConfig, slideShow.Name, ErrInvalidSlideShowName)
+		}
+		if slideShow.Duration <= 0 {
+			return fmt.Errorf("%w : slideshow '%s' needs a positive duration", ErrInvalidConfig, slideShow.Name)
+		}
+		if err := validateFit(slideShow.Fit); err != nil {
+			return fmt.Errorf("%w : slideshow '%s' : %v", ErrInvalidConfig, slideShow.Name, err)
+		}
+	}
+
+	for _, err := range []error{s.Processing.validate(), s.Overlay.validate()} {
+		if err != nil {
+			return fmt.Errorf("%w : %v", ErrInvalidConfig, err)
+		}
+	}
+
+	for name, profile := range s.Profiles {
+		if err := profile.validate(s.SlideShows); err != nil {
+			return fmt.Errorf("%w : profile '%s' : %v", ErrInvalidConfig, name, err)
+		}
+	}
+	if s.ActiveProfile != "" {
+		if _, _, err := findProfile(s, s.ActiveProfile); err != nil {
+			return fmt.Errorf("%w : ActiveProfile : %v", ErrInvalidConfig, err)
+		}
+	}
+
+	for i, rule := range s.Rules {
+		if err := rule.validate(s); err != nil {
+			return fmt.Errorf("%w : rule %s : %v", ErrInvalidConfig, rule.describe(i), err)
+		}
+	}
+	return nil
+}
+
+func loadSettings() (Settings, error) {
+	var settings Settings
+	if err := viper.Unmarshal(&settings); err != nil {
+		return Settings{}, fmt.Errorf("%w : %v", ErrInvalidConfig, err)
+	}
+	return settings, nil
+}
+
+// parseSettings reads a config file strictly, unknown keys and values of the wrong
+// type are errors instead of being ignored.
+func parseSettings(content []byte) (Settings, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return Settings{}, fmt.Errorf("%w : %v", ErrInvalidConfig, err)
+	}
+
+	var settings Settings
+	if err := v.Unmarshal(&settings, func(config *mapstructure.DecoderConfig) { config.ErrorUnused = true }); err != nil {
+		return Settings{}, fmt.Errorf("%w : %v", ErrInvalidConfig, err)
+	}
+	return settings, settings.validate()
+}
+
+// saveSettings validates the settings and writes the top level setting of the key to
+// the config file.
+func saveSettings(settings Settings, key string) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	topLevel, _, _ := strings.Cut(key, ".")
+	viper.Set(topLevel, reflect.ValueOf(settings).FieldByName(topLevel).Interface())
+	return writeConfig()
+}
+
+// DefaultConfigFilePath returns the config file read when --config is not given.
+func DefaultConfigFilePath() (string, error) {
+	return getConfigFilePath()
+}
+
+// configFilePath returns the config file in use, or the one that will be written.
+func configFilePath() (string, error) {
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+	return getConfigFilePath()
+}
+
+// findConfigField resolves a dotted key such as "processing.crop" to its field in
+// the settings, without case. It returns the field and the key as it is spelled in
+// the schema.
+func findConfigField(settings *Settings, key string) (reflect.Value, string, error) {
+	value := reflect.ValueOf(settings).Elem()
+	var names []string
+	for _, part := range strings.Split(key, ".") {
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, "", fmt.Errorf("%w : %s", ErrUnknownConfigKey, key)
+		}
+
+		fields := reflect.VisibleFields(value.Type())
+		index := slices.IndexFunc(fields, func(field reflect.StructField) bool {
+			return strings.EqualFold(field.Name, part) || strings.EqualFold(field.Tag.Get("mapstructure"), part)
+		})
+		if index < 0 {
+			return reflect.Value{}, "", fmt.Errorf("%w : %s", ErrUnknownConfigKey, key)
+		}
+
+		names = append(names, fields[index].Name)
+		value = value.FieldByIndex(fields[index].Index)
+	}
+	return value, strings.Join(names, "."), nil
+}
+
+// configKeys lists the keys of every setting that holds a value, e.g. "Overlay.Size".
+func configKeys(value reflect.Value, prefix string) []string {
+	var keys []string
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		key := prefix + field.Name
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(value.FieldByIndex(field.Index), key+".")...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func formatConfigValue(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return fmt.Sprint(value.Interface()), nil
+	case reflect.Slice:
+		if strs, ok := value.Interface().([]string); ok {
+			return strings.Join(strs, "\n"), nil
+		}
+	}
+
+	content, err := yaml.Marshal(value.Interface())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(content), "\n"), nil
+}
+
+func setConfigValue(value reflect.Value, key string, args []string) error {
+	if value.Kind() != reflect.Slice && len(args) != 1 {
+		return fmt.Errorf("%w : %s takes a single value", ErrInvalidConfig, key)
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(args[0])
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(args[0])
+		if err != nil {
+			return fmt.Errorf("%w : %s expects true or false, got '%s'", ErrInvalidConfig, key, args[0])
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w : %s expects a whole number, got '%s'", ErrInvalidConfig, key, args[0])
+		}
+		value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return fmt.Errorf("%w : %s expects a number, got '%s'", ErrInvalidConfig, key, args[0])
+		}
+		value.SetFloat(parsed)
+	default:
+		if _, ok := value.Interface().([]string); !ok {
+			return fmt.Errorf("%w : %s can't be set from the command line, use 'backdrop config edit'", ErrInvalidConfig, key)
+		}
+		value.Set(reflect.ValueOf(slices.Clone(args)))
+	}
+	return nil
+}
+
+// configOrigin tells where the effective value of the key comes from. Viper reads
+// environment variables named like the top level keys before the config file.
+func configOrigin(file *viper.Viper, key string) string {
+	topLevel, _, _ := strings.Cut(key, ".")
+	if _, ok := os.LookupEnv(strings.ToUpper(topLevel)); ok {
+		return "env " + strings.ToUpper(topLevel)
+	}
+	if file != nil && file.IsSet(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// ConfigOutput is 'backdrop config show' in structured formats.
+type ConfigOutput struct {
+	ConfigFile   string             `json:"config_file"`
+	ConfigOrigin string             `json:"config_origin"`
+	Settings     []ConfigSetting    `json:"settings"`
+	LibraryRoots []LibraryRootState `json:"library_roots"`
+}
+
+type ConfigSetting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// LibraryRootState is a configured library root, State is "used", "overridden" or
+// "missing" and only used roots have a name.
+type LibraryRootState struct {
+	State  string `json:"state"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Origin string `json:"origin"`
+}
+
+// ShowConfig prints every setting with the origin of its value, then every library
+// root in order of precedence with the ones images are listed from marked as used.
+func ShowConfig(out io.Writer, configFlag bool) error {
+	configPath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	origin := "default"
+	if configFlag {
+		origin = "flag --config"
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	var file *viper.Viper
+	if content, err := os.ReadFile(configPath); err == nil {
+		file = viper.New()
+		file.SetConfigType("yaml")
+		if err := file.ReadConfig(bytes.NewReader(content)); err != nil {
+			file = nil
+		}
+	}
+
+	roots, used, err := libraryRootStates()
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput() {
+		output := ConfigOutput{ConfigFile: configPath, ConfigOrigin: origin, LibraryRoots: roots}
+		for _, key := range configKeys(reflect.ValueOf(settings), "") {
+			value, _, _ := findConfigField(&settings, key)
+			output.Settings = append(output.Settings, ConfigSetting{Key: key, Value: value.Interface(), Origin: configOrigin(file, key)})
+		}
+		return writeStructured(out, output)
+	}
+
+	fmt.Fprintf(out, "Config file:\t%s (%s)\n\n", configPath, origin)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, key := range configKeys(reflect.ValueOf(settings), "") {
+		value, _, _ := findConfigField(&settings, key)
+
+		var formatted string
+		switch slideShows := value.Interface().(type) {
+		case []SavedSlideShow:
+			formatted = fmt.Sprintf("%d saved", len(slideShows))
+		case map[string]Profile:
+			formatted = fmt.Sprintf("%d defined", len(slideShows))
+		case []Rule:
+			formatted = fmt.Sprintf("%d defined", len(slideShows))
+		case []string:
+			formatted = strings.Join(slideShows, ", ")
+		default:
+			if formatted, err = formatConfigValue(value); err != nil {
+				return err
+			}
+		}
+		if formatted == "" {
+			formatted = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, formatted, configOrigin(file, key))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Library roots, the first origin with an existing directory is used:")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  STATE\tNAME\tPATH\tORIGIN")
+	for _, root := range roots {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", root.State, orDash(root.Name), root.Path, root.Origin)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if used > 1 {
+		fmt.Fprintln(out, "\nImages are named after their root, e.g. \"<name>/image.jpg\".")
+	}
+	if used == 0 {
+		fmt.Fprintln(out, "\nNone of the library roots exist, create one or set one with --path.")
+	}
+	return nil
+}
+
+// libraryRootStates returns every configured library root in order of precedence and
+// the number of roots images are listed from.
+func libraryRootStates() ([]LibraryRootState, int, error) {
+	groups, err := libraryRootGroups()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	states := []LibraryRootState{}
+	var roots []LibraryRoot
+	for _, group := range groups {
+		used := roots == nil
+		if used {
+			roots = existingLibraryRoots(group)
+			if len(roots) == 0 {
+				roots, used = nil, false
+			}
+		}
+
+		for _, root := range group {
+			path := filepath.Clean(expandHome(root.Path))
+			index := slices.IndexFunc(roots, func(existing LibraryRoot) bool { return existing.Path == path })
+
+			state := LibraryRootState{State: "overridden", Path: root.Path, Origin: root.Origin}
+			switch {
+			case used && index >= 0:
+				state.State, state.Name = "used", roots[index].Name
+			case !isDirectory(path):
+				state.State = "missing"
+			}
+			states = append(states, state)
+		}
+	}
+	return states, len(roots), nil
+}
+
+func GetConfig(out io.Writer, key string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	value, _, err := findConfigField(&settings, key)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := formatConfigValue(value)
+	if err != nil {
+		return err
+	}
+	if formatted != "" {
+		fmt.Fprintln(out, formatted)
+	}
+	return nil
+}
+
+// SetConfig sets a single value, lists such as LibraryRoots take every value given.
+// Nothing is written unless the resulting config is valid.
+func SetConfig(out io.Writer, key string, values []string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	value, name, err := findConfigField(&settings, key)
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(value, name, values); err != nil {
+		return err
+	}
+	if err := saveSettings(settings, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Set %s.\n", name)
+	return nil
+}
+
+// UnsetConfig puts the setting, or every setting under it, back to its default.
+func UnsetConfig(out io.Writer, key string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	value, name, err := findConfigField(&settings, key)
+	if err != nil {
+		return err
+	}
+	if _, ok := value.Interface().([]SavedSlideShow); ok {
+		return fmt.Errorf("%w : remove slideshows with 'backdrop slideshow delete'", ErrInvalidConfig)
+	}
+
+	value.Set(reflect.Zero(value.Type()))
+	if err := saveSettings(settings, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Unset %s.\n", name)
+	return nil
+}
+
+func ConfigPath(out io.Writer) error {
+	configPath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, configPath)
+	return nil
+}
+
+// editorCommand opens the file in $VISUAL or $EDITOR, which may include arguments
+// such as "code --wait".
+var editorCommand = func(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args := append(strings.Fields(editor), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd
+}
+
+// EditConfig opens a copy of the config file in the editor and only replaces the
+// config file once the copy is valid, so a typo can't break backdrop.
+func EditConfig(out io.Writer) error {
+	configPath, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		settings, err := loadSettings()
+		if err != nil {
+			return err
+		}
+		if content, err = yaml.Marshal(settings); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	draft, err := os.CreateTemp("", "backdrop-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(draft.Name())
+	if _, err := draft.Write(content); err != nil {
+		draft.Close()
+		return err
+	}
+	if err := draft.Close(); err != nil {
+		return err
+	}
+
+	for {
+		if err := editorCommand(draft.Name()).Run(); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+
+		edited, err := os.ReadFile(draft.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(edited, content) {
+			fmt.Fprintln(out, "No changes made.")
+			return nil
+		}
+
+		_, err = parseSettings(edited)
+		if err == nil {
+			if err := writeFileAtomic(configPath, edited, 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Saved %s.\n", configPath)
+			return nil
+		}
+
+		fmt.Fprintln(out, err)
+		answer, err := userConfirmationWithPrompt(inputConfirmation, out, "Edit again? [Y/n]: ")
+		if err != nil {
+			return err
+		}
+		if answer == "n" || answer == "no" {
+			fmt.Fprintln(out, "Discarded the changes.")
+			return nil
+		}
+	}
+}
+
+func isDirectory(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseSettings(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		expErr  error
+	}{
+		{name: "Valid", content: "wallpaperspath: /images\nprocessing:\n  crop: thirds\nslideshows:\n  - name: Work\n    images: [a.jpg]\n    duration: 60000\n"},
+		{name: "UnknownKey", content: "wallpaperpath: /images\n", expErr: ErrInvalidConfig},
+		{name: "WrongType", content: "libraryroots:\n  nested: true\n", expErr: ErrInvalidConfig},
+		{name: "InvalidCrop", content: "processing:\n  crop: sideways\n", expErr: ErrInvalidConfig},
+		{name: "InvalidSlideShowFit", content: "slideshows:\n  - name: Work\n    duration: 1\n    fit:\n      mode: huge\n", expErr: ErrInvalidConfig},
+		{name: "Syntax", content: "processing: [\n", expErr: ErrInvalidConfig},
+		{name: "Profile", content: "activeprofile: Work\nprofiles:\n  work:\n    paths: [/office]\n    tags: [neutral]\n    schedule:\n      every: 30m\n"},
+		{name: "InvalidProfileEffects", content: "profiles:\n  work:\n    effects: sparkles\n", expErr: ErrInvalidConfig},
+		{name: "ProfileSlideShowNotFound", content: "profiles:\n  work:\n    slideshow: office\n", expErr: ErrInvalidConfig},
+		{name: "InvalidProfileSchedule", content: "profiles:\n  work:\n    schedule:\n      every: 10s\n", expErr: ErrInvalidConfig},
+		{name: "ActiveProfileNotFound", content: "activeprofile: home\n", expErr: ErrInvalidConfig},
+		{name: "Rules", content: "profiles:\n  work: {tags: [neutral]}\nrules:\n  - when: {ssid: CorpWiFi, weekday: mon-fri}\n    use: profile work\n"},
+		{name: "RuleProfileNotFound", content: "rules:\n  - when: {outputs: 3}\n    use: profile work\n", expErr: ErrInvalidConfig},
+		{name: "InvalidRuleUse", content: "rules:\n  - use: wallpaper lake.jpg\n", expErr: ErrInvalidConfig},
+		{name: "InvalidRuleTime", content: "rules:\n  - when: {time: evenings}\n    use: slideshow x\n", expErr: ErrInvalidConfig},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if _, err := parseSettings([]byte(testCase.content)); !errors.Is(err, testCase.expErr) {
+				t.Errorf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigGetSetUnset(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	original := viper.AllSettings()
+	defer func() {
+		for key, value := range original {
+			viper.Set(key, value)
+		}
+	}()
+	for _, key := range []string{"Processing", "LibraryRoots", "Overlay"} {
+		viper.Set(key, nil)
+	}
+
+	get := func(key string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := GetConfig(&out, key); err != nil {
+			t.Fatalf("Expected NO error getting '%s', but got '%v' instead", key, err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	var out bytes.Buffer
+	if err := SetConfig(&out, "processing.CROP", []string{"entropy"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if got := get("Processing.Crop"); got != "entropy" {
+		t.Errorf("Expected 'entropy', but got '%s' instead", got)
+	}
+
+	if err := SetConfig(&out, "LibraryRoots", []string{"/images", "nas=/mnt/nas"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if got := get("libraryroots"); got != "/images\nnas=/mnt/nas" {
+		t.Errorf("Expected both roots, but got '%s' instead", got)
+	}
+
+	for _, testCase := range []struct {
+		key    string
+		values []string
+		expErr error
+	}{
+		{key: "Processing.Crop", values: []string{"sideways"}, expErr: ErrInvalidConfig},
+		{key: "Overlay.Size", values: []string{"big"}, expErr: ErrInvalidConfig},
+		{key: "Palette.Enabled", values: []string{"true", "false"}, expErr: ErrInvalidConfig},
+		{key: "SlideShows", values: []string{"Work"}, expErr: ErrInvalidConfig},
+		{key: "Processing.Crop.Mode", values: []string{"x"}, expErr: ErrUnknownConfigKey},
+		{key: "Wallpapers", values: []string{"x"}, expErr: ErrUnknownConfigKey},
+	} {
+		if err := SetConfig(&out, testCase.key, testCase.values); !errors.Is(err, testCase.expErr) {
+			t.Errorf("Expected error '%v' setting '%s', but got '%v' instead", testCase.expErr, testCase.key, err)
+		}
+	}
+	if got := get("Processing.Crop"); got != "entropy" {
+		t.Errorf("Expected an invalid value to leave 'entropy', but got '%s' instead", got)
+	}
+
+	if err := UnsetConfig(&out, "processing"); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if got := get("Processing.Crop"); got != "" {
+		t.Errorf("Expected no crop after unsetting, but got '%s' instead", got)
+	}
+
+	content, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".config", "backdrop", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseSettings(content); err != nil {
+		t.Errorf("Expected the written config to be valid, but got '%v'", err)
+	}
+}
+
+func TestEditConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	configPath, err := configFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("wallpaperspath: /images\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	originalEditor, originalInput := editorCommand, inputConfirmation
+	defer func() { editorCommand, inputConfirmation = originalEditor, originalInput }()
+
+	edits := []string{"wallpaperspath: [\n", "wallpaperspath: /photos\n"}
+	editorCommand = func(path string) *exec.Cmd {
+		edit := edits[0]
+		edits = edits[1:]
+		return exec.Command("sh", "-c", `printf '%s' "$1" > "$2"`, "sh", edit, path)
+	}
+	inputConfirmation = strings.NewReader("y\n")
+
+	var out bytes.Buffer
+	if err := EditConfig(&out); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if !strings.Contains(out.String(), ErrInvalidConfig.Error()) {
+		t.Errorf("Expected the invalid edit to be reported, but got '%s'", out.String())
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "wallpaperspath: /photos\n" {
+		t.Errorf("Expected the valid edit to be saved, but got '%s'", content)
+	}
+}
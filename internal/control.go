@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// ControlCommands lists the commands a running daemon accepts on its control socket.
+var ControlCommands = []string{"next", "prev", "pause", "resume", "status", "reload", "set"}
+
+// controlRequest and controlResponse are exchanged as one JSON document per line.
+type controlRequest struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+type controlResponse struct {
+	OK     bool           `json:"ok"`
+	Error  string         `json:"error,omitempty"`
+	Status []OutputStatus `json:"status,omitempty"`
+}
+
+func getControlSocketPath() (string, error) {
+	runtimePath, err := getRuntimePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runtimePath, "backdrop.sock"), nil
+}
+
+// listenControl replaces any stale socket left behind by a daemon that did not exit
+// cleanly. Callers must hold the daemon pidfile.
+func listenControl() (net.Listener, error) {
+	socketPath, err := getControlSocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale control socket %s: %w", socketPath, err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on control socket %s: %w", socketPath, err)
+	}
+
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions for control socket %s: %w", socketPath, err)
+	}
+
+	return listener, nil
+}
+
+// serveControl accepts connections until the context is canceled.
+func (d *daemon) serveControl(ctx context.Context, listener net.Listener, reload chan<- os.Signal) {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(d.out, "Control socket stopped: %v\n", err)
+			}
+			return
+		}
+		go d.handleControlConn(conn, reload)
+	}
+}
+
+func (d *daemon) handleControlConn(conn net.Conn, reload chan<- os.Signal) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req controlRequest
+		var response controlResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			response = controlResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			response = d.handleControl(req, reload)
+		}
+
+		if err := encoder.Encode(response); err != nil {
+			return
+		}
+	}
+}
+
+func (d *daemon) handleControl(req controlRequest, reload chan<- os.Signal) controlResponse {
+	var err error
+	switch req.Command {
+	case "next":
+		err = d.scheduler.step(1)
+	case "prev":
+		err = d.scheduler.step(-1)
+	case "pause":
+		d.scheduler.pause()
+	case "resume":
+		d.scheduler.resume()
+		err = d.scheduler.applyAll()
+	case "reload":
+		select {
+		case reload <- syscall.SIGHUP:
+		default:
+		}
+	case "set":
+		if len(req.Args) != 1 {
+			err = fmt.Errorf("set expects exactly one image")
+			break
+		}
+		if stat, statErr := os.Stat(req.Args[0]); statErr != nil || !stat.Mode().IsRegular() {
+			err = fmt.Errorf("image %s is not a regular file", req.Args[0])
+			break
+		}
+		err = d.scheduler.show(req.Args[0])
+	case "status":
+	default:
+		err = fmt.Errorf("%w : %s", ErrUnknownControlCommand, req.Command)
+	}
+
+	if err != nil {
+		return controlResponse{Error: err.Error()}
+	}
+	return controlResponse{OK: true, Status: d.scheduler.status()}
+}
+
+// SendControlCommand sends a command to the running daemon and prints its status.
+func SendControlCommand(out io.Writer, command string, args []string) error {
+	if command == "set" {
+		for i, arg := range args {
+			absArg, err := filepath.Abs(arg)
+			if err != nil {
+				return err
+			}
+			args[i] = absArg
+		}
+	}
+
+	response, err := sendControlRequest(controlRequest{Command: command, Args: args})
+	if err != nil {
+		return err
+	}
+
+	if !response.OK {
+		return errors.New(response.Error)
+	}
+
+	printControlStatus(out, response.Status)
+	return nil
+}
+
+func sendControlRequest(req controlRequest) (*controlResponse, error) {
+	socketPath, err := getControlSocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrDaemonNotRunning, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send control command: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read control response: %w", err)
+	}
+
+	var response controlResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		return nil, fmt.Errorf("invalid control response: %w", err)
+	}
+
+	return &response, nil
+}
+
+func printControlStatus(out io.Writer, statuses []OutputStatus) {
+	for _, status := range statuses {
+		output := status.Output
+		if output == allOutputs {
+			output = "All outputs"
+		}
+
+		fmt.Fprintf(out, "%s: %s (%d/%d)\n", output, status.Image, status.Position, status.Total)
+		if status.Paused {
+			fmt.Fprintln(out, "    Paused")
+		} else {
+			fmt.Fprintf(out, "    Next change at %s\n", status.NextChange.Local().Format(time.DateTime))
+		}
+	}
+}
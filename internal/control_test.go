@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestControlSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	images := make([]string, 0, len(testPlaylist))
+	imagesPath := t.TempDir()
+	for _, image := range testPlaylist {
+		image = filepath.Join(imagesPath, image)
+		if err := os.WriteFile(image, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, image)
+	}
+
+	clock := newFakeClock()
+	backend := newFakeBackend(allOutputs)
+	d := newDaemon(&bytes.Buffer{}, &DaemonOptions{Interval: 10 * time.Minute}, backend, clock)
+	d.scheduler.load(images, []string{allOutputs})
+
+	listener, err := listenControl()
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reload := make(chan os.Signal, 1)
+	go d.serveControl(ctx, listener, reload)
+
+	testCases := []struct {
+		command   string
+		args      []string
+		expOutput string
+		expImage  string
+	}{
+		{command: "next", expOutput: "(2/3)", expImage: images[1]},
+		{command: "next", expOutput: "(3/3)", expImage: images[2]},
+		{command: "prev", expOutput: "(2/3)", expImage: images[1]},
+		{command: "pause", expOutput: "Paused"},
+		{command: "set", args: []string{images[0]}, expOutput: "(1/3)", expImage: images[0]},
+		{command: "resume", expOutput: "Next change at"},
+		{command: "status", expOutput: images[0]},
+	}
+
+	for _, testCase := range testCases {
+		var out bytes.Buffer
+		if err := SendControlCommand(&out, testCase.command, testCase.args); err != nil {
+			t.Fatalf("Expected NO error for '%s', but got '%v' instead", testCase.command, err)
+		}
+
+		if !strings.Contains(out.String(), testCase.expOutput) {
+			t.Errorf("Expected output '%v' for '%s', but got '%v' instead", testCase.expOutput, testCase.command, out.String())
+		}
+
+		if testCase.expImage != "" {
+			backend.expect(t, fakeWallpaper{allOutputs, testCase.expImage})
+		}
+		backend.expectNothing(t)
+	}
+
+	if err := SendControlCommand(&bytes.Buffer{}, "reload", nil); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	select {
+	case <-reload:
+	case <-time.After(time.Second):
+		t.Errorf("Expected reload to be requested")
+	}
+
+	if err := SendControlCommand(&bytes.Buffer{}, "shuffle", nil); err == nil {
+		t.Errorf("Expected error for unknown command, but got NO error")
+	}
+}
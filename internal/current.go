@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// CurrentWallpaper is the image shown on one output.
+type CurrentWallpaper struct {
+	// Output is empty when the backend shows the image on every output.
+	Output string `json:"output"`
+	// Path is the library image, Displayed the processed copy shown in its place.
+	Path      string   `json:"path"`
+	Displayed string   `json:"displayed,omitempty"`
+	Backend   string   `json:"backend"`
+	Fit       string   `json:"fit,omitempty"`
+	InLibrary bool     `json:"in_library"`
+	Name      string   `json:"name,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	SourceURL string   `json:"source_url,omitempty"`
+	Width     int      `json:"width,omitempty"`
+	Height    int      `json:"height,omitempty"`
+}
+
+// currentWallpapers returns the image of every output, or a single image for
+// backends that show one image everywhere.
+func currentWallpapers() ([]CurrentWallpaper, error) {
+	backend, err := getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	displayed := map[string]string{}
+	if outputsBackend, ok := backend.(outputWallpapersBackend); ok {
+		if displayed, err = outputsBackend.CurrentWallpapers(); err != nil {
+			return nil, err
+		}
+	} else {
+		wallpaper, err := backend.CurrentWallpaper()
+		if err != nil {
+			return nil, err
+		}
+		displayed[allOutputs] = wallpaper
+	}
+
+	fit, err := backend.CurrentFit()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := loadMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]string, 0, len(displayed))
+	for output := range displayed {
+		outputs = append(outputs, output)
+	}
+	slices.Sort(outputs)
+
+	wallpapers := make([]CurrentWallpaper, 0, len(outputs))
+	for _, output := range outputs {
+		if displayed[output] == "" {
+			continue
+		}
+
+		wallpaper := CurrentWallpaper{
+			Output:  output,
+			Path:    sourceWallpaper(displayed[output]),
+			Backend: backend.Name(),
+			Fit:     fit.Mode,
+		}
+		if wallpaper.Path != displayed[output] {
+			wallpaper.Displayed = displayed[output]
+		}
+		wallpaper.Name, wallpaper.InLibrary = libraryName(wallpaper.Path)
+		wallpaper.Tags = metadata[wallpaper.Path].Tags
+		wallpaper.SourceURL = metadata[wallpaper.Path].SourceURL
+		if size, err := imageSize(wallpaper.Path); err == nil {
+			wallpaper.Width, wallpaper.Height = size.X, size.Y
+		}
+		wallpapers = append(wallpapers, wallpaper)
+	}
+
+	if len(wallpapers) == 0 {
+		return nil, fmt.Errorf("%w : the %s backend reports no wallpaper", ErrImageNotFound, backend.Name())
+	}
+	return wallpapers, nil
+}
+
+// imageSize reads the dimensions from the image header without decoding it.
+func imageSize(path string) (image.Point, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return image.Point{}, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return image.Point{}, err
+	}
+	return image.Pt(config.Width, config.Height), nil
+}
+
+// ShowCurrent prints the wallpaper of every output with what backdrop knows about
+// it, or only the image paths.
+func ShowCurrent(out io.Writer, pathOnly bool) error {
+	wallpapers, err := currentWallpapers()
+	if err != nil {
+		return err
+	}
+
+	if pathOnly {
+		for _, wallpaper := range wallpapers {
+			fmt.Fprintln(out, wallpaper.Path)
+		}
+		return nil
+	}
+	if isStructuredOutput() {
+		return writeStructured(out, wallpapers)
+	}
+
+	for i, wallpaper := range wallpapers {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		output := wallpaper.Output
+		if output == allOutputs {
+			output = "all"
+		}
+		library := "no"
+		if wallpaper.InLibrary {
+			library = "yes, " + wallpaper.Name
+		}
+		size := "-"
+		if wallpaper.Width > 0 {
+			size = fmt.Sprintf("%dx%d", wallpaper.Width, wallpaper.Height)
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Output:\t%s\n", output)
+		fmt.Fprintf(w, "Image:\t%s\n", wallpaper.Path)
+		if wallpaper.Displayed != "" {
+			fmt.Fprintf(w, "Displayed:\t%s\n", wallpaper.Displayed)
+		}
+		fmt.Fprintf(w, "Backend:\t%s\n", wallpaper.Backend)
+		fmt.Fprintf(w, "Fit:\t%s\n", orDash(wallpaper.Fit))
+		fmt.Fprintf(w, "Library:\t%s\n", library)
+		fmt.Fprintf(w, "Tags:\t%s\n", orDash(strings.Join(wallpaper.Tags, ", ")))
+		fmt.Fprintf(w, "Source URL:\t%s\n", orDash(wallpaper.SourceURL))
+		fmt.Fprintf(w, "Size:\t%s\n", size)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var revealInFileManager = revealFile
+
+// OpenCurrent shows the current wallpapers in the file manager.
+func OpenCurrent(out io.Writer) error {
+	wallpapers, err := currentWallpapers()
+	if err != nil {
+		return err
+	}
+
+	var revealed []string
+	for _, wallpaper := range wallpapers {
+		if slices.Contains(revealed, wallpaper.Path) {
+			continue
+		}
+		revealed = append(revealed, wallpaper.Path)
+
+		if err := revealInFileManager(wallpaper.Path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Opened %s in the file manager.\n", wallpaper.Path)
+	}
+	return nil
+}
+
+// revealFile selects the file in Explorer, xdg-open can only open its directory.
+func revealFile(path string) error {
+	if runtime.GOOS == "windows" {
+		// Explorer exits with 1 even when it opened the window.
+		exec.Command("explorer", "/select,"+path).Run()
+		return nil
+	}
+
+	if !commandExist("xdg-open") {
+		return fmt.Errorf("%w : xdg-open", ErrCommandNotFound)
+	}
+	return exec.Command("xdg-open", filepath.Dir(path)).Start()
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestShowCurrent(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(imagePathEnv, "")
+	library := t.TempDir()
+	originalPath := viper.Get(wallpapersPathConfigKey)
+	defer viper.Set(wallpapersPathConfigKey, originalPath)
+	viper.Set(wallpapersPathConfigKey, library)
+	defer SetOutputFormat("text")
+
+	image := filepath.Join(library, "logo.png")
+	writeTestPNG(t, image, color.White)
+	if err := updateImageMetadata(image, func(metadata *ImageMetadata) {
+		metadata.Tags = []string{"neutral"}
+		metadata.SourceURL = "https://example.com/logo.png"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := newFakeBackend(allOutputs)
+	backend.current = image
+	backend.currentFit = Fit{Mode: "zoom"}
+	originalGetBackend := getBackend
+	getBackend = func() (wallpaperBackend, error) { return backend, nil }
+	defer func() { getBackend = originalGetBackend }()
+
+	var out bytes.Buffer
+	if err := ShowCurrent(&out, true); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if out.String() != image+"\n" {
+		t.Errorf("Expected only the path, but got '%s'", out.String())
+	}
+
+	out.Reset()
+	if err := ShowCurrent(&out, false); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	for _, exp := range []string{"Output:      all", "Backend:     fake", "Fit:         zoom", "Library:     yes, logo.png", "Size:        8x8"} {
+		if !bytes.Contains(out.Bytes(), []byte(exp)) {
+			t.Errorf("Expected the output to contain '%s', but got '%s'", exp, out.String())
+		}
+	}
+
+	out.Reset()
+	SetOutputFormat("json")
+	if err := ShowCurrent(&out, false); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	var wallpapers []CurrentWallpaper
+	if err := json.Unmarshal(out.Bytes(), &wallpapers); err != nil {
+		t.Fatalf("Expected JSON, but got '%s': %v", out.String(), err)
+	}
+	if len(wallpapers) != 1 || !wallpapers[0].InLibrary || !slices.Equal(wallpapers[0].Tags, []string{"neutral"}) || wallpapers[0].SourceURL != "https://example.com/logo.png" {
+		t.Errorf("Expected the library image with its tags and source, but got %+v", wallpapers)
+	}
+
+	backend.current = filepath.Join(t.TempDir(), "elsewhere.png")
+	out.Reset()
+	if err := ShowCurrent(&out, false); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &wallpapers); err != nil || wallpapers[0].InLibrary {
+		t.Errorf("Expected an image outside the library, but got %+v, '%v'", wallpapers, err)
+	}
+}
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/spf13/viper"
+)
+
+type DaemonOptions struct {
+	SlideShow string
+	Interval  time.Duration
+	Shuffle   bool
+}
+
+// daemon keeps a scheduler running and rebuilds its playlist on reload.
+type daemon struct {
+	out       io.Writer
+	opts      *DaemonOptions
+	backend   wallpaperBackend
+	scheduler *scheduler
+}
+
+func newDaemon(out io.Writer, opts *DaemonOptions, backend wallpaperBackend, clock clock) *daemon {
+	return &daemon{
+		out:       out,
+		opts:      opts,
+		backend:   backend,
+		scheduler: newScheduler(out, backend, clock, opts.Interval),
+	}
+}
+
+func RunDaemon(out io.Writer, opts *DaemonOptions) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("invalid interval: please enter a positive duration")
+	}
+
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+
+	pidFile, err := acquirePidFile()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pidFile)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	d := newDaemon(out, opts, backend, realClock{})
+	d.scheduler.addOnChange(func(output, image string) {
+		if err := recordHistory(image, historySourceDaemon); err != nil {
+			fmt.Fprintf(out, "Could not record history: %v\n", err)
+		}
+	})
+
+	listener, err := listenControl()
+	if err != nil {
+		return err
+	}
+	go d.serveControl(ctx, listener, reload)
+
+	if conn, err := dbus.ConnectSessionBus(); err != nil {
+		fmt.Fprintf(out, "D-Bus session bus not available, skipping D-Bus service: %v\n", err)
+	} else {
+		defer conn.Close()
+		if err := d.startDBusService(conn); err != nil {
+			return err
+		}
+		if !hasDarkVariant(backend) {
+			go d.watchColorScheme(ctx, conn)
+		}
+	}
+
+	fmt.Fprintf(out, "Backdrop daemon started using the %s backend, rotating every %v.\n", backend.Name(), opts.Interval)
+	return d.run(ctx, reload)
+}
+
+func (d *daemon) run(ctx context.Context, reload <-chan os.Signal) error {
+	for {
+		if err := d.load(); err != nil {
+			return err
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			d.scheduler.run(runCtx)
+		}()
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return nil
+		case <-reload:
+			cancel()
+			<-done
+			fmt.Fprintln(d.out, "Reloading playlist...")
+		}
+	}
+}
+
+func (d *daemon) load() error {
+	images, err := loadDaemonPlaylist(d.opts)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := d.backend.Outputs()
+	if err != nil {
+		return err
+	}
+
+	d.scheduler.load(images, outputs)
+	return nil
+}
+
+// watchColorScheme switches paired images to their other variant when the desktop
+// changes between light and dark mode.
+func (d *daemon) watchColorScheme(ctx context.Context, conn *dbus.Conn) {
+	err := watchColorScheme(ctx, conn, func(colorScheme ColorScheme) {
+		fmt.Fprintf(d.out, "Color scheme changed to %s.\n", colorScheme)
+		d.scheduler.logError(allOutputs, d.scheduler.reapply())
+	})
+	if err != nil {
+		fmt.Fprintf(d.out, "Could not watch the color scheme, paired images won't switch: %v\n", err)
+	}
+}
+
+// loadDaemonPlaylist re-reads the config file so a reload picks up new saved
+// slideshows or a new wallpapers path.
+func loadDaemonPlaylist(opts *DaemonOptions) ([]string, error) {
+	if err := viper.ReadInConfig(); err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var images []string
+	if opts.SlideShow != "" {
+		slideShow, err := findSavedSlideShow(opts.SlideShow)
+		if err != nil {
+			return nil, err
+		}
+		images = slideShow.Images
+	} else {
+		libraryImages, err := getLibraryImages()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, libraryImage := range libraryImages {
+			images = append(images, libraryImage.Path)
+		}
+	}
+
+	if len(images) == 0 {
+		return nil, ErrEmptyPlaylist
+	}
+
+	if opts.Shuffle {
+		rand.Shuffle(len(images), func(i, j int) {
+			images[i], images[j] = images[j], images[i]
+		})
+	}
+
+	return images, nil
+}
+
+// acquirePidFile writes the daemon pidfile, refusing to start when the process it
+// names is still alive.
+func acquirePidFile() (string, error) {
+	runtimePath, err := getRuntimePath()
+	if err != nil {
+		return "", err
+	}
+
+	pidFile := filepath.Join(runtimePath, "backdrop.pid")
+	if pid, err := readPidFile(pidFile); err == nil && processExists(pid) {
+		return "", fmt.Errorf("%w : pid %d", ErrDaemonAlreadyRunning, pid)
+	}
+
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write pidfile %s: %w", pidFile, err)
+	}
+
+	return pidFile, nil
+}
+
+func readPidFile(pidFile string) (int, error) {
+	content, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	dbusServiceName = "org.backdrop.Wallpaper1"
+	dbusObjectPath  = dbus.ObjectPath("/org/backdrop/Wallpaper1")
+	dbusInterface   = "org.backdrop.Wallpaper1"
+)
+
+// dbusService exposes the daemon on the session bus. Every exported method is a
+// D-Bus method of the org.backdrop.Wallpaper1 interface.
+type dbusService struct {
+	daemon *daemon
+}
+
+func (s *dbusService) Set(image string) *dbus.Error {
+	if stat, err := os.Stat(image); err != nil || !stat.Mode().IsRegular() {
+		return dbus.MakeFailedError(fmt.Errorf("image %s is not a regular file", image))
+	}
+	return dbusError(s.daemon.scheduler.show(image))
+}
+
+func (s *dbusService) Next() *dbus.Error {
+	return dbusError(s.daemon.scheduler.step(1))
+}
+
+func (s *dbusService) Previous() *dbus.Error {
+	return dbusError(s.daemon.scheduler.step(-1))
+}
+
+func (s *dbusService) Random() *dbus.Error {
+	return dbusError(s.daemon.scheduler.random())
+}
+
+func (s *dbusService) GetCurrent() (string, *dbus.Error) {
+	statuses := s.daemon.scheduler.status()
+	if len(statuses) == 0 {
+		return "", nil
+	}
+	return statuses[0].Image, nil
+}
+
+func (s *dbusService) ListImages() ([]string, *dbus.Error) {
+	return s.daemon.scheduler.playlist(), nil
+}
+
+func dbusError(err error) *dbus.Error {
+	if err == nil {
+		return nil
+	}
+	return dbus.MakeFailedError(err)
+}
+
+// startDBusService exports the service on the connection, claims the well known
+// name and emits WallpaperChanged on every change the scheduler makes.
+func (d *daemon) startDBusService(conn *dbus.Conn) error {
+	service := &dbusService{daemon: d}
+	if err := conn.Export(service, dbusObjectPath, dbusInterface); err != nil {
+		return fmt.Errorf("failed to export D-Bus service: %w", err)
+	}
+
+	node := &introspect.Node{
+		Name: string(dbusObjectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			{
+				Name:    dbusInterface,
+				Methods: introspect.Methods(service),
+				Signals: []introspect.Signal{{
+					Name: "WallpaperChanged",
+					Args: []introspect.Arg{
+						{Name: "output", Type: "s"},
+						{Name: "image", Type: "s"},
+					},
+				}},
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), dbusObjectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return fmt.Errorf("failed to export D-Bus introspection: %w", err)
+	}
+
+	reply, err := conn.RequestName(dbusServiceName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("failed to request D-Bus name %s: %w", dbusServiceName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("%w : %s", ErrDaemonAlreadyRunning, dbusServiceName)
+	}
+
+	d.scheduler.addOnChange(func(output, image string) {
+		conn.Emit(dbusObjectPath, dbusInterface+".WallpaperChanged", output, image)
+	})
+	return nil
+}
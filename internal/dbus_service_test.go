@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const testBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startTestSessionBus launches a private dbus-daemon so tests never touch the
+// user's session bus.
+func startTestSessionBus(t *testing.T) string {
+	t.Helper()
+
+	if !commandExist("dbus-daemon") {
+		t.Skip("dbus-daemon is not available")
+	}
+
+	busPath := t.TempDir()
+	configFile := filepath.Join(busPath, "session.conf")
+	config := fmt.Sprintf(testBusConfig, filepath.Join(busPath, "bus"))
+	if err := os.WriteFile(configFile, []byte(config), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("dbus-daemon", "--config-file="+configFile, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Error starting dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Error reading dbus-daemon address: %v", err)
+	}
+
+	return strings.TrimSpace(address)
+}
+
+func connectTestSessionBus(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("Error connecting to test session bus: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+func TestDBusService(t *testing.T) {
+	address := startTestSessionBus(t)
+	serviceConn := connectTestSessionBus(t, address)
+	clientConn := connectTestSessionBus(t, address)
+
+	images := make([]string, 0, len(testPlaylist))
+	imagesPath := t.TempDir()
+	for _, image := range testPlaylist {
+		image = filepath.Join(imagesPath, image)
+		if err := os.WriteFile(image, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, image)
+	}
+
+	backend := newFakeBackend(allOutputs)
+	d := newDaemon(&bytes.Buffer{}, &DaemonOptions{Interval: 10 * time.Minute}, backend, newFakeClock())
+	d.scheduler.load(images, []string{allOutputs})
+
+	if err := d.startDBusService(serviceConn); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	if err := clientConn.AddMatchSignal(dbus.WithMatchInterface(dbusInterface), dbus.WithMatchMember("WallpaperChanged")); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	clientConn.Signal(signals)
+
+	expectSignal := func(expImage string) {
+		t.Helper()
+
+		select {
+		case signal := <-signals:
+			if len(signal.Body) != 2 || signal.Body[1] != expImage {
+				t.Errorf("Expected WallpaperChanged for '%v', but got '%v' instead", expImage, signal.Body)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected WallpaperChanged for '%v', but got no signal", expImage)
+		}
+	}
+
+	object := clientConn.Object(dbusServiceName, dbusObjectPath)
+
+	if err := object.Call(dbusInterface+".Next", 0).Err; err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, images[1]})
+	expectSignal(images[1])
+
+	if err := object.Call(dbusInterface+".Previous", 0).Err; err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, images[0]})
+	expectSignal(images[0])
+
+	if err := object.Call(dbusInterface+".Set", 0, images[2]).Err; err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, images[2]})
+	expectSignal(images[2])
+
+	var current string
+	if err := object.Call(dbusInterface+".GetCurrent", 0).Store(&current); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if current != images[2] {
+		t.Errorf("Expected current wallpaper '%v', but got '%v' instead", images[2], current)
+	}
+
+	if err := object.Call(dbusInterface+".Random", 0).Err; err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	select {
+	case got := <-backend.applied:
+		if got.wallpaper == images[2] {
+			t.Errorf("Expected Random to change the wallpaper, but got '%v' again", got.wallpaper)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Random to change the wallpaper")
+	}
+
+	var listed []string
+	if err := object.Call(dbusInterface+".ListImages", 0).Store(&listed); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if strings.Join(listed, ";") != strings.Join(images, ";") {
+		t.Errorf("Expected images '%v', but got '%v' instead", images, listed)
+	}
+
+	if err := object.Call(dbusInterface+".Set", 0, filepath.Join(imagesPath, "missing.jpg")).Err; err == nil {
+		t.Errorf("Expected error setting a missing image, but got NO error")
+	}
+}
@@ -0,0 +1,295 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+const (
+	effectsQuality = 92
+	maxBlurRadius  = 100
+	// fixedOne is 1.0 in the fixed point weights effects use, integer math keeps the
+	// output the same on every platform.
+	fixedOne = 256
+)
+
+var EffectNames = []string{"blur", "dim", "grayscale", "tint", "vignette"}
+
+// Effect is a single step such as "blur=8" or "tint=#ff8800:0.4".
+type Effect struct {
+	Name  string
+	Value string
+}
+
+func (e Effect) String() string {
+	if e.Value == "" {
+		return e.Name
+	}
+	return e.Name + "=" + e.Value
+}
+
+// Effects are applied in order, each one to the result of the previous one. nil
+// means none were chosen, an empty list clears the image's saved effects.
+type Effects []Effect
+
+func (e Effects) String() string {
+	steps := make([]string, len(e))
+	for i, effect := range e {
+		steps[i] = effect.String()
+	}
+	return strings.Join(steps, ",")
+}
+
+// ParseEffects parses the --effect flag, e.g. "blur=8,dim=0.3". "none" removes every
+// effect.
+func ParseEffects(spec string) (Effects, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	if spec == "none" {
+		return Effects{}, nil
+	}
+
+	var effects Effects
+	for _, step := range strings.Split(spec, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(step), "=")
+		effect := Effect{Name: strings.ToLower(strings.TrimSpace(name)), Value: strings.TrimSpace(value)}
+		if err := effect.validate(); err != nil {
+			return nil, err
+		}
+		effects = append(effects, effect)
+	}
+	return effects, nil
+}
+
+func (e Effect) validate() error {
+	switch e.Name {
+	case "blur":
+		if radius, err := strconv.Atoi(e.Value); err != nil || radius < 1 || radius > maxBlurRadius {
+			return fmt.Errorf("%w : blur takes a radius in pixels from 1 to %d, got '%s'", ErrInvalidEffect, maxBlurRadius, e.Value)
+		}
+	case "dim", "grayscale", "vignette":
+		if e.Value == "" {
+			return nil
+		}
+		if _, err := parseAmount(e.Value); err != nil {
+			return fmt.Errorf("%w : %s %v", ErrInvalidEffect, e.Name, err)
+		}
+	case "tint":
+		color, amount, found := strings.Cut(e.Value, ":")
+		if _, _, _, err := os_Specifics.ParseHexColor(color); err != nil {
+			return fmt.Errorf("%w : tint %v", ErrInvalidEffect, err)
+		}
+		if _, err := parseAmount(amount); found && err != nil {
+			return fmt.Errorf("%w : tint %v", ErrInvalidEffect, err)
+		}
+	default:
+		return fmt.Errorf("%w : unknown effect '%s', expected one of %v", ErrInvalidEffect, e.Name, EffectNames)
+	}
+	return nil
+}
+
+// parseAmount parses a strength from 0 to 1 into a fixed point weight.
+func parseAmount(amount string) (int, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil || value < 0 || value > 1 {
+		return 0, fmt.Errorf("takes an amount from 0 to 1, got '%s'", amount)
+	}
+	return int(math.Round(value * fixedOne)), nil
+}
+
+// amount returns the strength of the effect, or the default when none was given.
+func (e Effect) amount(value string, defaultAmount int) int {
+	if value == "" {
+		return defaultAmount
+	}
+	amount, _ := parseAmount(value)
+	return amount
+}
+
+// applyEffects returns a copy of the image with the effects drawn over it.
+func applyEffects(src image.Image, effects Effects) *image.RGBA {
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Src)
+
+	for _, effect := range effects {
+		switch effect.Name {
+		case "blur":
+			radius, _ := strconv.Atoi(effect.Value)
+			blur(img, radius)
+		case "dim":
+			dim(img, effect.amount(effect.Value, fixedOne/2))
+		case "grayscale":
+			grayscale(img, effect.amount(effect.Value, fixedOne))
+		case "tint":
+			color, amount, _ := strings.Cut(effect.Value, ":")
+			r, g, b, _ := os_Specifics.ParseHexColor(color)
+			tint(img, [3]int{int(r), int(g), int(b)}, effect.amount(amount, fixedOne/4))
+		case "vignette":
+			vignette(img, effect.amount(effect.Value, fixedOne/2))
+		}
+	}
+	return img
+}
+
+// blur approximates a gaussian blur with the radius as its standard deviation by
+// running a box blur three times in each direction.
+func blur(img *image.RGBA, radius int) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	line := make([]uint8, max(width, height)*4)
+	for pass := 0; pass < 3; pass++ {
+		for y := 0; y < height; y++ {
+			boxBlur(img.Pix[y*img.Stride:], 4, width, radius, line)
+		}
+		for x := 0; x < width; x++ {
+			boxBlur(img.Pix[x*4:], img.Stride, height, radius, line)
+		}
+	}
+}
+
+// boxBlur averages every pixel of a row or column with its neighbors within the
+// radius, pixels past the edges repeat the edge pixel.
+func boxBlur(pix []uint8, step, length, radius int, line []uint8) {
+	for i := 0; i < length; i++ {
+		copy(line[i*4:i*4+4], pix[i*step:i*step+4])
+	}
+
+	size := 2*radius + 1
+	at := func(i, channel int) int {
+		return int(line[min(max(i, 0), length-1)*4+channel])
+	}
+
+	for channel := 0; channel < 4; channel++ {
+		sum := 0
+		for i := -radius; i <= radius; i++ {
+			sum += at(i, channel)
+		}
+
+		for i := 0; i < length; i++ {
+			pix[i*step+channel] = uint8((sum + size/2) / size)
+			sum += at(i+radius+1, channel) - at(i-radius, channel)
+		}
+	}
+}
+
+func dim(img *image.RGBA, amount int) {
+	keep := fixedOne - amount
+	eachPixel(img, func(x, y int, pixel []uint8) {
+		for channel := 0; channel < 3; channel++ {
+			pixel[channel] = uint8((int(pixel[channel])*keep + fixedOne/2) / fixedOne)
+		}
+	})
+}
+
+func grayscale(img *image.RGBA, amount int) {
+	eachPixel(img, func(x, y int, pixel []uint8) {
+		luminance := (2126*int(pixel[0]) + 7152*int(pixel[1]) + 722*int(pixel[2]) + 5000) / 10000
+		for channel := 0; channel < 3; channel++ {
+			pixel[channel] = mix(pixel[channel], luminance, amount)
+		}
+	})
+}
+
+func tint(img *image.RGBA, color [3]int, amount int) {
+	eachPixel(img, func(x, y int, pixel []uint8) {
+		for channel := 0; channel < 3; channel++ {
+			pixel[channel] = mix(pixel[channel], color[channel], amount)
+		}
+	})
+}
+
+// vignette darkens the image towards the corners, the amount is how dark the
+// corners get.
+func vignette(img *image.RGBA, amount int) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	// Distances are doubled so the center of odd sized images stays an integer.
+	centerX, centerY := width-1, height-1
+	maxDistance := centerX*centerX + centerY*centerY
+	if maxDistance == 0 {
+		return
+	}
+
+	eachPixel(img, func(x, y int, pixel []uint8) {
+		dx, dy := 2*x-centerX, 2*y-centerY
+		keep := fixedOne - amount*(dx*dx+dy*dy)/maxDistance
+		for channel := 0; channel < 3; channel++ {
+			pixel[channel] = uint8((int(pixel[channel])*keep + fixedOne/2) / fixedOne)
+		}
+	})
+}
+
+func eachPixel(img *image.RGBA, apply func(x, y int, pixel []uint8)) {
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			i := y*img.Stride + x*4
+			apply(x, y, img.Pix[i:i+4])
+		}
+	}
+}
+
+// mix moves the value towards the target by the fixed point amount.
+func mix(value uint8, target, amount int) uint8 {
+	v := int(value)
+	return uint8(v + ((target-v)*amount+fixedOne/2)/fixedOne)
+}
+
+// renderEffects returns a copy of the image with the effects applied, cached by
+// the contents of the image and the effects. The original is never changed, and
+// slideshows and files that aren't images are returned as they are.
+func renderEffects(wallpaper string, effects Effects) (string, error) {
+	if len(effects) == 0 || wallpaper == "" || strings.Contains(wallpaper, "://") || os_Specifics.IsSlideShowFile(wallpaper) {
+		return wallpaper, nil
+	}
+
+	effectsPath, err := getCachePath("effects")
+	if err != nil {
+		return "", err
+	}
+
+	key, err := contentCacheKey(wallpaper, effects.String())
+	if err != nil {
+		return "", err
+	}
+
+	rendered := filepath.Join(effectsPath, key+".jpg")
+	if _, err := os.Stat(rendered); err == nil {
+		return rendered, nil
+	}
+
+	img, err := decodeImageFile(wallpaper)
+	if errors.Is(err, ErrUnsupportedImage) {
+		return wallpaper, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeJPEG(rendered, applyEffects(img, effects), effectsQuality); err != nil {
+		return "", err
+	}
+	if err := writeDerivedSource(rendered, wallpaper); err != nil {
+		return "", err
+	}
+	return rendered, nil
+}
+
+// savedEffects returns the effects saved for the image, images without saved effects
+// and broken metadata have none.
+func savedEffects(metadata ImageMetadata) Effects {
+	effects, err := ParseEffects(metadata.Effects)
+	if err != nil {
+		return nil
+	}
+	return effects
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseEffects(t *testing.T) {
+	testCases := []struct {
+		spec   string
+		exp    string
+		expNil bool
+		expErr error
+	}{
+		{spec: "", expNil: true},
+		{spec: "none", exp: ""},
+		{spec: "blur=8, dim=0.3", exp: "blur=8,dim=0.3"},
+		{spec: "Grayscale,tint=#ff8800:0.5,vignette", exp: "grayscale,tint=#ff8800:0.5,vignette"},
+		{spec: "blur", expErr: ErrInvalidEffect},
+		{spec: "blur=500", expErr: ErrInvalidEffect},
+		{spec: "dim=1.5", expErr: ErrInvalidEffect},
+		{spec: "tint=orange", expErr: ErrInvalidEffect},
+		{spec: "sepia", expErr: ErrInvalidEffect},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.spec, func(t *testing.T) {
+			got, err := ParseEffects(testCase.spec)
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+			if testCase.expErr != nil {
+				return
+			}
+			if (got == nil) != testCase.expNil || got.String() != testCase.exp {
+				t.Errorf("Expected effects '%s', but got '%s' instead", testCase.exp, got)
+			}
+		})
+	}
+}
+
+func TestEffectsGolden(t *testing.T) {
+	src, err := decodeImageFile("../test/testData/images/testImage.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := src.Bounds()
+	src = resizeImage(src, 160, max(bounds.Dy()*160/bounds.Dx(), 1))
+
+	testCases := []struct {
+		name string
+		spec string
+	}{
+		{name: "blur", spec: "blur=4"},
+		{name: "dim", spec: "dim=0.3"},
+		{name: "grayscale", spec: "grayscale"},
+		{name: "tint", spec: "tint=#ff8800:0.4"},
+		{name: "vignette", spec: "vignette=0.8"},
+		{name: "focus", spec: "blur=8,dim=0.3"},
+		{name: "presentation", spec: "grayscale,vignette"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			effects, err := ParseEffects(testCase.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := applyEffects(src, effects)
+
+			goldenFile := filepath.Join("..", "test", "testData", "effects", testCase.name+".png")
+			if *updateGolden {
+				var encoded bytes.Buffer
+				if err := png.Encode(&encoded, got); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(filepath.Dir(goldenFile), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenFile, encoded.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Pixels are compared instead of files, encoders may change between releases.
+			exp, err := decodeImageFile(goldenFile)
+			if err != nil {
+				t.Fatalf("Error reading golden file, run 'go test ./internal -run TestEffectsGolden -update': %v", err)
+			}
+			if !bytes.Equal(got.Pix, toRGBA(exp).Pix) {
+				t.Errorf("Expected '%s' to match %s", testCase.spec, goldenFile)
+			}
+		})
+	}
+}
+
+func TestApplyEffectsKeepsSource(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range src.Pix {
+		src.Pix[i] = 200
+	}
+
+	got := applyEffects(src, Effects{{Name: "dim", Value: "1"}})
+	if got.Pix[0] != 0 || src.Pix[0] != 200 {
+		t.Errorf("Expected a black copy and an untouched source, but got %d and %d", got.Pix[0], src.Pix[0])
+	}
+}
+
+func TestRenderEffects(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	wallpaper := filepath.Join(t.TempDir(), "testImage.jpg")
+	content, err := os.ReadFile("../test/testData/images/testImage.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(wallpaper, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := renderEffects(wallpaper, nil); err != nil || got != wallpaper {
+		t.Fatalf("Expected the original image without effects, but got '%s' and '%v'", got, err)
+	}
+
+	effects := Effects{{Name: "grayscale"}}
+	rendered, err := renderEffects(wallpaper, effects)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if rendered == wallpaper {
+		t.Fatal("Expected a rendered copy, but got the original image")
+	}
+
+	if cached, err := renderEffects(wallpaper, effects); err != nil || cached != rendered {
+		t.Errorf("Expected cached copy '%s', but got '%s' and '%v'", rendered, cached, err)
+	}
+	if source := sourceWallpaper(rendered); source != wallpaper {
+		t.Errorf("Expected source '%s', but got '%s' instead", wallpaper, source)
+	}
+	if original, _ := os.ReadFile(wallpaper); !bytes.Equal(original, content) {
+		t.Error("Expected the original image to be left untouched")
+	}
+}
+
+func TestProfileEffectsKeepSlideShows(t *testing.T) {
+	library := setupManagedLibrary(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	original := viper.AllSettings()
+	t.Cleanup(func() {
+		for _, key := range []string{activeProfileConfigKey, "Profiles"} {
+			viper.Set(key, original[key])
+		}
+	})
+	viper.Set("Profiles", map[string]any{"night": map[string]any{"Effects": "blur=8,dim=0.3"}})
+	viper.Set(activeProfileConfigKey, "night")
+
+	slideShow, err := configureSlideShow("lake.png;tower.png", library, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, _ := getBackend()
+
+	if err := setWallpaper(io.Discard, slideShow); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if current, _ := backend.CurrentWallpaper(); current != slideShow {
+		t.Errorf("Expected slideshow '%s' to be set, but got '%s' instead", slideShow, current)
+	}
+
+	notAnImage := filepath.Join(library, "notes.txt")
+	if err := os.WriteFile(notAnImage, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rendered, err := renderEffects(notAnImage, Effects{{Name: "grayscale"}}); err != nil || rendered != notAnImage {
+		t.Errorf("Expected '%s' unchanged, but got '%s' and '%v'", notAnImage, rendered, err)
+	}
+}
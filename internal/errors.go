@@ -11,11 +11,90 @@ var (
 	ErrCouldNotConfigureImagePath     = errors.New("Error trying to configure user image path")
 	ErrNoValidImagesPath              = errors.New(`User does not have valid images path configured.
     IMAGES
-      Images must be stored in ONE of the following paths:
-         - $HOME/.config/backdrop/wallpapers (This one has priority)
+      Images are listed from the first of these that points to an existing directory:
+         - The "BACKDROP_IMAGE_PATH" shell variable, several paths are separated by ":" (";" on Windows)
+         - "WallpapersPath" and "LibraryRoots" in the config file, "WallpapersPath" is set by using the "--path" or "-p" flag
+         - $XDG_DATA_HOME/backdrop/wallpapers, by default $HOME/.local/share/backdrop/wallpapers (%APPDATA%\Backdrop on Windows)
          - $HOME/Pictures/wallpapers
-      Note: If "BACKDROP_IMAGE_PATH" shell variable is set, it will have priority and be used to list images.
-            This is set by using the "--path" or "-p" flag mentioned above.
+      Run "backdrop config show" to see which paths are used.
     `)
-	ErrCommandNotFound = errors.New("Required command is not available")
+	ErrCommandNotFound       = errors.New("Required command is not available")
+	ErrNoSlideShowFound      = errors.New("No slideshow found to edit, create one first with the '--slideshow' flag")
+	ErrSlideShowNotFound     = errors.New("No saved slideshow found with that name")
+	ErrInvalidSlideShowName  = errors.New("Slideshow name must contain at least one letter or digit")
+	ErrEmptyPlaylist         = errors.New("No images found to rotate through")
+	ErrDaemonAlreadyRunning  = errors.New("Backdrop daemon is already running")
+	ErrDaemonNotRunning      = errors.New("Backdrop daemon is not running, start it with 'backdrop daemon'")
+	ErrUnknownControlCommand = errors.New("Unknown control command")
+	ErrImageNotFound         = errors.New("Image not found in the wallpapers path")
+	ErrUnsupportedImage      = errors.New("Image format is not supported")
+	ErrInvalidSchedule       = errors.New("Invalid schedule")
+	ErrScheduleNotFound      = errors.New("No schedule found with that name")
+	ErrInvalidFit            = errors.New("Invalid fit")
+	ErrSnapshotNotFound      = errors.New("No snapshot found with that name")
+	ErrInvalidSnapshotName   = errors.New("Snapshot name must contain at least one letter or digit")
+	ErrInvalidPair           = errors.New("A pair needs exactly two images, one for light and one for dark mode")
+	ErrPairNotFound          = errors.New("Image is not part of a light and dark pair")
+	ErrInvalidProcessing     = errors.New("Invalid image processing settings")
+	ErrInvalidEffect         = errors.New("Invalid effect")
+	ErrInvalidGenerator      = errors.New("Invalid generated wallpaper")
+	ErrInvalidOverlay        = errors.New("Invalid overlay")
+	ErrInvalidConfig         = errors.New("Invalid config")
+	ErrUnknownConfigKey      = errors.New("Unknown config key")
+	ErrInvalidTag            = errors.New("Tags must be a single word")
+	ErrProfileNotFound       = errors.New("No profile found with that name")
+	ErrInvalidRule           = errors.New("Invalid rule")
+	ErrNoRules               = errors.New("No rules configured, add them under 'Rules' with 'backdrop config edit'")
+	ErrInvalidOutputFormat   = errors.New("Invalid output format")
+	ErrInvalidListOption     = errors.New("Invalid list option")
+	ErrImageExists           = errors.New("An image already exists at that path")
+	ErrInvalidOrganize       = errors.New("Invalid way to organize the library")
+	ErrTrashItemNotFound     = errors.New("No image moved to the trash by backdrop found with that name")
 )
+
+// errorCodes are the codes of the sentinel errors in structured output. Scripts match
+// on them, so a code never changes once released.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrNoCompatibleDesktopEnvironment, "no_compatible_desktop_environment"},
+	{ErrNoCompatibleOS, "no_compatible_os"},
+	{ErrUserCanceledSelection, "user_canceled_selection"},
+	{ErrCouldNotSetBackground, "could_not_set_background"},
+	{ErrCouldNotListSchemas, "could_not_list_schemas"},
+	{ErrCouldNotConfigureImagePath, "could_not_configure_image_path"},
+	{ErrNoValidImagesPath, "no_valid_images_path"},
+	{ErrCommandNotFound, "command_not_found"},
+	{ErrNoSlideShowFound, "no_slideshow_found"},
+	{ErrSlideShowNotFound, "slideshow_not_found"},
+	{ErrInvalidSlideShowName, "invalid_slideshow_name"},
+	{ErrEmptyPlaylist, "empty_playlist"},
+	{ErrDaemonAlreadyRunning, "daemon_already_running"},
+	{ErrDaemonNotRunning, "daemon_not_running"},
+	{ErrUnknownControlCommand, "unknown_control_command"},
+	{ErrImageNotFound, "image_not_found"},
+	{ErrUnsupportedImage, "unsupported_image"},
+	{ErrInvalidSchedule, "invalid_schedule"},
+	{ErrScheduleNotFound, "schedule_not_found"},
+	{ErrInvalidFit, "invalid_fit"},
+	{ErrSnapshotNotFound, "snapshot_not_found"},
+	{ErrInvalidSnapshotName, "invalid_snapshot_name"},
+	{ErrInvalidPair, "invalid_pair"},
+	{ErrPairNotFound, "pair_not_found"},
+	{ErrInvalidProcessing, "invalid_processing"},
+	{ErrInvalidEffect, "invalid_effect"},
+	{ErrInvalidGenerator, "invalid_generator"},
+	{ErrInvalidOverlay, "invalid_overlay"},
+	{ErrInvalidConfig, "invalid_config"},
+	{ErrUnknownConfigKey, "unknown_config_key"},
+	{ErrInvalidTag, "invalid_tag"},
+	{ErrProfileNotFound, "profile_not_found"},
+	{ErrInvalidRule, "invalid_rule"},
+	{ErrNoRules, "no_rules"},
+	{ErrInvalidOutputFormat, "invalid_output_format"},
+	{ErrInvalidListOption, "invalid_list_option"},
+	{ErrImageExists, "image_exists"},
+	{ErrInvalidOrganize, "invalid_organize"},
+	{ErrTrashItemNotFound, "trash_item_not_found"},
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+)
+
+// withFileLock runs fn holding an exclusive lock on a ".lock" file next to path, so
+// backdrop processes, like the daemon and serve, take turns updating it. Goroutines
+// of one process still need their own mutex, Windows locks belong to the process.
+func withFileLock(path string, fn func() error) error {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file for %s: %w", path, err)
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer unlockFile(lock)
+
+	return fn()
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+// Fit is how an image is scaled on screen and the colors shown around it.
+type Fit = os_Specifics.PictureOptions
+
+// ParseFit builds a fit from the --fit and --fit-color flags. Colors are one color,
+// or two comma separated colors drawn as a vertical gradient.
+func ParseFit(mode, colors string) (Fit, error) {
+	var fit Fit
+	if mode != "" {
+		if !slices.Contains(os_Specifics.PictureModes, mode) {
+			return Fit{}, fmt.Errorf("%w : '%s', expected one of %v", ErrInvalidFit, mode, os_Specifics.PictureModes)
+		}
+		fit.Mode = mode
+	}
+
+	if colors == "" {
+		return fit, nil
+	}
+
+	parts := strings.Split(colors, ",")
+	if len(parts) > 2 {
+		return Fit{}, fmt.Errorf("%w : expected one or two colors, got '%s'", ErrInvalidFit, colors)
+	}
+	for _, color := range parts {
+		if _, _, _, err := os_Specifics.ParseHexColor(strings.TrimSpace(color)); err != nil {
+			return Fit{}, fmt.Errorf("%w : %v", ErrInvalidFit, err)
+		}
+	}
+
+	fit.PrimaryColor = strings.TrimSpace(parts[0])
+	fit.SecondaryColor = fit.PrimaryColor
+	fit.Shading = "solid"
+	if len(parts) == 2 {
+		fit.SecondaryColor = strings.TrimSpace(parts[1])
+		fit.Shading = "vertical"
+	}
+	return fit, nil
+}
+
+// validateFit checks a fit read from a file, the way ParseFit checks flags.
+func validateFit(fit Fit) error {
+	if fit.Mode != "" && !slices.Contains(os_Specifics.PictureModes, fit.Mode) {
+		return fmt.Errorf("%w : '%s', expected one of %v", ErrInvalidFit, fit.Mode, os_Specifics.PictureModes)
+	}
+	for _, color := range []string{fit.PrimaryColor, fit.SecondaryColor} {
+		if color == "" {
+			continue
+		}
+		if _, _, _, err := os_Specifics.ParseHexColor(color); err != nil {
+			return fmt.Errorf("%w : %v", ErrInvalidFit, err)
+		}
+	}
+	return nil
+}
+
+// applyWallpaper sets the image on the output with the given fit and effects. Without
+// a fit the image's default fit from the metadata is used, then the active profile's,
+// and otherwise the current one is kept. nil effects use the image's saved effects, then
+// the profile's. Images paired with a dark variant are applied as a pair.
+func applyWallpaper(out io.Writer, backend wallpaperBackend, output, wallpaper string, fit Fit, effects Effects) error {
+	// A broken metadata file must not stop wallpapers from changing.
+	metadata, _ := getImageMetadata(wallpaper)
+	if fit.IsZero() {
+		fit = metadata.Fit
+	}
+	if effects == nil {
+		effects = savedEffects(metadata)
+	}
+	if _, profile, err := activeProfile(); err == nil {
+		if fit.IsZero() {
+			fit = profile.Fit
+		}
+		if effects == nil {
+			effects, _ = ParseEffects(profile.Effects)
+		}
+	}
+
+	return applyWallpaperPair(out, backend, output, wallpaper, metadata.Dark, fit, effects)
+}
+
+// applyWallpaperPair is applyWallpaper for an explicit pair, an empty dark image
+// shows the light one in both modes. Images are processed before effects are drawn,
+// so effects work in screen pixels, and overlays go on last so effects leave the text
+// alone.
+func applyWallpaperPair(out io.Writer, backend wallpaperBackend, output, light, dark string, fit Fit, effects Effects) error {
+	light, err := processWallpaper(backend, output, light)
+	if err != nil {
+		return err
+	}
+	if dark, err = processWallpaper(backend, output, dark); err != nil {
+		return err
+	}
+
+	if light, err = renderEffects(light, effects); err != nil {
+		return err
+	}
+	if dark, err = renderEffects(dark, effects); err != nil {
+		return err
+	}
+
+	overlay, err := getOverlayOptions()
+	if err != nil {
+		return err
+	}
+	if light, err = renderOverlay(light, overlay); err != nil {
+		return err
+	}
+	if dark, err = renderOverlay(dark, overlay); err != nil {
+		return err
+	}
+
+	if err := setWallpaperVariant(backend, output, light, dark); err != nil {
+		return err
+	}
+
+	applyPalette(out, light, dark)
+
+	if fit.IsZero() {
+		return nil
+	}
+	return backend.SetFit(output, fit)
+}
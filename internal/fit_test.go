@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFit(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mode   string
+		colors string
+		expFit Fit
+		expErr error
+	}{
+		{name: "Empty"},
+		{name: "ModeOnly", mode: "scaled", expFit: Fit{Mode: "scaled"}},
+		{name: "SolidColor", mode: "centered", colors: "#000000", expFit: Fit{Mode: "centered", PrimaryColor: "#000000", SecondaryColor: "#000000", Shading: "solid"}},
+		{name: "Gradient", colors: "#112233, #445566", expFit: Fit{PrimaryColor: "#112233", SecondaryColor: "#445566", Shading: "vertical"}},
+		{name: "UnknownMode", mode: "cover", expErr: ErrInvalidFit},
+		{name: "InvalidColor", colors: "black", expErr: ErrInvalidFit},
+		{name: "TooManyColors", colors: "#000,#111,#222", expErr: ErrInvalidFit},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fit, err := ParseFit(testCase.mode, testCase.colors)
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+			if fit != testCase.expFit {
+				t.Errorf("Expected fit '%+v', but got '%+v' instead", testCase.expFit, fit)
+			}
+		})
+	}
+}
+
+func TestApplyWallpaperUsesDefaultFit(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	image := filepath.Join(t.TempDir(), "one.jpg")
+	defaultFit := Fit{Mode: "scaled", PrimaryColor: "#000000", SecondaryColor: "#000000", Shading: "solid"}
+	if err := updateImageMetadata(image, func(metadata *ImageMetadata) { metadata.Fit = defaultFit }); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	backend := newFakeBackend(allOutputs)
+	expectFit := func(exp Fit) {
+		t.Helper()
+
+		backend.expect(t, fakeWallpaper{allOutputs, image})
+		select {
+		case got := <-backend.fitted:
+			if got != exp {
+				t.Errorf("Expected fit '%+v', but got '%+v' instead", exp, got)
+			}
+		default:
+			t.Errorf("Expected fit '%+v' to be applied, but nothing was applied", exp)
+		}
+	}
+
+	if err := applyWallpaper(io.Discard, backend, allOutputs, image, Fit{}, nil); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	expectFit(defaultFit)
+
+	chosenFit := Fit{Mode: "zoom"}
+	if err := applyWallpaper(io.Discard, backend, allOutputs, image, chosenFit, nil); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	expectFit(chosenFit)
+
+	if err := updateImageMetadata(image, func(metadata *ImageMetadata) { metadata.Fit = Fit{} }); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if metadata, err := loadMetadata(); err != nil || len(metadata) != 0 {
+		t.Errorf("Expected empty metadata to be dropped, but got '%v' and '%v'", metadata, err)
+	}
+
+	if err := applyWallpaper(io.Discard, backend, allOutputs, image, Fit{}, nil); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, image})
+	if len(backend.fitted) != 0 {
+		t.Errorf("Expected the current fit to be kept, but got '%+v'", <-backend.fitted)
+	}
+}
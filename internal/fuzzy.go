@@ -4,7 +4,6 @@ import (
 	"errors"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/ktr0731/go-fuzzyfinder"
@@ -13,18 +12,22 @@ import (
 type FuzzySelection func(s []string) (string, error)
 type GetFuzzySelector func(c *Config) FuzzySelection
 
+// PreselectedFuzzySelection selects many items, starting with the ones preselected
+// reports already selected.
+type PreselectedFuzzySelection func(s []string, preselected func(i int) bool) (string, error)
+
 func getFuzzySelector(c *Config) FuzzySelection {
 	switch {
-	case c.isSlideShow:
+	case c.isSlideShow, c.isPair:
 		return multiFuzzySelection
 	default:
 		return fuzzySelection
 	}
 }
 
-func handleFuzzySearch(out io.Writer, wallpapersPath string, wallpapers []string, imageSelection FuzzySelection) error {
+func handleFuzzySearch(out io.Writer, wallpapersPath string, wallpapers []string, imageSelection FuzzySelection, fit Fit, effects Effects) error {
 	for hasConfirmed := false; !hasConfirmed; {
-		previousWallpaper, err := getPreviousWallpaper()
+		previousState, err := captureWallpaperState()
 		if err != nil {
 			return err
 		}
@@ -34,10 +37,10 @@ func handleFuzzySearch(out io.Writer, wallpapersPath string, wallpapers []string
 			return err
 		}
 
-		fullSelectedPath := filepath.Join(wallpapersPath, selectedWallpaper)
+		fullSelectedPath := libraryImagePath(wallpapersPath, selectedWallpaper)
 		stats, err := os.Stat(fullSelectedPath)
 		if err == nil && stats.Mode().IsRegular() {
-			err := setWallpaper(fullSelectedPath)
+			err := setWallpaperFit(out, fullSelectedPath, fit, effects)
 			if err != nil {
 				return err
 			}
@@ -46,10 +49,14 @@ func handleFuzzySearch(out io.Writer, wallpapersPath string, wallpapers []string
 			return err
 		}
 
-		hasConfirmed, err = handleSelectionConfirmation(previousWallpaper, out, &SelectionOptions{
+		hasConfirmed, err = handleSelectionConfirmation(previousState, out, &SelectionOptions{
 			Prompt:         "",
 			SuccessMessage: "",
 			Cleanup:        func() {},
+			Wallpaper:      fullSelectedPath,
+			Source:         historySourceFuzzy,
+			Fit:            fit,
+			Effects:        effects,
 		})
 
 		if err != nil {
@@ -79,12 +86,17 @@ func fuzzySelection(fileNames []string) (string, error) {
 }
 
 func multiFuzzySelection(fileNames []string) (string, error) {
+	return preselectedFuzzySelection(fileNames, func(int) bool { return false })
+}
+
+func preselectedFuzzySelection(fileNames []string, preselected func(i int) bool) (string, error) {
 	selectedIndexes, err := fuzzyfinder.FindMulti(
 		fileNames,
 		func(i int) string {
 			return fileNames[i]
 		},
 		fuzzyfinder.WithCursorPosition(1),
+		fuzzyfinder.WithPreselected(preselected),
 	)
 	if errors.Is(err, fuzzyfinder.ErrAbort) {
 		return "", ErrUserCanceledSelection
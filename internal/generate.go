@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+var GeneratorKinds = []string{"solid", "gradient", "noise", "stripes", "dots"}
+
+// GenerateOptions describe a generated wallpaper. Angles are in degrees clockwise,
+// 0 runs from left to right and 90 from top to bottom.
+type GenerateOptions struct {
+	Kind       string
+	From       string
+	To         string
+	Angle      int
+	Size       int
+	Seed       int64
+	Resolution string
+	Name       string
+	Apply      bool
+}
+
+func (o GenerateOptions) validate() error {
+	if !slices.Contains(GeneratorKinds, o.Kind) {
+		return fmt.Errorf("%w : unknown kind '%s', expected one of %v", ErrInvalidGenerator, o.Kind, GeneratorKinds)
+	}
+	for _, color := range []string{o.From, o.To} {
+		if _, _, _, err := os_Specifics.ParseHexColor(color); err != nil {
+			return fmt.Errorf("%w : %v", ErrInvalidGenerator, err)
+		}
+	}
+	if o.Kind != "solid" && o.Kind != "gradient" && o.Size < 1 {
+		return fmt.Errorf("%w : size must be at least 1 pixel", ErrInvalidGenerator)
+	}
+	if o.Name != "" && (strings.ContainsAny(o.Name, `/\`) || o.Name == "." || o.Name == "..") {
+		return fmt.Errorf("%w : name '%s' must be a file name", ErrInvalidGenerator, o.Name)
+	}
+	return nil
+}
+
+// fileName names generated images after what they show, so generating the same
+// wallpaper twice reuses the file.
+func (o GenerateOptions) fileName(size image.Point) string {
+	if o.Name != "" {
+		return strings.TrimSuffix(o.Name, ".png") + ".png"
+	}
+
+	parts := []string{o.Kind, strings.TrimPrefix(o.From, "#")}
+	if o.Kind != "solid" {
+		parts = append(parts, strings.TrimPrefix(o.To, "#"))
+	}
+	if o.Kind == "gradient" || o.Kind == "stripes" {
+		parts = append(parts, fmt.Sprintf("%ddeg", normalizeAngle(o.Angle)))
+	}
+	if o.Kind == "noise" || o.Kind == "stripes" || o.Kind == "dots" {
+		parts = append(parts, fmt.Sprintf("%dpx", o.Size))
+	}
+	if o.Kind == "noise" {
+		parts = append(parts, fmt.Sprintf("seed%d", o.Seed))
+	}
+	parts = append(parts, fmt.Sprintf("%dx%d", size.X, size.Y))
+	return strings.ToLower(strings.Join(parts, "-")) + ".png"
+}
+
+// nativeColorBackend is implemented by backends that draw a solid color or a
+// horizontal or vertical two color gradient themselves when the fit mode is "none".
+type nativeColorBackend interface {
+	DrawsColors() bool
+}
+
+// nativeFit returns the fit that shows the wallpaper without an image, if the
+// desktop can draw it.
+func (o GenerateOptions) nativeFit() (Fit, bool) {
+	switch {
+	case o.Kind == "solid":
+		return Fit{Mode: "none", PrimaryColor: o.From, SecondaryColor: o.From, Shading: "solid"}, true
+	case o.Kind != "gradient":
+		return Fit{}, false
+	}
+
+	switch normalizeAngle(o.Angle) {
+	case 0:
+		return Fit{Mode: "none", PrimaryColor: o.From, SecondaryColor: o.To, Shading: "horizontal"}, true
+	case 90:
+		return Fit{Mode: "none", PrimaryColor: o.From, SecondaryColor: o.To, Shading: "vertical"}, true
+	case 180:
+		return Fit{Mode: "none", PrimaryColor: o.To, SecondaryColor: o.From, Shading: "horizontal"}, true
+	case 270:
+		return Fit{Mode: "none", PrimaryColor: o.To, SecondaryColor: o.From, Shading: "vertical"}, true
+	}
+	return Fit{}, false
+}
+
+func normalizeAngle(angle int) int {
+	return (angle%360 + 360) % 360
+}
+
+// Generate renders the wallpaper into the wallpapers path and applies it if asked.
+// Solid colors and straight gradients are drawn by desktops that can do it without
+// an image.
+func Generate(out io.Writer, opts GenerateOptions) error {
+	if opts.Kind == "solid" {
+		opts.To = opts.From
+	}
+	// "#" starts a comment in most shells, so colors are accepted without it.
+	opts.From = "#" + strings.TrimPrefix(opts.From, "#")
+	opts.To = "#" + strings.TrimPrefix(opts.To, "#")
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+
+	if colorBackend, ok := backend.(nativeColorBackend); ok && opts.Apply && colorBackend.DrawsColors() {
+		if fit, ok := opts.nativeFit(); ok {
+			if err := backend.SetFit(allOutputs, fit); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "The %s desktop now draws the %s background itself, no image needed.\n", backend.Name(), opts.Kind)
+			return nil
+		}
+	}
+
+	size, err := targetResolution(backend, allOutputs, ProcessingOptions{Resolution: opts.Resolution})
+	if err != nil {
+		return fmt.Errorf("could not detect the display resolution, set one with --resolution: %w", err)
+	}
+
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+
+	wallpaper := filepath.Join(wallpapersPath, opts.fileName(size))
+	if err := writePNG(wallpaper, renderGenerated(opts, size)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Generated %s.\n", wallpaper)
+
+	if !opts.Apply {
+		return nil
+	}
+
+	if err := setWallpaperFit(out, wallpaper, Fit{Mode: "zoom"}, nil); err != nil {
+		return err
+	}
+	if err := recordHistory(wallpaper, historySourceGenerate); err != nil {
+		fmt.Fprintf(out, "Could not record history: %v\n", err)
+	}
+	return nil
+}
+
+func renderGenerated(opts GenerateOptions, size image.Point) *image.RGBA {
+	from, to := rgbColor(opts.From), rgbColor(opts.To)
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+
+	angle := float64(normalizeAngle(opts.Angle)) * math.Pi / 180
+	dirX, dirY := math.Cos(angle), math.Sin(angle)
+
+	var noise *valueNoise
+	if opts.Kind == "noise" {
+		noise = newValueNoise(opts.Seed, size, opts.Size)
+	}
+
+	eachPixel(img, func(x, y int, pixel []uint8) {
+		var amount float64
+		switch opts.Kind {
+		case "gradient":
+			amount = gradientAmount(x, y, size, dirX, dirY)
+		case "noise":
+			amount = noise.at(x, y)
+		case "stripes":
+			position := float64(x)*dirX + float64(y)*dirY
+			if int(math.Floor(position/float64(opts.Size)))%2 != 0 {
+				amount = 1
+			}
+		case "dots":
+			amount = dotAmount(x, y, opts.Size)
+		}
+
+		for channel := 0; channel < 3; channel++ {
+			pixel[channel] = uint8(math.Round(float64(from[channel]) + float64(to[channel]-from[channel])*amount))
+		}
+		pixel[3] = 255
+	})
+	return img
+}
+
+// gradientAmount projects the pixel on the gradient direction, 0 at the corner the
+// gradient starts from and 1 at the opposite one.
+func gradientAmount(x, y int, size image.Point, dirX, dirY float64) float64 {
+	width, height := float64(size.X-1), float64(size.Y-1)
+	project := func(x, y float64) float64 {
+		return x*dirX + y*dirY
+	}
+
+	corners := []float64{project(0, 0), project(width, 0), project(0, height), project(width, height)}
+	low, high := slices.Min(corners), slices.Max(corners)
+	if high == low {
+		return 0
+	}
+	return (project(float64(x), float64(y)) - low) / (high - low)
+}
+
+// dotAmount draws a dot in the middle of every cell of the size, with smoothed edges.
+func dotAmount(x, y, cell int) float64 {
+	radius := float64(cell) / 4
+	center := float64(cell) / 2
+	dx := float64(x%cell) + 0.5 - center
+	dy := float64(y%cell) + 0.5 - center
+	distance := math.Sqrt(dx*dx + dy*dy)
+	return math.Max(0, math.Min(1, radius-distance+0.5))
+}
+
+// valueNoise interpolates random values on a grid with the size as cell size, the
+// seed makes the same pattern every time.
+type valueNoise struct {
+	cell    int
+	columns int
+	values  []float64
+}
+
+func newValueNoise(seed int64, size image.Point, cell int) *valueNoise {
+	columns := size.X/cell + 2
+	rows := size.Y/cell + 2
+	random := rand.New(rand.NewSource(seed))
+
+	values := make([]float64, columns*rows)
+	for i := range values {
+		values[i] = random.Float64()
+	}
+	return &valueNoise{cell: cell, columns: columns, values: values}
+}
+
+func (n *valueNoise) at(x, y int) float64 {
+	column, row := x/n.cell, y/n.cell
+	fx := smoothstep(float64(x%n.cell) / float64(n.cell))
+	fy := smoothstep(float64(y%n.cell) / float64(n.cell))
+
+	value := func(column, row int) float64 {
+		return n.values[row*n.columns+column]
+	}
+	top := lerp(value(column, row), value(column+1, row), fx)
+	bottom := lerp(value(column, row+1), value(column+1, row+1), fx)
+	return lerp(top, bottom, fy)
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func rgbColor(color string) [3]int {
+	r, g, b, _ := os_Specifics.ParseHexColor(color)
+	return [3]int{int(r), int(g), int(b)}
+}
+
+func writePNG(path string, img image.Image) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
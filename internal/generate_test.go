@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+type fakeColorBackend struct {
+	*fakeBackend
+}
+
+func (fakeColorBackend) DrawsColors() bool {
+	return true
+}
+
+func pixelAt(img *image.RGBA, x, y int) [3]uint8 {
+	i := y*img.Stride + x*4
+	return [3]uint8{img.Pix[i], img.Pix[i+1], img.Pix[i+2]}
+}
+
+func TestRenderGenerated(t *testing.T) {
+	black, white := [3]uint8{0, 0, 0}, [3]uint8{255, 255, 255}
+	size := image.Pt(64, 32)
+
+	testCases := []struct {
+		name   string
+		opts   GenerateOptions
+		pixels map[image.Point][3]uint8
+	}{
+		{
+			name:   "Solid",
+			opts:   GenerateOptions{Kind: "solid", From: "#1e1e2e", To: "#1e1e2e"},
+			pixels: map[image.Point][3]uint8{{0, 0}: {0x1e, 0x1e, 0x2e}, {63, 31}: {0x1e, 0x1e, 0x2e}},
+		},
+		{
+			name:   "GradientLeftToRight",
+			opts:   GenerateOptions{Kind: "gradient", From: "#000000", To: "#ffffff", Angle: 0},
+			pixels: map[image.Point][3]uint8{{0, 31}: black, {63, 0}: white},
+		},
+		{
+			name:   "GradientBottomToTop",
+			opts:   GenerateOptions{Kind: "gradient", From: "#000000", To: "#ffffff", Angle: -90},
+			pixels: map[image.Point][3]uint8{{63, 31}: black, {0, 0}: white},
+		},
+		{
+			name:   "Stripes",
+			opts:   GenerateOptions{Kind: "stripes", From: "#000000", To: "#ffffff", Angle: 0, Size: 8},
+			pixels: map[image.Point][3]uint8{{0, 0}: black, {7, 31}: black, {8, 0}: white, {16, 5}: black},
+		},
+		{
+			name:   "Dots",
+			opts:   GenerateOptions{Kind: "dots", From: "#000000", To: "#ffffff", Size: 16},
+			pixels: map[image.Point][3]uint8{{0, 0}: black, {8, 8}: white, {24, 24}: white, {16, 8}: black},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			img := renderGenerated(testCase.opts, size)
+			for point, exp := range testCase.pixels {
+				if got := pixelAt(img, point.X, point.Y); got != exp {
+					t.Errorf("Expected pixel %v to be %v, but got %v instead", point, exp, got)
+				}
+			}
+		})
+	}
+
+	noise := GenerateOptions{Kind: "noise", From: "#000000", To: "#ffffff", Size: 8, Seed: 7}
+	if !bytes.Equal(renderGenerated(noise, size).Pix, renderGenerated(noise, size).Pix) {
+		t.Error("Expected the same seed to draw the same noise")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	wallpapersPath := t.TempDir()
+	originalPath := viper.Get("WallpapersPath")
+	viper.Set("WallpapersPath", wallpapersPath)
+	defer viper.Set("WallpapersPath", originalPath)
+
+	originalGetBackend := getBackend
+	defer func() { getBackend = originalGetBackend }()
+
+	t.Run("Native", func(t *testing.T) {
+		backend := fakeColorBackend{newFakeBackend(allOutputs)}
+		getBackend = func() (wallpaperBackend, error) { return backend, nil }
+
+		var out bytes.Buffer
+		opts := GenerateOptions{Kind: "gradient", From: "112233", To: "#445566", Angle: 180, Apply: true}
+		if err := Generate(&out, opts); err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+
+		exp := Fit{Mode: "none", PrimaryColor: "#445566", SecondaryColor: "#112233", Shading: "horizontal"}
+		if got := <-backend.fitted; got != exp {
+			t.Errorf("Expected fit '%+v', but got '%+v' instead", exp, got)
+		}
+		backend.expectNothing(t)
+	})
+
+	t.Run("Image", func(t *testing.T) {
+		backend := newFakeBackend(allOutputs)
+		getBackend = func() (wallpaperBackend, error) { return backend, nil }
+
+		var out bytes.Buffer
+		opts := GenerateOptions{Kind: "dots", From: "#000000", To: "#ffffff", Size: 10, Resolution: "40x20", Apply: true}
+		if err := Generate(&out, opts); err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+
+		wallpaper := filepath.Join(wallpapersPath, "dots-000000-ffffff-10px-40x20.png")
+		backend.expect(t, fakeWallpaper{allOutputs, wallpaper})
+		if _, err := os.Stat(wallpaper); err != nil {
+			t.Errorf("Expected '%s' to be generated, but got '%v'", wallpaper, err)
+		}
+	})
+
+	if err := Generate(&bytes.Buffer{}, GenerateOptions{Kind: "plaid", From: "#000000", To: "#ffffff"}); !errors.Is(err, ErrInvalidGenerator) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidGenerator, err)
+	}
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// maxHistoryEntries keeps the history file small, older entries are dropped first.
+const maxHistoryEntries = 500
+
+const (
+	historySourceFuzzy    = "fuzzy"
+	historySourceUrl      = "url"
+	historySourceDaemon   = "daemon"
+	historySourceRandom   = "random"
+	historySourceServe    = "serve"
+	historySourceSchedule = "schedule"
+	historySourceGenerate = "generate"
+	historySourceProfile  = "profile"
+)
+
+type HistoryEntry struct {
+	Image  string    `json:"image"`
+	SetAt  time.Time `json:"set_at"`
+	Source string    `json:"source"`
+}
+
+func getHistoryFile() (string, error) {
+	statePath, err := getStatePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(statePath, "history.json"), nil
+}
+
+// loadHistory returns the history oldest first.
+func loadHistory() ([]HistoryEntry, error) {
+	historyFile, err := getHistoryFile()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(historyFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file %s: %w", historyFile, err)
+	}
+
+	var history []HistoryEntry
+	if err := json.Unmarshal(content, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse history file %s: %w", historyFile, err)
+	}
+
+	return history, nil
+}
+
+// historyNewestFirst returns at most limit entries of the history newest first, every
+// entry without a limit.
+func historyNewestFirst(limit int) ([]HistoryEntry, error) {
+	history, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	newestFirst := make([]HistoryEntry, 0, len(history))
+	for i := len(history) - 1; i >= 0 && (limit <= 0 || len(newestFirst) < limit); i-- {
+		newestFirst = append(newestFirst, history[i])
+	}
+	return newestFirst, nil
+}
+
+// ShowHistory prints the wallpapers set by backdrop newest first.
+func ShowHistory(out io.Writer, limit int) error {
+	history, err := historyNewestFirst(limit)
+	if err != nil {
+		return err
+	}
+	if isStructuredOutput() {
+		return writeStructured(out, history)
+	}
+
+	if len(history) == 0 {
+		fmt.Fprintln(out, "No wallpapers have been set with backdrop yet.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SET AT\tSOURCE\tIMAGE")
+	for _, entry := range history {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.SetAt.Local().Format("2006-01-02 15:04"), orDash(entry.Source), entry.Image)
+	}
+	return w.Flush()
+}
+
+// historyMutex serializes history updates of one process, the daemon rotates every
+// output in its own goroutine and serve records from its handlers.
+var historyMutex sync.Mutex
+
+// updateHistory replaces the history with what update returns, holding the history
+// lock so concurrent updates, from this process or another, are not lost.
+func updateHistory(update func(history []HistoryEntry) ([]HistoryEntry, bool)) error {
+	historyFile, err := getHistoryFile()
+	if err != nil {
+		return err
+	}
+
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+
+	return withFileLock(historyFile, func() error {
+		history, err := loadHistory()
+		if err != nil {
+			return err
+		}
+
+		history, changed := update(history)
+		if !changed {
+			return nil
+		}
+		return saveHistory(history)
+	})
+}
+
+func recordHistory(image, source string) error {
+	return updateHistory(func(history []HistoryEntry) ([]HistoryEntry, bool) {
+		history = append(history, HistoryEntry{Image: image, SetAt: time.Now(), Source: source})
+		if len(history) > maxHistoryEntries {
+			history = history[len(history)-maxHistoryEntries:]
+		}
+		return history, true
+	})
+}
+
+func saveHistory(history []HistoryEntry) error {
+	historyFile, err := getHistoryFile()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(historyFile, content, 0644)
+}
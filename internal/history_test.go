@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestRecordHistoryConcurrently(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	const writers, records = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*records)
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for record := 0; record < records; record++ {
+				errs <- recordHistory(fmt.Sprintf("/wallpapers/%d-%d.png", writer, record), historySourceDaemon)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != writers*records {
+		t.Errorf("Expected %d history entries, but got %d instead", writers*records, len(history))
+	}
+}
+
+// TestFileLock updates a counter without the history mutex, only the file lock
+// keeps the updates from overwriting each other, as it does between processes.
+func TestFileLock(t *testing.T) {
+	counterFile := filepath.Join(t.TempDir(), "counter")
+	if err := os.WriteFile(counterFile, []byte("0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const updates = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, updates)
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- withFileLock(counterFile, func() error {
+				content, err := os.ReadFile(counterFile)
+				if err != nil {
+					return err
+				}
+				count, err := strconv.Atoi(string(content))
+				if err != nil {
+					return err
+				}
+				return writeFileAtomic(counterFile, []byte(strconv.Itoa(count+1)), 0644)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+	}
+
+	if content, _ := os.ReadFile(counterFile); string(content) != strconv.Itoa(updates) {
+		t.Errorf("Expected the counter to be %d, but got %s instead", updates, content)
+	}
+}
@@ -15,14 +15,14 @@ var (
 	inputImageUrl io.Reader = os.Stdin
 )
 
-func handleImageUrl(out io.Writer, wallpapersPath string) error {
+func handleImageUrl(out io.Writer, wallpapersPath string, fit Fit, effects Effects) error {
 	for hasConfirmed := false; !hasConfirmed; {
-		previousWallpaper, err := getPreviousWallpaper()
+		previousState, err := captureWallpaperState()
 		if err != nil {
 			return err
 		}
 
-		imageUrl, err := userImageUrl(inputImageUrl)
+		imageUrl, err := userImageUrl(inputImageUrl, out)
 		if err != nil {
 			return err
 		}
@@ -32,19 +32,31 @@ func handleImageUrl(out io.Writer, wallpapersPath string) error {
 			return err
 		}
 
-		err = setWallpaper(image)
+		err = setWallpaperFit(out, image, fit, effects)
 		if err != nil {
 			return err
 		}
 
 		imageCleanup := func() {
-			os.Remove(image)
+			if _, err := moveToTrash(image, trashReasonRejected); err != nil {
+				fmt.Fprintf(out, "Could not move the rejected download to the trash: %v\n", err)
+				return
+			}
+			// The download is gone, what backdrop knows about it goes too.
+			if err := relocateImage(out, image, ""); err != nil {
+				fmt.Fprintf(out, "Could not forget the rejected download: %v\n", err)
+			}
 		}
 
-		hasConfirmed, err = handleSelectionConfirmation(previousWallpaper, out, &SelectionOptions{
+		hasConfirmed, err = handleSelectionConfirmation(previousState, out, &SelectionOptions{
 			Prompt:         "",
 			SuccessMessage: "",
 			Cleanup:        imageCleanup,
+			Wallpaper:      image,
+			Source:         historySourceUrl,
+			Fit:            fit,
+			Effects:        effects,
+			SourceURL:      imageUrl,
 		})
 
 		if err != nil {
@@ -55,9 +67,9 @@ func handleImageUrl(out io.Writer, wallpapersPath string) error {
 	return nil
 }
 
-func userImageUrl(r io.Reader) (string, error) {
+func userImageUrl(r io.Reader, out io.Writer) (string, error) {
 	reader := bufio.NewReader(r)
-	fmt.Print("Provide Image Url: ")
+	fmt.Fprint(out, "Provide Image Url: ")
 
 	input, err := reader.ReadString('\n')
 	if err != nil {
@@ -81,8 +93,17 @@ func downloadImage(imageUrl, wallpapersPath string) (string, error) {
 
 	fileName := strings.Split(imageUrl, "/")
 	sanitizedFileName := sanitizeFilename(fileName[len(fileName)-1])
-	filePath := filepath.Join(wallpapersPath, sanitizedFileName)
-	file, err := os.Create(filePath)
+	// Images already in the library are never overwritten, so rejecting the download
+	// only ever removes the file it created.
+	var filePath string
+	var file *os.File
+	for {
+		filePath = uniqueImagePath(filepath.Join(wallpapersPath, sanitizedFileName))
+		file, err = os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			break
+		}
+	}
 	if err != nil {
 		return "", fmt.Errorf("Could not create file for image url, got error: %v", err)
 	}
@@ -90,6 +111,7 @@ func downloadImage(imageUrl, wallpapersPath string) (string, error) {
 
 	_, err = io.Copy(file, resp.Body)
 	if err != nil {
+		os.Remove(filePath)
 		return "", fmt.Errorf("Could not copy contents from image url to file, got error: %v", err)
 	}
 
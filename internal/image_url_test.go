@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRejectedDownloadKeepsLibraryImage(t *testing.T) {
+	library := setupManagedLibrary(t)
+
+	source := filepath.Join(t.TempDir(), "lake.png")
+	writeSizedPNG(t, source, 32, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, source)
+	}))
+	defer server.Close()
+
+	originalUrl, originalConfirmation := inputImageUrl, inputConfirmation
+	defer func() { inputImageUrl, inputConfirmation = originalUrl, originalConfirmation }()
+	inputImageUrl = strings.NewReader(server.URL + "/lake.png\n")
+	inputConfirmation = strings.NewReader("n\n")
+
+	// lake.png is in the library already, tagged, in the history and in a slideshow.
+	var out bytes.Buffer
+	if err := handleImageUrl(&out, library, Fit{Mode: "zoom"}, nil); !errors.Is(err, io.EOF) {
+		t.Fatalf("Expected error '%v' once no URL is left, but got '%v' instead", io.EOF, err)
+	}
+	if strings.Contains(out.String(), "Could not") {
+		t.Errorf("Expected the rejected download to be forgotten, but got output '%s'", out.String())
+	}
+
+	lake, download := filepath.Join(library, "lake.png"), filepath.Join(library, "lake-2.png")
+	if _, err := os.Stat(download); !os.IsNotExist(err) {
+		t.Errorf("Expected the rejected download to be moved to the trash, but got '%v'", err)
+	}
+	if images, err := trashedImages(); err != nil || len(images) != 1 || images[0].Path != download {
+		t.Errorf("Expected only '%s' in the trash, but got %+v and '%v'", download, images, err)
+	}
+
+	if _, err := os.Stat(lake); err != nil {
+		t.Errorf("Expected the library image to be kept, but got '%v'", err)
+	}
+	if metadata, err := getImageMetadata(lake); err != nil || !slices.Equal(metadata.Tags, []string{"calm"}) {
+		t.Errorf("Expected the library image to keep its tags, but got %+v and '%v'", metadata, err)
+	}
+	if history, err := loadHistory(); err != nil || len(history) != 1 || history[0].Image != lake {
+		t.Errorf("Expected the library image to stay in the history, but got %v and '%v'", history, err)
+	}
+	expectSlideShow(t, library, "lake.png", "tower.png")
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+)
+
+const thumbnailWidth = 320
+
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %s: %v", ErrUnsupportedImage, path, err)
+	}
+	return img, nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	return rgba
+}
+
+// resizeImage scales the image to the given size by averaging the source pixels
+// covered by every destination pixel, which keeps downscaled photos smooth.
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	source := toRGBA(src)
+	srcWidth, srcHeight := source.Rect.Dx(), source.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := sy*source.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(source.Pix[offset])
+					g += uint64(source.Pix[offset+1])
+					b += uint64(source.Pix[offset+2])
+					a += uint64(source.Pix[offset+3])
+					offset += 4
+					count++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / count)
+			dst.Pix[i+1] = uint8(g / count)
+			dst.Pix[i+2] = uint8(b / count)
+			dst.Pix[i+3] = uint8(a / count)
+		}
+	}
+
+	return dst
+}
+
+// cacheKey identifies a derived file by its source file and the parameters used
+// to generate it, so edits to the source invalidate the cached file.
+func cacheKey(path string, params ...any) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s|%d|%d", path, stat.Size(), stat.ModTime().UnixNano())
+	for _, param := range params {
+		fmt.Fprintf(hash, "|%v", param)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32], nil
+}
+
+// getThumbnail returns a cached JPEG thumbnail of the image, generating it if needed.
+func getThumbnail(path string) (string, error) {
+	thumbnailsPath, err := getCachePath("thumbnails")
+	if err != nil {
+		return "", err
+	}
+
+	key, err := cacheKey(path, thumbnailWidth)
+	if err != nil {
+		return "", err
+	}
+
+	thumbnail := filepath.Join(thumbnailsPath, key+".jpg")
+	if _, err := os.Stat(thumbnail); err == nil {
+		return thumbnail, nil
+	}
+
+	img, err := decodeImageFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	height := max(bounds.Dy()*thumbnailWidth/max(bounds.Dx(), 1), 1)
+	resized := resizeImage(img, thumbnailWidth, height)
+
+	if err := writeJPEG(thumbnail, resized, 85); err != nil {
+		return "", err
+	}
+	return thumbnail, nil
+}
+
+func writeJPEG(path string, img image.Image, quality int) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := jpeg.Encode(file, img, &jpeg.Options{Quality: quality}); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
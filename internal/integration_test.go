@@ -3,6 +3,7 @@ package internal
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -34,7 +35,7 @@ func TestSetWallpaper(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error getting system initial wallpaper for eventual cleanup after tests: %v", err)
 	}
-	defer setWallpaper(initialWallpaper)
+	defer setWallpaper(io.Discard, initialWallpaper)
 
 	testFile, cleanupTempImageFile := setupTempImageFile(t)
 	defer cleanupTempImageFile()
@@ -71,7 +72,7 @@ func TestConfigurePath(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error getting system initial wallpaper for eventual cleanup after tests: %v", err)
 	}
-	defer setWallpaper(initialWallpaper)
+	defer setWallpaper(io.Discard, initialWallpaper)
 
 	cleanupCustomPath := cleanupCustomPath(t)
 	defer cleanupCustomPath()
@@ -116,7 +117,7 @@ func TestSetSlideShow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error getting system initial wallpaper for eventual cleanup after tests: %v", err)
 	}
-	defer setWallpaper(initialWallpaper)
+	defer setWallpaper(io.Discard, initialWallpaper)
 
 	cleanupCustomPath := cleanupCustomPath(t)
 	defer cleanupCustomPath()
@@ -194,7 +195,7 @@ func TestSetImageUrl(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error getting system initial wallpaper for eventual cleanup after tests: %v", err)
 	}
-	defer setWallpaper(initialWallpaper)
+	defer setWallpaper(io.Discard, initialWallpaper)
 
 	cleanup := cleanupImageUrl(t)
 	defer cleanup()
@@ -289,12 +290,10 @@ func cleanupCustomPath(t *testing.T) func() {
 			if err != nil {
 				t.Fatalf("Error during CustomPathTest cleanup, couldn't set original configureImagePath: '%v'", err)
 			} else {
-				homePath, err := os.UserHomeDir()
+				configPath, err := getConfigFilePath()
 				if err != nil {
-					t.Fatalf("Error during CustomPathTest cleanup, could'nt get user home directory: '%v'", err)
+					t.Fatalf("Error during CustomPathTest cleanup, could'nt get config file path: '%v'", err)
 				}
-
-				configPath := filepath.Join(homePath, ".backdrop.yaml")
 				os.Remove(configPath)
 
 			}
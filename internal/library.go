@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LibraryImage is an image file found in one of the library roots.
+type LibraryImage struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+func getLibraryImages() ([]LibraryImage, error) {
+	roots, err := getLibraryRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	inProfile, err := profileImageFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	var images []LibraryImage
+	for _, root := range roots {
+		wallpapers, err := getWallpapers(root.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, wallpaper := range wallpapers {
+			path := filepath.Join(root.Path, wallpaper)
+			stat, err := os.Stat(path)
+			if err != nil || !stat.Mode().IsRegular() || !inProfile(path) {
+				continue
+			}
+
+			images = append(images, LibraryImage{
+				Name:     libraryRootName(roots, root, wallpaper),
+				Path:     path,
+				Size:     stat.Size(),
+				Modified: stat.ModTime(),
+			})
+		}
+	}
+
+	return images, nil
+}
+
+// findLibraryImage only resolves names listed in the library, so names coming from
+// outside backdrop can never point at arbitrary files.
+func findLibraryImage(name string) (*LibraryImage, error) {
+	images, err := getLibraryImages()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		if image.Name == name {
+			return &image, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w : %s", ErrImageNotFound, name)
+}
+
+// libraryName returns the library name of the image at path, false when the image
+// is not in a library root.
+func libraryName(path string) (string, bool) {
+	roots, err := getLibraryRoots()
+	if err != nil {
+		return "", false
+	}
+
+	for _, root := range roots {
+		file, err := filepath.Rel(root.Path, path)
+		if err != nil || file == "." || strings.HasPrefix(file, ".."+string(filepath.Separator)) || file == ".." {
+			continue
+		}
+		return libraryRootName(roots, root, file), true
+	}
+	return "", false
+}
+
+// setRandomWallpaper sets a random library image other than the current one.
+func setRandomWallpaper(out io.Writer, source string) (string, error) {
+	libraryImages, err := getLibraryImages()
+	if err != nil {
+		return "", err
+	}
+
+	images := make([]string, 0, len(libraryImages))
+	for _, libraryImage := range libraryImages {
+		images = append(images, libraryImage.Path)
+	}
+
+	currentWallpaper, _ := getPreviousWallpaper()
+	if len(images) == 0 || (len(images) == 1 && images[0] == currentWallpaper) {
+		return "", ErrEmptyPlaylist
+	}
+
+	image := pickRandomImage(images, currentWallpaper)
+	if err := setWallpaper(out, image); err != nil {
+		return "", err
+	}
+
+	return image, recordHistory(image, source)
+}
+
+// pickRandomImage avoids picking the current wallpaper unless it is the only image.
+func pickRandomImage(images []string, currentWallpaper string) string {
+	candidates := make([]string, 0, len(images))
+	for _, image := range images {
+		if image != currentWallpaper {
+			candidates = append(candidates, image)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return images[0]
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
@@ -0,0 +1,559 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/spf13/viper"
+)
+
+// OrganizeKeys are the ways "organize" can sort the library into folders.
+var OrganizeKeys = []string{"orientation", "resolution", "color"}
+
+// resolutionBuckets name images after the longest side, largest first.
+var resolutionBuckets = []struct {
+	name    string
+	longest int
+}{
+	{"8k", 7680},
+	{"5k", 5120},
+	{"4k", 3840},
+	{"1440p", 2560},
+	{"1080p", 1920},
+	{"720p", 1280},
+	{"smaller", 0},
+}
+
+// libraryFile is an image file of a library root, whatever the active profile.
+type libraryFile struct {
+	root LibraryRoot
+	path string
+}
+
+func libraryFiles() ([]libraryFile, error) {
+	roots, err := getLibraryRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []libraryFile
+	for _, root := range roots {
+		wallpapers, err := getWallpapers(root.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, wallpaper := range wallpapers {
+			path := filepath.Join(root.Path, wallpaper)
+			if stat, err := os.Stat(path); err == nil && stat.Mode().IsRegular() {
+				files = append(files, libraryFile{root: root, path: path})
+			}
+		}
+	}
+	return files, nil
+}
+
+// resolveLibraryImage finds an image given as a path or a name, it has to be in the
+// library.
+func resolveLibraryImage(image string) (string, string, error) {
+	path, err := resolveImage(image)
+	if err != nil {
+		return "", "", err
+	}
+	name, ok := libraryName(path)
+	if !ok {
+		return "", "", fmt.Errorf("%w : %s is not in the library", ErrImageNotFound, image)
+	}
+	return path, name, nil
+}
+
+// uniqueImagePath returns the path, or the first of "forest-2.jpg", "forest-3.jpg" and
+// so on that is free.
+func uniqueImagePath(path string) string {
+	extension := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, extension)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d%s", stem, i, extension)
+	}
+}
+
+// duplicateIndex finds library images with the same content, hashing only the
+// images of the same size.
+type duplicateIndex struct {
+	bySize map[int64][]string
+	hashes map[string]string
+}
+
+func newDuplicateIndex(files []libraryFile) *duplicateIndex {
+	index := &duplicateIndex{bySize: make(map[int64][]string), hashes: make(map[string]string)}
+	for _, file := range files {
+		index.add(file.path)
+	}
+	return index
+}
+
+func (d *duplicateIndex) add(path string) {
+	if stat, err := os.Stat(path); err == nil {
+		d.bySize[stat.Size()] = append(d.bySize[stat.Size()], path)
+	}
+}
+
+func (d *duplicateIndex) hash(path string) string {
+	if hash, ok := d.hashes[path]; ok {
+		return hash
+	}
+	hash, _ := contentCacheKey(path)
+	d.hashes[path] = hash
+	return hash
+}
+
+// find returns a library image with the same content as the file.
+func (d *duplicateIndex) find(file string) (string, bool) {
+	stat, err := os.Stat(file)
+	if err != nil {
+		return "", false
+	}
+
+	hash := d.hash(file)
+	for _, path := range d.bySize[stat.Size()] {
+		if hash != "" && d.hash(path) == hash {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// AddImages copies the files into the wallpapers path, or links them with link.
+// Files already in the library are skipped, whatever their name.
+func AddImages(out io.Writer, files []string, link bool) error {
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+	existing, err := libraryFiles()
+	if err != nil {
+		return err
+	}
+	duplicates := newDuplicateIndex(existing)
+
+	for _, file := range files {
+		source, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		stat, err := os.Stat(source)
+		if err != nil {
+			return err
+		}
+		if !stat.Mode().IsRegular() {
+			return fmt.Errorf("%w : %s is not a file", ErrUnsupportedImage, file)
+		}
+		if _, err := imageSize(source); err != nil {
+			return fmt.Errorf("%w : %s: %v", ErrUnsupportedImage, file, err)
+		}
+
+		if duplicate, ok := duplicates.find(source); ok {
+			name, _ := libraryName(duplicate)
+			fmt.Fprintf(out, "Skipped %s, it is already in the library as %s.\n", file, orDash(name))
+			continue
+		}
+
+		target := uniqueImagePath(filepath.Join(wallpapersPath, filepath.Base(source)))
+		if link {
+			err = os.Symlink(source, target)
+		} else {
+			err = copyFile(source, target, 0644)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add %s to the library: %w", file, err)
+		}
+		duplicates.add(target)
+
+		name, _ := libraryName(target)
+		fmt.Fprintf(out, "Added %s as %s.\n", file, name)
+	}
+	return nil
+}
+
+// RemoveImages moves library images to the trash and forgets them everywhere
+// backdrop refers to them.
+func RemoveImages(out io.Writer, images []string) error {
+	active := activeSlideShowImages()
+
+	for _, image := range images {
+		path, name, err := resolveLibraryImage(image)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(active, path) {
+			fmt.Fprintf(out, "Warning: %s is shown by the active slideshow, it is dropped from it.\n", name)
+		}
+
+		if _, err := moveToTrash(path, trashReasonRemoved); err != nil {
+			return fmt.Errorf("failed to move %s to the trash: %w", name, err)
+		}
+		if err := relocateImage(out, path, ""); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Moved %s to the trash.\n", name)
+	}
+	return nil
+}
+
+// MoveImage moves or renames a library image. A destination ending in a separator,
+// or naming an existing folder, keeps the file name, one without an extension keeps
+// the extension. Relative destinations are in the wallpapers path.
+func MoveImage(out io.Writer, image, destination string) error {
+	from, name, err := resolveLibraryImage(image)
+	if err != nil {
+		return err
+	}
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+
+	to, err := filepath.Abs(libraryImagePath(wallpapersPath, destination))
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(destination, "/") || strings.HasSuffix(destination, string(filepath.Separator)) || isDirectory(to) {
+		to = filepath.Join(to, filepath.Base(from))
+	} else if filepath.Ext(to) == "" {
+		to += filepath.Ext(from)
+	}
+
+	if to == from {
+		return nil
+	}
+	if _, err := os.Lstat(to); err == nil {
+		return fmt.Errorf("%w : %s", ErrImageExists, to)
+	}
+
+	if err := moveLibraryImage(out, from, to); err != nil {
+		return err
+	}
+
+	newName, ok := libraryName(to)
+	if !ok {
+		fmt.Fprintf(out, "Moved %s to %s, it is no longer in the library.\n", name, to)
+		return nil
+	}
+	fmt.Fprintf(out, "Moved %s to %s.\n", name, newName)
+	return nil
+}
+
+func moveLibraryImage(out io.Writer, from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", to, err)
+	}
+	if err := movePath(from, to); err != nil {
+		return err
+	}
+	return relocateImage(out, from, to)
+}
+
+// OrganizeLibrary moves every image of a library root into a folder of the root
+// named after its orientation, resolution or dominant color.
+func OrganizeLibrary(out io.Writer, by string, dryRun bool) error {
+	if !slices.Contains(OrganizeKeys, by) {
+		return fmt.Errorf("%w : '%s', expected one of %v", ErrInvalidOrganize, by, OrganizeKeys)
+	}
+
+	files, err := libraryFiles()
+	if err != nil {
+		return err
+	}
+
+	moved := 0
+	for _, file := range files {
+		name, _ := libraryName(file.path)
+		folder, err := organizeFolder(by, file.path)
+		if err != nil {
+			fmt.Fprintf(out, "Skipped %s: %v\n", name, err)
+			continue
+		}
+
+		target := filepath.Join(file.root.Path, folder, filepath.Base(file.path))
+		if target == file.path {
+			continue
+		}
+		target = uniqueImagePath(target)
+		moved++
+
+		if dryRun {
+			fmt.Fprintf(out, "Would move %s to %s.\n", name, filepath.ToSlash(folder)+"/")
+			continue
+		}
+		if err := moveLibraryImage(out, file.path, target); err != nil {
+			return err
+		}
+		// Folders emptied by sorting an earlier way are removed, the root never is.
+		if dir := filepath.Dir(file.path); dir != file.root.Path {
+			os.Remove(dir)
+		}
+		fmt.Fprintf(out, "Moved %s to %s.\n", name, filepath.ToSlash(folder)+"/")
+	}
+
+	switch {
+	case moved == 0:
+		fmt.Fprintf(out, "The library is already organized by %s.\n", by)
+	case dryRun:
+		fmt.Fprintf(out, "%d images would be moved, run again without --dry-run to move them.\n", moved)
+	default:
+		fmt.Fprintf(out, "Organized %d images by %s.\n", moved, by)
+	}
+	return nil
+}
+
+func organizeFolder(by, path string) (string, error) {
+	if by == "color" {
+		return dominantColorName(path)
+	}
+
+	size, err := imageSize(path)
+	if err != nil {
+		return "", fmt.Errorf("%w : %v", ErrUnsupportedImage, err)
+	}
+	if by == "orientation" {
+		return imageOrientation(size.X, size.Y), nil
+	}
+
+	longest := max(size.X, size.Y)
+	for _, bucket := range resolutionBuckets {
+		if longest >= bucket.longest {
+			return bucket.name, nil
+		}
+	}
+	return resolutionBuckets[len(resolutionBuckets)-1].name, nil
+}
+
+// colorNames are the folders of "organize --by color", in the order ties are broken.
+var colorNames = []string{"black", "white", "gray", "red", "orange", "yellow", "green", "cyan", "blue", "purple", "pink"}
+
+// dominantColorName names the color most pixels of a small copy of the image are
+// closest to.
+func dominantColorName(path string) (string, error) {
+	img, err := decodeImageFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	scale := float64(paletteSampleSize) / float64(max(bounds.Dx(), bounds.Dy(), 1))
+	sample := resizeImage(img, max(int(float64(bounds.Dx())*scale), 1), max(int(float64(bounds.Dy())*scale), 1))
+
+	counts := make(map[string]int)
+	for i := 0; i < len(sample.Pix); i += 4 {
+		counts[colorName(rgb{float64(sample.Pix[i]), float64(sample.Pix[i+1]), float64(sample.Pix[i+2])})]++
+	}
+
+	dominant := colorNames[0]
+	for _, name := range colorNames {
+		if counts[name] > counts[dominant] {
+			dominant = name
+		}
+	}
+	return dominant, nil
+}
+
+func colorName(c rgb) string {
+	high := math.Max(c[0], math.Max(c[1], c[2])) / 255
+	low := math.Min(c[0], math.Min(c[1], c[2])) / 255
+	lightness := (high + low) / 2
+
+	switch {
+	case lightness < 0.15:
+		return "black"
+	case high-low < 0.15 && lightness > 0.85:
+		return "white"
+	case high-low < 0.15:
+		return "gray"
+	}
+
+	switch hue := c.hue(); {
+	case hue < 15 || hue >= 345:
+		return "red"
+	case hue < 45:
+		return "orange"
+	case hue < 70:
+		return "yellow"
+	case hue < 165:
+		return "green"
+	case hue < 195:
+		return "cyan"
+	case hue < 255:
+		return "blue"
+	case hue < 290:
+		return "purple"
+	default:
+		return "pink"
+	}
+}
+
+// activeSlideShowImages returns the images of the slideshow set as wallpaper, if any.
+func activeSlideShowImages() []string {
+	current, err := getPreviousWallpaper()
+	if err != nil || !os_Specifics.IsSlideShowFile(current) {
+		return nil
+	}
+	background, err := os_Specifics.ParseBackgroundFile(current)
+	if err != nil {
+		return nil
+	}
+	return background.Images()
+}
+
+// relocateImage points everything that refers to the image at its new path: its
+// metadata, dark mode variants, the history, saved slideshows and slideshow files.
+// An empty path forgets the image instead.
+func relocateImage(out io.Writer, from, to string) error {
+	if err := relocateMetadata(from, to); err != nil {
+		return err
+	}
+	if err := relocateHistory(from, to); err != nil {
+		return err
+	}
+	if err := relocateSavedSlideShows(out, from, to); err != nil {
+		return err
+	}
+	if runtime.GOOS == "linux" {
+		return relocateSlideShowFiles(out, from, to)
+	}
+	// Windows slideshows are folders of copies, they don't refer to library images.
+	return nil
+}
+
+// relocateImages replaces from with to in the images, dropping it when to is empty.
+func relocateImages(images []string, from, to string, path func(string) string) ([]string, bool) {
+	relocated := make([]string, 0, len(images))
+	changed := false
+	for _, image := range images {
+		if path(image) != from {
+			relocated = append(relocated, image)
+			continue
+		}
+		changed = true
+		if to != "" {
+			relocated = append(relocated, to)
+		}
+	}
+	return relocated, changed
+}
+
+func relocateMetadata(from, to string) error {
+	return updateMetadata(func(metadata map[string]ImageMetadata) bool {
+		changed := false
+		if imageMetadata, ok := metadata[from]; ok {
+			delete(metadata, from)
+			if to != "" {
+				metadata[to] = imageMetadata
+			}
+			changed = true
+		}
+		for image, imageMetadata := range metadata {
+			if imageMetadata.Dark != from {
+				continue
+			}
+			imageMetadata.Dark = to
+			if imageMetadata.isZero() {
+				delete(metadata, image)
+			} else {
+				metadata[image] = imageMetadata
+			}
+			changed = true
+		}
+		return changed
+	})
+}
+
+func relocateHistory(from, to string) error {
+	return updateHistory(func(history []HistoryEntry) ([]HistoryEntry, bool) {
+		relocated := make([]HistoryEntry, 0, len(history))
+		changed := false
+		for _, entry := range history {
+			if entry.Image == from {
+				changed = true
+				if to == "" {
+					continue
+				}
+				entry.Image = to
+			}
+			relocated = append(relocated, entry)
+		}
+		return relocated, changed
+	})
+}
+
+func relocateSavedSlideShows(out io.Writer, from, to string) error {
+	slideShows, err := getSavedSlideShows()
+	if err != nil {
+		return err
+	}
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+
+	newName := ""
+	if to != "" {
+		newName = libraryImageName(wallpapersPath, to)
+	}
+	imagePath := func(image string) string { return libraryImagePath(wallpapersPath, image) }
+
+	changed := false
+	for i, slideShow := range slideShows {
+		images, relocated := relocateImages(slideShow.Images, from, newName, imagePath)
+		if !relocated {
+			continue
+		}
+		if len(images) == 0 {
+			fmt.Fprintf(out, "Warning: slideshow '%s' has no other images, it is kept as it is.\n", slideShow.Name)
+			continue
+		}
+		slideShows[i].Images = images
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	viper.Set(slideShowsConfigKey, slideShows)
+	return writeConfig()
+}
+
+func relocateSlideShowFiles(out io.Writer, from, to string) error {
+	files, err := os_Specifics.ListLinuxSlideShowConfigFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		background, err := os_Specifics.ParseBackgroundFile(file)
+		if err != nil {
+			continue
+		}
+
+		images, relocated := relocateImages(background.Images(), from, to, func(image string) string { return image })
+		if !relocated {
+			continue
+		}
+		if len(images) == 0 {
+			fmt.Fprintf(out, "Warning: slideshow file %s has no other images, it is kept as it is.\n", filepath.Base(file))
+			continue
+		}
+		if err := os_Specifics.RewriteLinuxSlideShowConfigFile(file, images, background.Duration()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/spf13/viper"
+)
+
+// setupManagedLibrary creates a library with a landscape and a portrait image, the
+// landscape one tagged, in the history and in the "water" slideshow set as wallpaper.
+func setupManagedLibrary(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(imagePathEnv, "")
+	originalPath, originalSlideShows := viper.Get(wallpapersPathConfigKey), viper.Get(slideShowsConfigKey)
+	t.Cleanup(func() {
+		viper.Set(wallpapersPathConfigKey, originalPath)
+		viper.Set(slideShowsConfigKey, originalSlideShows)
+	})
+
+	library := t.TempDir()
+	viper.Set(wallpapersPathConfigKey, library)
+	viper.Set(slideShowsConfigKey, nil)
+	writeSizedPNG(t, filepath.Join(library, "lake.png"), 32, 16)
+	writeSizedPNG(t, filepath.Join(library, "tower.png"), 16, 32)
+
+	lake := filepath.Join(library, "lake.png")
+	if err := updateImageMetadata(lake, func(metadata *ImageMetadata) { metadata.Tags = []string{"calm"} }); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordHistory(lake, historySourceFuzzy); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeSlideShow(SavedSlideShow{Name: "water", Images: []string{"lake.png", "tower.png"}, Duration: 60}); err != nil {
+		t.Fatal(err)
+	}
+	// Slideshows saved from the finder are written with the wallpapers path.
+	if _, err := configureNamedSlideShow("water", []string{"lake.png", "tower.png"}, library, 60, Fit{}); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := newFakeBackend(allOutputs)
+	backend.current = slideShowFile(t, "water")
+	originalGetBackend := getBackend
+	getBackend = func() (wallpaperBackend, error) { return backend, nil }
+	t.Cleanup(func() { getBackend = originalGetBackend })
+	return library
+}
+
+func slideShowFile(t *testing.T, name string) string {
+	t.Helper()
+
+	files, err := os_Specifics.ListLinuxSlideShowConfigFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if filepath.Base(file) == "slideshow_"+name+".xml" {
+			return file
+		}
+	}
+	t.Fatalf("Expected a slideshow file for '%s', but got %v", name, files)
+	return ""
+}
+
+// expectSlideShow checks the images of the saved slideshow and of its file.
+func expectSlideShow(t *testing.T, library string, expImages ...string) {
+	t.Helper()
+
+	slideShow, err := findSavedSlideShow("water")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(slideShow.Images, expImages) {
+		t.Errorf("Expected the saved slideshow to have %v, but got %v instead", expImages, slideShow.Images)
+	}
+
+	background, err := os_Specifics.ParseBackgroundFile(slideShowFile(t, "water"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expPaths []string
+	for _, image := range expImages {
+		expPaths = append(expPaths, filepath.Join(library, image))
+	}
+	if !slices.Equal(background.Images(), expPaths) {
+		t.Errorf("Expected the slideshow file to show %v, but got %v instead", expPaths, background.Images())
+	}
+}
+
+func TestAddImages(t *testing.T) {
+	library := setupManagedLibrary(t)
+
+	downloads := t.TempDir()
+	writeSizedPNG(t, filepath.Join(downloads, "lake.png"), 64, 16)
+	writeSizedPNG(t, filepath.Join(downloads, "copy.png"), 32, 16)
+	writeSizedPNG(t, filepath.Join(downloads, "dune.png"), 48, 16)
+
+	var out bytes.Buffer
+	files := []string{filepath.Join(downloads, "lake.png"), filepath.Join(downloads, "copy.png"), filepath.Join(downloads, "lake.png")}
+	if err := AddImages(&out, files, false); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if err := AddImages(&out, []string{filepath.Join(downloads, "dune.png")}, true); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	// copy.png has the content of lake.png, the second lake.png was added already.
+	for _, exp := range []string{"as lake-2.png", "copy.png, it is already in the library as lake.png", "lake.png, it is already in the library as lake-2.png"} {
+		if !strings.Contains(out.String(), exp) {
+			t.Errorf("Expected the output to contain '%s', but got '%s'", exp, out.String())
+		}
+	}
+	if link, err := os.Readlink(filepath.Join(library, "dune.png")); err != nil || link != filepath.Join(downloads, "dune.png") {
+		t.Errorf("Expected dune.png to be linked, but got '%s', '%v'", link, err)
+	}
+
+	if err := AddImages(&out, []string{filepath.Join(library, "missing.png")}, false); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected error '%v', but got '%v' instead", os.ErrNotExist, err)
+	}
+}
+
+func TestMoveImage(t *testing.T) {
+	library := setupManagedLibrary(t)
+
+	var out bytes.Buffer
+	if err := MoveImage(&out, "lake.png", "water/"); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if err := MoveImage(&out, "water/lake.png", "water/pond"); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	pond := filepath.Join(library, "water", "pond.png")
+	if _, err := os.Stat(pond); err != nil {
+		t.Fatalf("Expected the image to be moved, but got '%v'", err)
+	}
+	if metadata, err := getImageMetadata(pond); err != nil || !slices.Equal(metadata.Tags, []string{"calm"}) {
+		t.Errorf("Expected the tags to follow the image, but got %v, '%v'", metadata.Tags, err)
+	}
+	if history, err := loadHistory(); err != nil || len(history) != 1 || history[0].Image != pond {
+		t.Errorf("Expected the history to follow the image, but got %v, '%v'", history, err)
+	}
+	expectSlideShow(t, library, "water/pond.png", "tower.png")
+
+	if err := MoveImage(&out, "tower.png", "water/pond.png"); !errors.Is(err, ErrImageExists) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrImageExists, err)
+	}
+	if err := MoveImage(&out, "lake.png", "sea.png"); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrImageNotFound, err)
+	}
+}
+
+func TestRemoveImages(t *testing.T) {
+	library := setupManagedLibrary(t)
+
+	var out bytes.Buffer
+	if err := RemoveImages(&out, []string{"lake.png"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if !strings.Contains(out.String(), "Warning: lake.png is shown by the active slideshow") {
+		t.Errorf("Expected a warning about the active slideshow, but got '%s'", out.String())
+	}
+
+	trashPath, err := getTrashPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(trashPath, "files", "lake.png")); err != nil {
+		t.Errorf("Expected the image in the trash, but got '%v'", err)
+	}
+	info, err := os.ReadFile(filepath.Join(trashPath, "info", "lake.png"+trashInfoExtension))
+	if err != nil || !strings.Contains(string(info), "Path="+filepath.Join(library, "lake.png")) {
+		t.Errorf("Expected trash info with the original path, but got '%s', '%v'", info, err)
+	}
+
+	if metadata, err := loadMetadata(); err != nil || len(metadata) != 0 {
+		t.Errorf("Expected the metadata to be forgotten, but got %v, '%v'", metadata, err)
+	}
+	if history, err := loadHistory(); err != nil || len(history) != 0 {
+		t.Errorf("Expected the history to be forgotten, but got %v, '%v'", history, err)
+	}
+	expectSlideShow(t, library, "tower.png")
+
+	// The last image of a slideshow is kept in it.
+	out.Reset()
+	if err := RemoveImages(&out, []string{"tower.png"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if !strings.Contains(out.String(), "slideshow 'water' has no other images") {
+		t.Errorf("Expected a warning about the emptied slideshow, but got '%s'", out.String())
+	}
+	expectSlideShow(t, library, "tower.png")
+}
+
+func TestOrganizeLibrary(t *testing.T) {
+	library := setupManagedLibrary(t)
+
+	var out bytes.Buffer
+	if err := OrganizeLibrary(&out, "orientation", true); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if _, err := os.Stat(filepath.Join(library, "lake.png")); err != nil {
+		t.Errorf("Expected a dry run to leave the images, but got '%v'", err)
+	}
+
+	if err := OrganizeLibrary(&out, "orientation", false); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	images, err := getLibraryWallpapers()
+	if err != nil || !slices.Equal(images, []string{"landscape/lake.png", "portrait/tower.png"}) {
+		t.Errorf("Expected the images in orientation folders, but got %v, '%v'", images, err)
+	}
+	expectSlideShow(t, library, "landscape/lake.png", "portrait/tower.png")
+
+	if err := OrganizeLibrary(&out, "resolution", false); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	images, err = getLibraryWallpapers()
+	if err != nil || !slices.Equal(images, []string{"smaller/lake.png", "smaller/tower.png"}) {
+		t.Errorf("Expected the images in resolution folders, but got %v, '%v'", images, err)
+	}
+	if _, err := os.Stat(filepath.Join(library, "landscape")); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied folder to be removed, but got '%v'", err)
+	}
+
+	if err := OrganizeLibrary(&out, "size", false); !errors.Is(err, ErrInvalidOrganize) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidOrganize, err)
+	}
+}
+
+func TestColorName(t *testing.T) {
+	testCases := []struct {
+		color   rgb
+		expName string
+	}{
+		{color: rgb{10, 10, 12}, expName: "black"},
+		{color: rgb{250, 250, 250}, expName: "white"},
+		{color: rgb{128, 128, 128}, expName: "gray"},
+		{color: rgb{200, 30, 30}, expName: "red"},
+		{color: rgb{30, 160, 60}, expName: "green"},
+		{color: rgb{40, 80, 200}, expName: "blue"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expName, func(t *testing.T) {
+			if got := colorName(testCase.color); got != testCase.expName {
+				t.Errorf("Expected '%s', but got '%s' instead", testCase.expName, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLibraryRoots(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	originalPath, originalRoots := viper.Get("WallpapersPath"), viper.Get("LibraryRoots")
+	defer func() {
+		viper.Set("WallpapersPath", originalPath)
+		viper.Set("LibraryRoots", originalRoots)
+	}()
+
+	dir := t.TempDir()
+	mkdir := func(parts ...string) string {
+		path := filepath.Join(append([]string{dir}, parts...)...)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pictures, nas, team := mkdir("home", "wallpapers"), mkdir("nas", "wallpapers"), mkdir("team")
+	for _, image := range []string{filepath.Join(pictures, "lake.jpg"), filepath.Join(nas, "forest.jpg"), filepath.Join(team, "logo.png")} {
+		if err := os.WriteFile(image, []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		env       string
+		path      string
+		roots     []string
+		expNames  []string
+		expImages []string
+		expErr    error
+	}{
+		{name: "Nothing", path: filepath.Join(dir, "missing"), expErr: ErrNoValidImagesPath},
+		{name: "WallpapersPath", path: pictures, expNames: []string{"wallpapers"}, expImages: []string{"lake.jpg"}},
+		{
+			name:      "ConfigRoots",
+			path:      pictures,
+			roots:     []string{nas, "shared=" + team, filepath.Join(dir, "missing")},
+			expNames:  []string{"wallpapers", "wallpapers-2", "shared"},
+			expImages: []string{"wallpapers/lake.jpg", "wallpapers-2/forest.jpg", "shared/logo.png"},
+		},
+		{
+			name:      "EnvironmentFirst",
+			env:       "nas=" + nas + string(filepath.ListSeparator) + team,
+			path:      pictures,
+			expNames:  []string{"nas", "team"},
+			expImages: []string{"nas/forest.jpg", "team/logo.png"},
+		},
+		{name: "MissingEnvironment", env: filepath.Join(dir, "missing"), path: team, expNames: []string{"team"}, expImages: []string{"logo.png"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv(imagePathEnv, testCase.env)
+			viper.Set("WallpapersPath", testCase.path)
+			viper.Set("LibraryRoots", testCase.roots)
+
+			roots, err := getLibraryRoots()
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+
+			var names []string
+			for _, root := range roots {
+				names = append(names, root.Name)
+			}
+			if !slices.Equal(names, testCase.expNames) {
+				t.Errorf("Expected roots %v, but got %v instead", testCase.expNames, names)
+			}
+			if testCase.expErr != nil {
+				return
+			}
+
+			images, err := getLibraryWallpapers()
+			if err != nil {
+				t.Fatalf("Expected NO error, but got '%v' instead", err)
+			}
+			if !slices.Equal(images, testCase.expImages) {
+				t.Errorf("Expected images %v, but got %v instead", testCase.expImages, images)
+			}
+
+			for _, image := range images {
+				path := libraryImagePath(roots[0].Path, image)
+				if _, err := os.Stat(path); err != nil {
+					t.Errorf("Expected '%s' to resolve to an image, but got '%s'", image, path)
+				}
+				if name := libraryImageName(roots[0].Path, path); name != image {
+					t.Errorf("Expected '%s' to be named '%s', but got '%s' instead", path, image, name)
+				}
+			}
+		})
+	}
+}
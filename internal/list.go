@@ -0,0 +1,316 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	// ListSortKeys are the values of "list --sort".
+	ListSortKeys = []string{"name", "mtime", "size", "resolution", "last-used", "use-count"}
+	// ListColumns are the values of "list --columns".
+	ListColumns = []string{"name", "path", "size", "modified", "resolution", "orientation", "tags", "last-used", "use-count"}
+	// DefaultListColumns are shown without "list --columns".
+	DefaultListColumns = []string{"name", "resolution", "size", "modified", "tags"}
+
+	orientations = []string{"landscape", "portrait", "square"}
+)
+
+// ListOptions choose which library images are listed and how.
+type ListOptions struct {
+	Sort    string
+	Reverse bool
+	// MinWidth drops images narrower than it, or whose size can't be read.
+	MinWidth    int
+	Orientation string
+	// Tags keeps images with any of them.
+	Tags []string
+	// Since keeps images modified after it, a date like 2024-01-31 or a duration
+	// like 36h, 7d or 2w.
+	Since      string
+	Extensions []string
+	Columns    []string
+}
+
+// ListedImage is a library image with what backdrop knows about it.
+type ListedImage struct {
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	Size        int64      `json:"size"`
+	Modified    time.Time  `json:"modified"`
+	Width       int        `json:"width,omitempty"`
+	Height      int        `json:"height,omitempty"`
+	Orientation string     `json:"orientation,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	LastUsed    *time.Time `json:"last_used,omitempty"`
+	UseCount    int        `json:"use_count"`
+}
+
+func (opts *ListOptions) validate() error {
+	if opts.Sort == "" {
+		opts.Sort = "name"
+	}
+	if !slices.Contains(ListSortKeys, opts.Sort) {
+		return fmt.Errorf("%w : sort '%s', expected one of %v", ErrInvalidListOption, opts.Sort, ListSortKeys)
+	}
+	if opts.Orientation != "" && !slices.Contains(orientations, opts.Orientation) {
+		return fmt.Errorf("%w : orientation '%s', expected one of %v", ErrInvalidListOption, opts.Orientation, orientations)
+	}
+	if opts.MinWidth < 0 {
+		return fmt.Errorf("%w : minimum width can't be negative", ErrInvalidListOption)
+	}
+
+	if len(opts.Columns) == 0 {
+		opts.Columns = DefaultListColumns
+	}
+	for _, column := range opts.Columns {
+		if !slices.Contains(ListColumns, column) {
+			return fmt.Errorf("%w : column '%s', expected one of %v", ErrInvalidListOption, column, ListColumns)
+		}
+	}
+
+	tags, err := parseTags(opts.Tags)
+	if err != nil {
+		return err
+	}
+	opts.Tags = tags
+
+	extensions := make([]string, 0, len(opts.Extensions))
+	for _, extension := range opts.Extensions {
+		extensions = append(extensions, "."+strings.ToLower(strings.TrimPrefix(strings.TrimSpace(extension), ".")))
+	}
+	opts.Extensions = extensions
+	return nil
+}
+
+// parseSince returns the time a date or a duration before now points at.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, since, time.Local); err == nil {
+		return date, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if count, found := strings.CutSuffix(since, suffix); found {
+			if n, err := strconv.Atoi(count); err == nil && n >= 0 {
+				return now.Add(-time.Duration(n) * unit), nil
+			}
+		}
+	}
+	if duration, err := time.ParseDuration(since); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	return time.Time{}, fmt.Errorf("%w : since '%s', expected a date like 2024-01-31 or a duration like 36h, 7d or 2w", ErrInvalidListOption, since)
+}
+
+func imageOrientation(width, height int) string {
+	switch {
+	case width == 0 || height == 0:
+		return ""
+	case width > height:
+		return "landscape"
+	case width < height:
+		return "portrait"
+	default:
+		return "square"
+	}
+}
+
+// listImages returns the library images kept by the options, sorted.
+func listImages(opts ListOptions, now time.Time) ([]ListedImage, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	var since time.Time
+	if opts.Since != "" {
+		var err error
+		if since, err = parseSince(opts.Since, now); err != nil {
+			return nil, err
+		}
+	}
+
+	libraryImages, err := getLibraryImages()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := loadMetadata()
+	if err != nil {
+		return nil, err
+	}
+	history, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	lastUsed := make(map[string]time.Time)
+	useCount := make(map[string]int)
+	for _, entry := range history {
+		useCount[entry.Image]++
+		if entry.SetAt.After(lastUsed[entry.Image]) {
+			lastUsed[entry.Image] = entry.SetAt
+		}
+	}
+
+	var images []ListedImage
+	for _, libraryImage := range libraryImages {
+		if len(opts.Extensions) > 0 && !slices.Contains(opts.Extensions, strings.ToLower(filepath.Ext(libraryImage.Path))) {
+			continue
+		}
+		if !since.IsZero() && libraryImage.Modified.Before(since) {
+			continue
+		}
+		if !hasAnyTag(metadata[libraryImage.Path], opts.Tags) {
+			continue
+		}
+
+		image := ListedImage{
+			Name:     libraryImage.Name,
+			Path:     libraryImage.Path,
+			Size:     libraryImage.Size,
+			Modified: libraryImage.Modified,
+			Tags:     metadata[libraryImage.Path].Tags,
+			UseCount: useCount[libraryImage.Path],
+		}
+		if size, err := imageSize(libraryImage.Path); err == nil {
+			image.Width, image.Height = size.X, size.Y
+			image.Orientation = imageOrientation(size.X, size.Y)
+		}
+		if used, ok := lastUsed[libraryImage.Path]; ok {
+			image.LastUsed = &used
+		}
+
+		if image.Width < opts.MinWidth {
+			continue
+		}
+		if opts.Orientation != "" && image.Orientation != opts.Orientation {
+			continue
+		}
+		images = append(images, image)
+	}
+
+	sortListedImages(images, opts.Sort, opts.Reverse)
+	return images, nil
+}
+
+// sortListedImages sorts by name ascending and by every other key largest or newest
+// first, ties are sorted by name.
+func sortListedImages(images []ListedImage, key string, reverse bool) {
+	lastUsed := func(image ListedImage) int64 {
+		if image.LastUsed == nil {
+			return 0
+		}
+		return image.LastUsed.UnixNano()
+	}
+
+	slices.SortStableFunc(images, func(a, b ListedImage) int {
+		var order int
+		switch key {
+		case "mtime":
+			order = b.Modified.Compare(a.Modified)
+		case "size":
+			order = cmp.Compare(b.Size, a.Size)
+		case "resolution":
+			order = cmp.Compare(b.Width*b.Height, a.Width*a.Height)
+		case "last-used":
+			order = cmp.Compare(lastUsed(b), lastUsed(a))
+		case "use-count":
+			order = cmp.Compare(b.UseCount, a.UseCount)
+		}
+		if order == 0 {
+			order = strings.Compare(a.Name, b.Name)
+		}
+		if reverse {
+			return -order
+		}
+		return order
+	})
+}
+
+// formatSize prints sizes the way "ls -h" does.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, prefix := float64(size)/unit, 0
+	for value >= unit && prefix < 3 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f%c", value, "KMGT"[prefix])
+}
+
+func listColumnValue(image ListedImage, column string) string {
+	switch column {
+	case "name":
+		return image.Name
+	case "path":
+		return image.Path
+	case "size":
+		return formatSize(image.Size)
+	case "modified":
+		return image.Modified.Local().Format("2006-01-02 15:04")
+	case "resolution":
+		if image.Width == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%dx%d", image.Width, image.Height)
+	case "orientation":
+		return orDash(image.Orientation)
+	case "tags":
+		return orDash(strings.Join(image.Tags, ","))
+	case "last-used":
+		if image.LastUsed == nil {
+			return "-"
+		}
+		return image.LastUsed.Local().Format("2006-01-02 15:04")
+	case "use-count":
+		return strconv.Itoa(image.UseCount)
+	}
+	return ""
+}
+
+// ListImages prints the library images kept by the options as a table of the chosen
+// columns, structured output has every field.
+func ListImages(out io.Writer, opts ListOptions) error {
+	images, err := listImages(opts, time.Now())
+	if err != nil {
+		return err
+	}
+	if isStructuredOutput() {
+		if images == nil {
+			images = []ListedImage{}
+		}
+		return writeStructured(out, images)
+	}
+
+	if len(images) == 0 {
+		fmt.Fprintln(out, "No images match.")
+		return nil
+	}
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultListColumns
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, image := range images {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = listColumnValue(image, column)
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func writeSizedPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListImages(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(imagePathEnv, "")
+	library := t.TempDir()
+	originalPath := viper.Get(wallpapersPathConfigKey)
+	defer viper.Set(wallpapersPathConfigKey, originalPath)
+	viper.Set(wallpapersPathConfigKey, library)
+
+	now := time.Now()
+	for _, image := range []struct {
+		name          string
+		width, height int
+		age           time.Duration
+	}{
+		{"forest.png", 64, 16, time.Hour},
+		{"tower.png", 16, 64, 48 * time.Hour},
+		{"tile.png", 32, 32, 240 * time.Hour},
+	} {
+		path := filepath.Join(library, image.name)
+		writeSizedPNG(t, path, image.width, image.height)
+		if err := os.Chtimes(path, now.Add(-image.age), now.Add(-image.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(library, "notes.jpg"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := updateImageMetadata(filepath.Join(library, "tile.png"), func(metadata *ImageMetadata) {
+		metadata.Tags = []string{"pattern"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveHistory([]HistoryEntry{
+		{Image: filepath.Join(library, "tile.png"), SetAt: now.Add(-3 * time.Hour)},
+		{Image: filepath.Join(library, "tower.png"), SetAt: now.Add(-2 * time.Hour)},
+		{Image: filepath.Join(library, "tile.png"), SetAt: now.Add(-time.Hour)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		opts      ListOptions
+		expImages []string
+		expErr    error
+	}{
+		{name: "Name", expImages: []string{"forest.png", "notes.jpg", "tile.png", "tower.png"}},
+		{name: "Reverse", opts: ListOptions{Reverse: true}, expImages: []string{"tower.png", "tile.png", "notes.jpg", "forest.png"}},
+		{name: "Resolution", opts: ListOptions{Sort: "resolution"}, expImages: []string{"forest.png", "tile.png", "tower.png", "notes.jpg"}},
+		{name: "LastUsed", opts: ListOptions{Sort: "last-used"}, expImages: []string{"tile.png", "tower.png", "forest.png", "notes.jpg"}},
+		{name: "UseCount", opts: ListOptions{Sort: "use-count", Extensions: []string{"PNG"}}, expImages: []string{"tile.png", "tower.png", "forest.png"}},
+		{name: "MinWidth", opts: ListOptions{MinWidth: 32}, expImages: []string{"forest.png", "tile.png"}},
+		{name: "Orientation", opts: ListOptions{Orientation: "portrait"}, expImages: []string{"tower.png"}},
+		{name: "Tag", opts: ListOptions{Tags: []string{"Pattern"}}, expImages: []string{"tile.png"}},
+		{name: "Since", opts: ListOptions{Since: "3d", Extensions: []string{".png"}}, expImages: []string{"forest.png", "tower.png"}},
+		{name: "InvalidSort", opts: ListOptions{Sort: "color"}, expErr: ErrInvalidListOption},
+		{name: "InvalidColumn", opts: ListOptions{Columns: []string{"color"}}, expErr: ErrInvalidListOption},
+		{name: "InvalidSince", opts: ListOptions{Since: "lately"}, expErr: ErrInvalidListOption},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			images, err := listImages(testCase.opts, now)
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+
+			var names []string
+			for _, image := range images {
+				names = append(names, image.Name)
+			}
+			if !slices.Equal(names, testCase.expImages) {
+				t.Errorf("Expected images %v, but got %v instead", testCase.expImages, names)
+			}
+		})
+	}
+}
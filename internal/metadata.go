@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ImageMetadata is what backdrop remembers about a single image.
+type ImageMetadata struct {
+	Fit Fit `json:"fit,omitempty"`
+	// Dark is the image shown instead of this one while the desktop is in dark mode.
+	Dark string `json:"dark,omitempty"`
+	// Effects are drawn over the image whenever it is applied, e.g. "blur=8,dim=0.3".
+	Effects string `json:"effects,omitempty"`
+	// Tags group images, profiles can limit the library to some of them.
+	Tags []string `json:"tags,omitempty"`
+	// SourceURL is where a downloaded image came from.
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+func (m ImageMetadata) isZero() bool {
+	return m.Fit.IsZero() && m.Dark == "" && m.Effects == "" && len(m.Tags) == 0 && m.SourceURL == ""
+}
+
+func getMetadataFile() (string, error) {
+	statePath, err := getStatePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(statePath, "metadata.json"), nil
+}
+
+// loadMetadata returns the metadata of every image keyed by absolute path.
+func loadMetadata() (map[string]ImageMetadata, error) {
+	metadataFile, err := getMetadataFile()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]ImageMetadata)
+	content, err := os.ReadFile(metadataFile)
+	if os.IsNotExist(err) {
+		return metadata, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file %s: %w", metadataFile, err)
+	}
+
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata file %s: %w", metadataFile, err)
+	}
+	return metadata, nil
+}
+
+func saveMetadata(metadata map[string]ImageMetadata) error {
+	metadataFile, err := getMetadataFile()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(metadataFile, content, 0644)
+}
+
+func getImageMetadata(image string) (ImageMetadata, error) {
+	metadata, err := loadMetadata()
+	if err != nil {
+		return ImageMetadata{}, err
+	}
+	return metadata[image], nil
+}
+
+// metadataMutex serializes metadata updates of one process, the file lock those of
+// other processes.
+var metadataMutex sync.Mutex
+
+// updateMetadata saves the metadata when update reports a change, holding the
+// metadata lock so concurrent updates are not lost.
+func updateMetadata(update func(metadata map[string]ImageMetadata) bool) error {
+	metadataFile, err := getMetadataFile()
+	if err != nil {
+		return err
+	}
+
+	metadataMutex.Lock()
+	defer metadataMutex.Unlock()
+
+	return withFileLock(metadataFile, func() error {
+		metadata, err := loadMetadata()
+		if err != nil {
+			return err
+		}
+
+		if !update(metadata) {
+			return nil
+		}
+		return saveMetadata(metadata)
+	})
+}
+
+// updateImageMetadata changes the metadata of one image, images left without any
+// metadata are dropped from the file.
+func updateImageMetadata(image string, update func(*ImageMetadata)) error {
+	return updateMetadata(func(metadata map[string]ImageMetadata) bool {
+		imageMetadata := metadata[image]
+		update(&imageMetadata)
+		if imageMetadata.isZero() {
+			delete(metadata, image)
+		} else {
+			metadata[image] = imageMetadata
+		}
+		return true
+	})
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestUpdateImageMetadataConcurrently(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	const images = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, images*2)
+	for i := 0; i < images; i++ {
+		image := fmt.Sprintf("/wallpapers/%d.png", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- updateImageMetadata(image, func(metadata *ImageMetadata) { metadata.Tags = []string{"calm"} })
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- updateImageMetadata(image, func(metadata *ImageMetadata) { metadata.Fit = Fit{Mode: "zoom"} })
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+	}
+
+	metadata, err := loadMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < images; i++ {
+		image := fmt.Sprintf("/wallpapers/%d.png", i)
+		if len(metadata[image].Tags) != 1 || metadata[image].Fit.Mode != "zoom" {
+			t.Errorf("Expected %s to keep its tag and fit, but got %+v instead", image, metadata[image])
+		}
+	}
+}
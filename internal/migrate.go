@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+// migrationNoticeName is left next to the config file once files were moved.
+const migrationNoticeName = "MIGRATION.txt"
+
+// layoutMove is a file or directory older versions kept somewhere else.
+type layoutMove struct {
+	from, to string
+	// link leaves a symlink at from, desktops and slideshows still point at images
+	// with their old path.
+	link bool
+}
+
+// legacyLayoutMoves lists where versions before the XDG layout kept their files and
+// where they belong now: the config file, the default images path and the state.
+func legacyLayoutMoves() ([]layoutMove, error) {
+	legacyConfig, err := os_Specifics.GetLinuxLegacyConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+	config, err := getConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+	legacyData, err := os_Specifics.GetLinuxLegacyDataPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os_Specifics.GetLinuxDataPath()
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	state, err := os_Specifics.GetLinuxStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	moves := []layoutMove{
+		{from: legacyConfig, to: config},
+		{from: legacyData, to: data, link: true},
+	}
+	for _, name := range []string{"history.json", "metadata.json", "snapshots"} {
+		moves = append(moves, layoutMove{
+			from: filepath.Join(configPath, "backdrop", name),
+			to:   filepath.Join(state, name),
+		})
+	}
+	return moves, nil
+}
+
+// MigrateLegacyLayout moves the files older versions kept in the home and config
+// directories to the XDG base directories. Files already in their new place are never
+// overwritten, so it does nothing once the legacy files are gone. What was moved is
+// printed to out and written to a notice next to the config file.
+func MigrateLegacyLayout(out io.Writer) error {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	moves, err := legacyLayoutMoves()
+	if err != nil {
+		return err
+	}
+
+	var moved []layoutMove
+	for _, move := range moves {
+		info, err := os.Lstat(move.from)
+		// A symlink is what an earlier migration left behind.
+		if err != nil || info.Mode()&fs.ModeSymlink != 0 || move.from == move.to {
+			continue
+		}
+		if _, err := os.Lstat(move.to); err == nil {
+			continue
+		}
+
+		if err := moveLegacyPath(move); err != nil {
+			return err
+		}
+		moved = append(moved, move)
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+
+	// Metadata, history and saved slideshows know images by their path, they would
+	// lose track of images in the moved images path.
+	for _, imagesMove := range moved {
+		if !imagesMove.link {
+			continue
+		}
+		for _, move := range moves {
+			if move.link {
+				continue
+			}
+			if err := rewriteLegacyPaths(move.to, imagesMove.from, imagesMove.to); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeMigrationNotice(out, moves[0].to, moved)
+}
+
+func moveLegacyPath(move layoutMove) error {
+	if err := os.MkdirAll(filepath.Dir(move.to), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", move.to, err)
+	}
+
+	if err := movePath(move.from, move.to); err != nil {
+		return err
+	}
+
+	if move.link {
+		if err := os.Symlink(move.to, move.from); err != nil {
+			return fmt.Errorf("failed to link %s to %s: %w", move.from, move.to, err)
+		}
+	}
+	return nil
+}
+
+// copyTree copies a file or a directory with everything in it, symlinks are copied as
+// symlinks.
+func copyTree(from, to string) error {
+	return filepath.WalkDir(from, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, relPath)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(from, to string, perm os.FileMode) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return err
+	}
+	return target.Close()
+}
+
+// rewriteLegacyPaths replaces the old images path with the new one in a file, or in
+// every file of a directory. Only whole path components are replaced, so a sibling
+// such as "wallpapers-old" is left alone.
+func rewriteLegacyPaths(path, from, to string) error {
+	pattern := regexp.MustCompile(regexp.QuoteMeta(from) + `([/"'\s]|$)`)
+	replacement := strings.ReplaceAll(to, "$", "$$") + "${1}"
+
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rewritten := pattern.ReplaceAll(content, []byte(replacement))
+		if string(rewritten) == string(content) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(file, rewritten, info.Mode().Perm())
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func writeMigrationNotice(out io.Writer, configFile string, moved []layoutMove) error {
+	var notice strings.Builder
+	fmt.Fprintf(&notice, "backdrop moved its files to the XDG base directories on %s:\n\n", time.Now().Format(time.DateOnly))
+	for _, move := range moved {
+		fmt.Fprintf(&notice, "  %s -> %s\n", move.from, move.to)
+	}
+	for _, move := range moved {
+		if move.link {
+			fmt.Fprintf(&notice, "\n%s now links to the new images path, it can be removed once no desktop or\nslideshow shows images from it.\n", move.from)
+		}
+	}
+
+	noticeFile := filepath.Join(filepath.Dir(configFile), migrationNoticeName)
+	if err := os.MkdirAll(filepath.Dir(noticeFile), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(noticeFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write migration notice %s: %w", noticeFile, err)
+	}
+	defer file.Close()
+	if _, err := io.WriteString(file, notice.String()+"\n"); err != nil {
+		return fmt.Errorf("failed to write migration notice %s: %w", noticeFile, err)
+	}
+
+	fmt.Fprintf(out, "%sThis notice is kept in %s\n", notice.String(), noticeFile)
+	return nil
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMigrateLegacyLayout(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the legacy layout only existed on Linux")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(env, "")
+	}
+
+	legacyImages := filepath.Join(home, ".config", "backdrop", "wallpapers")
+	image := filepath.Join(legacyImages, "lake.jpg")
+	legacyFiles := map[string]string{
+		filepath.Join(home, ".backdrop.yaml"): "wallpaperspath: " + legacyImages + "\nlibraryroots:\n    - " + legacyImages + "-old\n",
+		image:                                 "image",
+		filepath.Join(home, ".config", "backdrop", "metadata.json"):          `{"` + image + `":{"fit":{"mode":"zoom"}}}`,
+		filepath.Join(home, ".config", "backdrop", "snapshots", "work.json"): `{"outputs":{"":"` + image + `"}}`,
+		filepath.Join(home, ".config", "backdrop", "history.json"):           `[{"image":"/elsewhere/forest.jpg"}]`,
+	}
+	for path, content := range legacyFiles {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := MigrateLegacyLayout(&out); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	images := filepath.Join(home, ".local", "share", "backdrop", "wallpapers")
+	state := filepath.Join(home, ".local", "state", "backdrop")
+	expFiles := map[string]string{
+		filepath.Join(home, ".config", "backdrop", "config.yaml"): "wallpaperspath: " + images + "\nlibraryroots:\n    - " + legacyImages + "-old\n",
+		filepath.Join(images, "lake.jpg"):                         "image",
+		filepath.Join(state, "metadata.json"):                     `{"` + filepath.Join(images, "lake.jpg") + `":{"fit":{"mode":"zoom"}}}`,
+		filepath.Join(state, "snapshots", "work.json"):            `{"outputs":{"":"` + filepath.Join(images, "lake.jpg") + `"}}`,
+		filepath.Join(state, "history.json"):                      `[{"image":"/elsewhere/forest.jpg"}]`,
+	}
+	for path, expContent := range expFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("Expected '%s' to be moved, but got '%v' instead", path, err)
+			continue
+		}
+		if string(content) != expContent {
+			t.Errorf("Expected '%s' to contain '%s', but got '%s' instead", path, expContent, content)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".backdrop.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected the legacy config file to be gone, but got '%v'", err)
+	}
+	if link, err := os.Readlink(legacyImages); err != nil || link != images {
+		t.Errorf("Expected the legacy images path to link to '%s', but got '%s', '%v' instead", images, link, err)
+	}
+
+	noticeFile := filepath.Join(home, ".config", "backdrop", migrationNoticeName)
+	notice, err := os.ReadFile(noticeFile)
+	if err != nil {
+		t.Fatalf("Expected a migration notice, but got '%v' instead", err)
+	}
+	if !strings.Contains(string(notice), legacyImages+" -> "+images) || !strings.Contains(out.String(), noticeFile) {
+		t.Errorf("Expected the notice to list the moved images path, but got '%s' and '%s'", notice, out.String())
+	}
+
+	out.Reset()
+	if err := MigrateLegacyLayout(&out); err != nil {
+		t.Fatalf("Expected NO error migrating again, but got '%v' instead", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to migrate the second time, but got '%s'", out.String())
+	}
+}
@@ -0,0 +1,199 @@
+package os_Specifics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Background mirrors the GNOME "<background>" slideshow format.
+type Background struct {
+	XMLName     xml.Name               `xml:"background"`
+	StartTime   BackgroundStartTime    `xml:"starttime"`
+	Statics     []BackgroundStatic     `xml:"static"`
+	Transitions []BackgroundTransition `xml:"transition"`
+}
+
+type BackgroundStartTime struct {
+	Year   int `xml:"year"`
+	Month  int `xml:"month"`
+	Day    int `xml:"day"`
+	Hour   int `xml:"hour"`
+	Minute int `xml:"minute"`
+	Second int `xml:"second"`
+}
+
+type BackgroundStatic struct {
+	Duration float64 `xml:"duration"`
+	File     string  `xml:"file"`
+}
+
+type BackgroundTransition struct {
+	Duration float64 `xml:"duration"`
+	From     string  `xml:"from"`
+	To       string  `xml:"to"`
+}
+
+// Images returns the slideshow images in the order they are shown.
+func (b *Background) Images() []string {
+	images := make([]string, 0, len(b.Statics))
+	for _, static := range b.Statics {
+		images = append(images, strings.TrimSpace(static.File))
+	}
+	return images
+}
+
+// Duration returns the duration of the first slide, which is the value backdrop
+// writes for every slide.
+func (b *Background) Duration() int {
+	if len(b.Statics) == 0 {
+		return 0
+	}
+	return int(math.Round(b.Statics[0].Duration))
+}
+
+// WallpaperList mirrors the "gnome-background-properties" list format.
+type WallpaperList struct {
+	XMLName    xml.Name         `xml:"wallpapers"`
+	Wallpapers []WallpaperEntry `xml:"wallpaper"`
+}
+
+type WallpaperEntry struct {
+	Deleted          string `xml:"deleted,attr,omitempty"`
+	Name             string `xml:"name"`
+	TranslatableName string `xml:"_name"`
+	Filename         string `xml:"filename"`
+	FilenameDark     string `xml:"filename-dark"`
+	Options          string `xml:"options"`
+	PColor           string `xml:"pcolor"`
+	SColor           string `xml:"scolor"`
+	ShadeType        string `xml:"shade_type"`
+}
+
+// DisplayName returns the entry name, falling back to the translatable name and file.
+func (w WallpaperEntry) DisplayName() string {
+	switch {
+	case strings.TrimSpace(w.Name) != "":
+		return strings.TrimSpace(w.Name)
+	case strings.TrimSpace(w.TranslatableName) != "":
+		return strings.TrimSpace(w.TranslatableName)
+	default:
+		return filepath.Base(strings.TrimSpace(w.Filename))
+	}
+}
+
+// SlideShowInfo describes a slideshow registered in a background properties file.
+type SlideShowInfo struct {
+	Name           string
+	Filename       string
+	PropertiesFile string
+}
+
+func ParseBackgroundFile(path string) (*Background, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slideshow file %s: %w", path, err)
+	}
+
+	var background Background
+	if err := xml.Unmarshal(data, &background); err != nil {
+		return nil, fmt.Errorf("failed to parse slideshow file %s: %w", path, err)
+	}
+
+	return &background, nil
+}
+
+func ParseWallpaperListFile(path string) (*WallpaperList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background properties file %s: %w", path, err)
+	}
+
+	var list WallpaperList
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse background properties file %s: %w", path, err)
+	}
+
+	return &list, nil
+}
+
+// IsSlideShowFile reports whether the file is a GNOME "<background>" XML file.
+func IsSlideShowFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".xml")
+}
+
+// ListSlideShowsLinux returns every slideshow registered in the user and system
+// background properties directories. Unreadable or malformed files are skipped.
+func ListSlideShowsLinux() ([]SlideShowInfo, error) {
+	dirs, err := backgroundPropertiesDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var slideShows []SlideShowInfo
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !IsSlideShowFile(entry.Name()) {
+				continue
+			}
+
+			propertiesFile := filepath.Join(dir, entry.Name())
+			list, err := ParseWallpaperListFile(propertiesFile)
+			if err != nil {
+				continue
+			}
+
+			for _, wallpaper := range list.Wallpapers {
+				filename := strings.TrimSpace(wallpaper.Filename)
+				if wallpaper.Deleted == "true" || !IsSlideShowFile(filename) || seen[filename] {
+					continue
+				}
+				seen[filename] = true
+
+				slideShows = append(slideShows, SlideShowInfo{
+					Name:           wallpaper.DisplayName(),
+					Filename:       filename,
+					PropertiesFile: propertiesFile,
+				})
+			}
+		}
+	}
+
+	return slideShows, nil
+}
+
+func backgroundPropertiesDirs() ([]string, error) {
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user home directory: %w", err)
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(homePath, ".local", "share")
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+
+	var dirs []string
+	for _, base := range append([]string{dataHome}, filepath.SplitList(dataDirs)...) {
+		dirs = append(dirs,
+			filepath.Join(base, "gnome-background-properties"),
+			filepath.Join(base, "mate-background-properties"),
+		)
+	}
+
+	return dirs, nil
+}
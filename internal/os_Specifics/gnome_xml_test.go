@@ -0,0 +1,115 @@
+package os_Specifics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+const (
+	testSlideShowSettings   = "../../test/testData/backdrop_settings.xml"
+	testSlideShowProperties = "../../test/testData/backdrop_slideshow.xml"
+)
+
+func TestBackgroundRoundTrip(t *testing.T) {
+	background, err := ParseBackgroundFile(testSlideShowSettings)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	expImages := []string{
+		"../test/testData/images/testImage.png",
+		"../test/testData/images/testImage2.png",
+		"../test/testData/images/testImage3.png",
+	}
+	if !reflect.DeepEqual(background.Images(), expImages) {
+		t.Fatalf("Expected images '%v', but got '%v' instead", expImages, background.Images())
+	}
+	if background.Duration() != 10 {
+		t.Fatalf("Expected duration '%v', but got '%v' instead", 10, background.Duration())
+	}
+
+	configFile := filepath.Join(t.TempDir(), "backdrop_settings.xml")
+	if _, err := createSlideShowConfigFile(background.Images(), configFile, "", background.Duration()); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	roundTrip, err := ParseBackgroundFile(configFile)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	if !reflect.DeepEqual(roundTrip, background) {
+		t.Errorf("Expected slideshow:\n %+v \nGot slideshow:\n %+v", background, roundTrip)
+	}
+}
+
+func TestListSlideShows(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_DATA_DIRS", t.TempDir())
+
+	propertiesDir := filepath.Join(dataHome, "gnome-background-properties")
+	if err := os.MkdirAll(propertiesDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(testSlideShowProperties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	propertiesFile := filepath.Join(propertiesDir, "backdrop_slideshow.xml")
+	if err := os.WriteFile(propertiesFile, content, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	slideShows, err := ListSlideShowsLinux()
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	expSlideShows := []SlideShowInfo{{
+		Name:           "Backdrop Slideshow",
+		Filename:       "/home/mike/.local/share/backgrounds/backdrop_settings/backdrop_settings.xml",
+		PropertiesFile: propertiesFile,
+	}}
+	if !reflect.DeepEqual(slideShows, expSlideShows) {
+		t.Errorf("Expected slideshows '%+v', but got '%+v' instead", expSlideShows, slideShows)
+	}
+}
+
+func TestSlideShowEscapesPaths(t *testing.T) {
+	dir := t.TempDir()
+	images := []string{"/photos/Tom & Jerry/<1>.png", `/photos/it's "here".jpg`}
+
+	configFile := filepath.Join(dir, "R&D <slides>.xml")
+	if _, err := createSlideShowConfigFile(images, configFile, "", 60); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	background, err := ParseBackgroundFile(configFile)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if !reflect.DeepEqual(background.Images(), images) {
+		t.Errorf("Expected images '%v', but got '%v' instead", images, background.Images())
+	}
+	for _, transition := range background.Transitions {
+		if !slices.Contains(images, transition.From) || !slices.Contains(images, transition.To) {
+			t.Errorf("Expected transitions between the images, but got '%+v'", transition)
+		}
+	}
+
+	propertiesFile := filepath.Join(dir, "backdrop_slideshow.xml")
+	if err := createSlideShowFile(propertiesFile, configFile, "Tom & Jerry", PictureOptions{}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	list, err := ParseWallpaperListFile(propertiesFile)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if len(list.Wallpapers) != 1 || list.Wallpapers[0].Filename != configFile || list.Wallpapers[0].Name != "Tom & Jerry" {
+		t.Errorf("Expected the slideshow entry for '%s', but got '%+v' instead", configFile, list.Wallpapers)
+	}
+}
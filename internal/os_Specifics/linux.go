@@ -2,12 +2,67 @@ package os_Specifics
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// xdgPath returns the XDG base directory in env, or fallback under the home
+// directory when it is unset or not absolute, as the specification asks.
+func xdgPath(env string, fallback ...string) (string, error) {
+	if path := os.Getenv(env); filepath.IsAbs(path) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home}, fallback...)...), nil
+}
+
 func GetLinuxConfigFilePath() (string, error) {
+	configHome, err := xdgPath("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configHome, "backdrop")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// GetLinuxDataPath returns the default images path.
+func GetLinuxDataPath() (string, error) {
+	dataHome, err := xdgPath("XDG_DATA_HOME", ".local", "share")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataHome, "backdrop", "wallpapers"), nil
+}
+
+// GetLinuxStatePath returns the directory for history, metadata and snapshots.
+func GetLinuxStatePath() (string, error) {
+	stateHome, err := xdgPath("XDG_STATE_HOME", ".local", "state")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateHome, "backdrop"), nil
+}
+
+// GetLinuxTrashPath returns the home trash of the freedesktop.org Trash specification.
+func GetLinuxTrashPath() (string, error) {
+	dataHome, err := xdgPath("XDG_DATA_HOME", ".local", "share")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// GetLinuxLegacyConfigFilePath returns where versions before the XDG layout kept
+// the config file.
+func GetLinuxLegacyConfigFilePath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -15,7 +70,8 @@ func GetLinuxConfigFilePath() (string, error) {
 	return filepath.Join(home, ".backdrop.yaml"), nil
 }
 
-func GetLinuxConfigPath() (string, error) {
+// GetLinuxLegacyDataPath returns where versions before the XDG layout kept images.
+func GetLinuxLegacyDataPath() (string, error) {
 	homePath, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -26,13 +82,19 @@ func GetLinuxConfigPath() (string, error) {
 }
 
 func ConfigureSlideShowLinux(images []string, wallpapersPath string, duration int) (string, error) {
-	slideShowFile, slideShowConfigFile, err := createSlideShowDirectory()
+	return ConfigureNamedSlideShowLinux("", images, wallpapersPath, duration, PictureOptions{})
+}
+
+// ConfigureNamedSlideShowLinux writes a slideshow and its background properties entry.
+// An empty name configures the default "Backdrop Slideshow".
+func ConfigureNamedSlideShowLinux(name string, images []string, wallpapersPath string, duration int, options PictureOptions) (string, error) {
+	slideShowFile, slideShowConfigFile, err := createSlideShowDirectory(name)
 
 	if err != nil {
 		return "", err
 	}
 
-	if err := createSlideShowFile(slideShowFile, slideShowConfigFile); err != nil {
+	if err := createSlideShowFile(slideShowFile, slideShowConfigFile, slideShowDisplayName(name), options); err != nil {
 		return "", err
 	}
 
@@ -45,17 +107,62 @@ func ConfigureSlideShowLinux(images []string, wallpapersPath string, duration in
 	return slideShowConfigFile, nil
 }
 
-func createSlideShowDirectory() (string, string, error) {
+// GetLinuxSlideShowConfigFile returns the "<background>" file backdrop writes slideshows to.
+func GetLinuxSlideShowConfigFile() (string, error) {
+	_, slideShowConfigFile, err := createSlideShowDirectory("")
+	return slideShowConfigFile, err
+}
+
+// GetLinuxSlideShowFiles returns the background properties entry and the "<background>"
+// file of the default slideshow.
+func GetLinuxSlideShowFiles() (string, string, error) {
+	return createSlideShowDirectory("")
+}
+
+// ListLinuxSlideShowConfigFiles returns every "<background>" file backdrop wrote, the
+// default slideshow and the named ones.
+func ListLinuxSlideShowConfigFiles() ([]string, error) {
+	_, slideShowConfigFile, err := createSlideShowDirectory("")
+	if err != nil {
+		return nil, err
+	}
+	return filepath.Glob(filepath.Join(filepath.Dir(slideShowConfigFile), "*.xml"))
+}
+
+// RewriteLinuxSlideShowConfigFile replaces the images of an existing "<background>"
+// file, its background properties entry stays as it is.
+func RewriteLinuxSlideShowConfigFile(configFile string, images []string, duration int) error {
+	_, err := createSlideShowConfigFile(images, configFile, "", duration)
+	return err
+}
+
+// RemoveNamedSlideShowLinux removes the slideshow and its background properties entry.
+func RemoveNamedSlideShowLinux(name string) error {
+	slideShowFile, slideShowConfigFile, err := createSlideShowDirectory(name)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []string{slideShowFile, slideShowConfigFile} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove slideshow file %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+func createSlideShowDirectory(name string) (string, string, error) {
 	homePath, err := os.UserHomeDir()
 
 	if err != nil {
 		return "", "", fmt.Errorf("unable to find user home directory: %w", err)
 	}
 
-	return configureLinuxSlideShowPaths(homePath)
+	return configureLinuxSlideShowPaths(homePath, name)
 }
 
-func configureLinuxSlideShowPaths(homePath string) (string, string, error) {
+func configureLinuxSlideShowPaths(homePath, name string) (string, string, error) {
 	paths := map[string]string{
 		"slideShowPath":       filepath.Join(homePath, ".local", "share", "gnome-background-properties"),
 		"slideShowConfigPath": filepath.Join(homePath, ".local", "share", "backgrounds", "backdrop_settings"),
@@ -70,23 +177,32 @@ func configureLinuxSlideShowPaths(homePath string) (string, string, error) {
 	slideShowFile := filepath.Join(paths["slideShowPath"], "backdrop_slideshow.xml")
 	slideShowConfigFile := filepath.Join(paths["slideShowConfigPath"], "backdrop_settings.xml")
 
+	// Named files get a prefix so no name can collide with the default slideshow.
+	if name != "" {
+		fileName := SlideShowFileName(name)
+		slideShowFile = filepath.Join(paths["slideShowPath"], fmt.Sprintf("backdrop_slideshow_%s.xml", fileName))
+		slideShowConfigFile = filepath.Join(paths["slideShowConfigPath"], fmt.Sprintf("slideshow_%s.xml", fileName))
+	}
+
 	return slideShowFile, slideShowConfigFile, nil
 }
 
-func createSlideShowFile(outFile, configFile string) error {
+func createSlideShowFile(outFile, configFile, displayName string, options PictureOptions) error {
+	options = options.WithDefaults()
 	content := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 	<!DOCTYPE wallpapers SYSTEM "gnome-wp-list.dtd">
 	<wallpapers>
 	  <wallpaper>
-		    <name>Backdrop Slideshow</name>
+		    <name>%v</name>
 				    <filename>%v</filename>
-						    <options>zoom</options>
-								    <pcolor>#2c001e</pcolor>
-										    <scolor>#2c001e</scolor>
-												    <shade_type>solid</shade_type>
+						    <options>%v</options>
+								    <pcolor>%v</pcolor>
+										    <scolor>%v</scolor>
+												    <shade_type>%v</shade_type>
 														  </wallpaper>
 															</wallpapers>
-															`, configFile)
+															`, html.EscapeString(displayName), html.EscapeString(configFile),
+		html.EscapeString(options.Mode), html.EscapeString(options.PrimaryColor), html.EscapeString(options.SecondaryColor), html.EscapeString(options.Shading))
 
 	file, err := os.Create(outFile)
 
@@ -121,23 +237,23 @@ func createSlideShowConfigFile(images []string, configFile, wallpapersPath strin
 `)
 
 	writeStatic := func(path string) {
-		fmt.Fprintf(&content, "  <static>\n    <duration>%d.0</duration>\n    <file>%s</file>\n  </static>\n", duration, path)
+		fmt.Fprintf(&content, "  <static>\n    <duration>%d.0</duration>\n    <file>%s</file>\n  </static>\n", duration, html.EscapeString(path))
 	}
 
 	writeTransition := func(from, to string) {
-		fmt.Fprintf(&content, "  <transition>\n    <duration>0.5</duration>\n    <from>%s</from>\n    <to>%s</to>\n  </transition>\n", from, to)
+		fmt.Fprintf(&content, "  <transition>\n    <duration>0.5</duration>\n    <from>%s</from>\n    <to>%s</to>\n  </transition>\n", html.EscapeString(from), html.EscapeString(to))
 	}
 
 	for i := 0; i < len(images)-1; i++ {
-		current := filepath.Join(wallpapersPath, images[i])
-		next := filepath.Join(wallpapersPath, images[i+1])
+		current := resolveImagePath(wallpapersPath, images[i])
+		next := resolveImagePath(wallpapersPath, images[i+1])
 		writeStatic(current)
 		writeTransition(current, next)
 	}
 
 	// Wrap around to the start
-	start := filepath.Join(wallpapersPath, images[0])
-	end := filepath.Join(wallpapersPath, images[len(images)-1])
+	start := resolveImagePath(wallpapersPath, images[0])
+	end := resolveImagePath(wallpapersPath, images[len(images)-1])
 	writeStatic(end)
 	writeTransition(end, start)
 
@@ -0,0 +1,106 @@
+package os_Specifics
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PictureOptions describes how a background image is scaled and the colors shown
+// around it. Values follow GNOME's picture-options, primary-color, secondary-color
+// and color-shading-type keys, other desktops map them to their closest equivalent.
+// Empty fields are left as they are.
+type PictureOptions struct {
+	Mode           string `mapstructure:"mode" yaml:"mode,omitempty" json:"mode,omitempty"`
+	PrimaryColor   string `mapstructure:"primary_color" yaml:"primary_color,omitempty" json:"primary_color,omitempty"`
+	SecondaryColor string `mapstructure:"secondary_color" yaml:"secondary_color,omitempty" json:"secondary_color,omitempty"`
+	Shading        string `mapstructure:"shading" yaml:"shading,omitempty" json:"shading,omitempty"`
+}
+
+var (
+	PictureModes = []string{"none", "wallpaper", "centered", "scaled", "stretched", "zoom", "spanned"}
+	ShadingTypes = []string{"solid", "horizontal", "vertical"}
+)
+
+// DefaultPictureOptions is how backdrop showed every background before fit modes
+// could be chosen.
+var DefaultPictureOptions = PictureOptions{
+	Mode:           "zoom",
+	PrimaryColor:   "#2c001e",
+	SecondaryColor: "#2c001e",
+	Shading:        "solid",
+}
+
+func (o PictureOptions) IsZero() bool {
+	return o == PictureOptions{}
+}
+
+// WithDefaults fills the empty fields from DefaultPictureOptions.
+func (o PictureOptions) WithDefaults() PictureOptions {
+	if o.Mode == "" {
+		o.Mode = DefaultPictureOptions.Mode
+	}
+	if o.PrimaryColor == "" {
+		o.PrimaryColor = DefaultPictureOptions.PrimaryColor
+	}
+	if o.SecondaryColor == "" {
+		o.SecondaryColor = o.PrimaryColor
+	}
+	if o.Shading == "" {
+		o.Shading = DefaultPictureOptions.Shading
+	}
+	return o
+}
+
+// WindowsStyle returns the WallpaperStyle and TileWallpaper registry values of the mode.
+func (o PictureOptions) WindowsStyle() (int, int) {
+	switch o.Mode {
+	case "wallpaper":
+		return 0, 1
+	case "none", "centered":
+		return 0, 0
+	case "stretched":
+		return 2, 0
+	case "scaled":
+		return 6, 0
+	case "spanned":
+		return 22, 0
+	default:
+		return 10, 0
+	}
+}
+
+// PictureModeFromWindowsStyle is the inverse of WindowsStyle.
+func PictureModeFromWindowsStyle(style, tile int) string {
+	if tile == 1 {
+		return "wallpaper"
+	}
+	switch style {
+	case 0:
+		return "centered"
+	case 2:
+		return "stretched"
+	case 6:
+		return "scaled"
+	case 22:
+		return "spanned"
+	default:
+		return "zoom"
+	}
+}
+
+// ParseHexColor splits "#rrggbb" or "#rgb" into its components.
+func ParseHexColor(color string) (r, g, b uint8, err error) {
+	hex := color
+	if len(hex) > 0 && hex[0] == '#' {
+		hex = hex[1:]
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	value, parseErr := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || parseErr != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color '%s', expected #rrggbb", color)
+	}
+	return uint8(value >> 16), uint8(value >> 8), uint8(value), nil
+}
@@ -0,0 +1,37 @@
+package os_Specifics
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+const defaultSlideShowName = "Backdrop Slideshow"
+
+// SlideShowFileName turns a slideshow name into a string safe to use in file names.
+func SlideShowFileName(name string) string {
+	fileName := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+
+	return strings.Trim(fileName, "_")
+}
+
+func slideShowDisplayName(name string) string {
+	if name == "" {
+		return defaultSlideShowName
+	}
+	return name
+}
+
+// resolveImagePath joins relative image names with the wallpapers path and keeps
+// absolute paths, such as images imported from an existing slideshow, untouched.
+func resolveImagePath(wallpapersPath, image string) string {
+	if filepath.IsAbs(image) {
+		return image
+	}
+	return filepath.Join(wallpapersPath, image)
+}
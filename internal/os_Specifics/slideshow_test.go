@@ -0,0 +1,49 @@
+package os_Specifics
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSlideShowFileName(t *testing.T) {
+	testCases := map[string]string{
+		"Work":              "work",
+		"  Late Night  ":    "late_night",
+		"Côte d'Azur":       "côte_d_azur",
+		"2024-summer":       "2024-summer",
+		"../../etc/passwd":  "etc_passwd",
+		"backdrop_settings": "backdrop_settings",
+		"!!!":               "",
+	}
+
+	for name, exp := range testCases {
+		if fileName := SlideShowFileName(name); fileName != exp {
+			t.Errorf("Expected '%s' to become '%s', but got '%s' instead", name, exp, fileName)
+		}
+	}
+}
+
+func TestConfigureLinuxSlideShowPaths(t *testing.T) {
+	home := t.TempDir()
+
+	defaultFile, defaultConfigFile, err := configureLinuxSlideShowPaths(home, "")
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if filepath.Base(defaultFile) != "backdrop_slideshow.xml" || filepath.Base(defaultConfigFile) != "backdrop_settings.xml" {
+		t.Errorf("Expected the default slideshow files, but got '%s' and '%s' instead", defaultFile, defaultConfigFile)
+	}
+
+	for _, name := range []string{"Work", "backdrop_settings", "Backdrop Settings", "slideshow"} {
+		file, configFile, err := configureLinuxSlideShowPaths(home, name)
+		if err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+		if file == defaultFile || configFile == defaultConfigFile {
+			t.Errorf("Expected slideshow '%s' not to use the default slideshow files, but got '%s' and '%s'", name, file, configFile)
+		}
+		if filepath.Dir(configFile) != filepath.Dir(defaultConfigFile) {
+			t.Errorf("Expected slideshow '%s' next to the default slideshow, but got '%s' instead", name, configFile)
+		}
+	}
+}
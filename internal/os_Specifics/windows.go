@@ -25,7 +25,13 @@ func GetWindowsConfigPath() string {
 }
 
 func ConfigureSlideShowWindows(images []string, wallpapersPath string, duration int) (string, error) {
-	slideShowDir := filepath.Join(os.Getenv("APPDATA"), "BackdropSlideShow")
+	return ConfigureNamedSlideShowWindows("", images, wallpapersPath, duration, PictureOptions{})
+}
+
+// ConfigureNamedSlideShowWindows copies the images to the slideshow folder and applies
+// its theme. An empty name configures the default "Backdrop Slideshow".
+func ConfigureNamedSlideShowWindows(name string, images []string, wallpapersPath string, duration int, options PictureOptions) (string, error) {
+	slideShowDir := windowsSlideShowDir(name)
 
 	if err := os.MkdirAll(slideShowDir, 0777); err != nil {
 		return "", fmt.Errorf("failed to create slideshow directory: %w", err)
@@ -44,7 +50,7 @@ func ConfigureSlideShowWindows(images []string, wallpapersPath string, duration
 	}
 
 	for _, img := range images {
-		src := filepath.Join(wallpapersPath, img)
+		src := resolveImagePath(wallpapersPath, img)
 		dst := filepath.Join(slideShowDir, filepath.Base(img))
 
 		data, _ := os.ReadFile(src)
@@ -54,7 +60,7 @@ func ConfigureSlideShowWindows(images []string, wallpapersPath string, duration
 		}
 	}
 
-	if err := setWindowsSlideShow(slideShowDir, duration); err != nil {
+	if err := setWindowsSlideShow(slideShowDir, duration, options); err != nil {
 		return "", err
 	}
 
@@ -63,7 +69,7 @@ func ConfigureSlideShowWindows(images []string, wallpapersPath string, duration
 	}
 
 	firstImagePath := filepath.Join(slideShowDir, filepath.Base(images[0]))
-	themeFile, err := createWindowsThemeFile(firstImagePath, slideShowDir, duration)
+	themeFile, err := createWindowsThemeFile(name, firstImagePath, slideShowDir, duration, options)
 	if err != nil {
 		return "", fmt.Errorf("failed to create theme file: %w", err)
 	}
@@ -76,7 +82,40 @@ func ConfigureSlideShowWindows(images []string, wallpapersPath string, duration
 	return slideShowDir, nil
 }
 
-func setWindowsSlideShow(folder string, duration int) error {
+// RemoveNamedSlideShowWindows removes the slideshow folder and its theme file.
+func RemoveNamedSlideShowWindows(name string) error {
+	if err := os.RemoveAll(windowsSlideShowDir(name)); err != nil {
+		return fmt.Errorf("failed to remove slideshow directory: %w", err)
+	}
+
+	themeFile := filepath.Join(windowsThemesDir(), windowsThemeFileName(name))
+	if err := os.Remove(themeFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove theme file: %w", err)
+	}
+
+	return nil
+}
+
+func windowsSlideShowDir(name string) string {
+	if name == "" {
+		return filepath.Join(os.Getenv("APPDATA"), "BackdropSlideShow")
+	}
+	return filepath.Join(os.Getenv("APPDATA"), "BackdropSlideShows", SlideShowFileName(name))
+}
+
+func windowsThemesDir() string {
+	return filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Themes")
+}
+
+func windowsThemeFileName(name string) string {
+	if name == "" {
+		return "backdrop.theme"
+	}
+	return fmt.Sprintf("backdrop_%s.theme", SlideShowFileName(name))
+}
+
+func setWindowsSlideShow(folder string, duration int, options PictureOptions) error {
+	style, tile := options.WithDefaults().WindowsStyle()
 	cmd := exec.Command("powershell", "-Command", fmt.Sprintf(`
 	$RegPath = "HKCU:\Control Panel\Personalization\Desktop Slideshow"
 	Set-ItemProperty -Path $RegPath -Name Interval -Value %d
@@ -88,15 +127,15 @@ func setWindowsSlideShow(folder string, duration int) error {
 	
 	$WallpaperPath = "HKCU:\Control Panel\Desktop"
 	Set-ItemProperty -Path $WallpaperPath -Name Wallpaper -Value ""
-	Set-ItemProperty -Path $WallpaperPath -Name WallpaperStyle -Value 10
-	Set-ItemProperty -Path $WallpaperPath -Name TileWallpaper -Value 0
+	Set-ItemProperty -Path $WallpaperPath -Name WallpaperStyle -Value %d
+	Set-ItemProperty -Path $WallpaperPath -Name TileWallpaper -Value %d
 	
 	# Critical: Remove corrupted or cached wallpaper to avoid black background
 	$transcoded = "$env:APPDATA\Microsoft\Windows\Themes\TranscodedWallpaper"
 	if (Test-Path $transcoded) { Remove-Item $transcoded -Force -ErrorAction SilentlyContinue }
 	
 	RUNDLL32.EXE user32.dll, UpdatePerUserSystemParameters
-	`, duration, folder))
+	`, duration, folder, style, tile))
 
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to set registry slideshow values: %v\n%s", err, output)
@@ -105,22 +144,24 @@ func setWindowsSlideShow(folder string, duration int) error {
 	return nil
 }
 
-func createWindowsThemeFile(firstImagePath, slideshowDir string, duration int) (string, error) {
-	themesDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "Windows", "Themes")
+func createWindowsThemeFile(name, firstImagePath, slideshowDir string, duration int, options PictureOptions) (string, error) {
+	style, tile := options.WithDefaults().WindowsStyle()
+
+	themesDir := windowsThemesDir()
 	if err := os.MkdirAll(themesDir, 0777); err != nil {
 		return "", fmt.Errorf("failed to create themes directory: %w", err)
 	}
 
-	themeFilePath := filepath.Join(themesDir, "backdrop.theme")
+	themeFilePath := filepath.Join(themesDir, windowsThemeFileName(name))
 
 	content := fmt.Sprintf(`[Theme]
-	DisplayName=Backdrop Slideshow
+	DisplayName=%s
 
 	[Control Panel\Desktop]
 	wallpaper=%s
-	TileWallpaper=0
-	WallpaperStyle=10
-	PicturePosition=10
+	TileWallpaper=%d
+	WallpaperStyle=%d
+	PicturePosition=%d
 	SlideshowEnabled=1
 	MultimonBackgrounds=1
 
@@ -141,7 +182,7 @@ func createWindowsThemeFile(firstImagePath, slideshowDir string, duration int) (
 
 	[Sounds]
 	SchemeName=@mmres.dll,-800
-	`, firstImagePath, slideshowDir, duration)
+	`, slideShowDisplayName(name), firstImagePath, tile, style, style, slideshowDir, duration)
 
 	if err := os.WriteFile(themeFilePath, []byte(content), 0666); err != nil {
 		return "", fmt.Errorf("failed to write theme file: %w", err)
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormats are the values of --output. Structured formats share one schema, the
+// JSON field names, so scripts can switch between them.
+var OutputFormats = []string{"text", "json", "yaml"}
+
+// outputFormat is the format chosen with --output.
+var outputFormat = "text"
+
+func SetOutputFormat(format string) error {
+	if !slices.Contains(OutputFormats, format) {
+		return fmt.Errorf("%w : '%s', expected one of %v", ErrInvalidOutputFormat, format, OutputFormats)
+	}
+	outputFormat = format
+	return nil
+}
+
+// isStructuredOutput reports whether results are written as data instead of text.
+func isStructuredOutput() bool {
+	return outputFormat != "text"
+}
+
+// writeStructured writes the value in the chosen structured format.
+func writeStructured(out io.Writer, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if outputFormat == "json" {
+		_, err = fmt.Fprintf(out, "%s\n", content)
+		return err
+	}
+
+	// JSON is YAML, reading it back as a node keeps the field names and their order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return err
+	}
+	clearNodeStyle(&node)
+
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// clearNodeStyle drops the flow style JSON is read with, so YAML is written in blocks.
+func clearNodeStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode {
+		node.Style = 0
+	} else if node.Style == yaml.DoubleQuotedStyle {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		clearNodeStyle(child)
+	}
+}
+
+// ErrorOutput is how errors are written in structured formats, Code names the sentinel
+// error from errors.go.
+type ErrorOutput struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// WriteError writes an error of a command, as text like cobra does or as an error
+// object.
+func WriteError(out io.Writer, err error) {
+	if !isStructuredOutput() {
+		fmt.Fprintln(out, "Error:", err)
+		return
+	}
+
+	var output ErrorOutput
+	output.Error.Code = ErrorCode(err)
+	output.Error.Message = err.Error()
+	if err := writeStructured(out, output); err != nil {
+		fmt.Fprintln(out, "Error:", err)
+	}
+}
+
+// ErrorCode returns the stable code of the sentinel error the error wraps, or "error"
+// for errors without one.
+func ErrorCode(err error) string {
+	for _, errorCode := range errorCodes {
+		if errors.Is(err, errorCode.err) {
+			return errorCode.code
+		}
+	}
+	return "error"
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorCodes(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sentinels []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		if spec, ok := node.(*ast.ValueSpec); ok {
+			for _, name := range spec.Names {
+				if strings.HasPrefix(name.Name, "Err") {
+					sentinels = append(sentinels, name.Name)
+				}
+			}
+		}
+		return true
+	})
+
+	if len(sentinels) != len(errorCodes) {
+		t.Errorf("Expected a code for each of the %d sentinel errors, but got %d codes", len(sentinels), len(errorCodes))
+	}
+	seen := make(map[string]bool)
+	for _, errorCode := range errorCodes {
+		if seen[errorCode.code] {
+			t.Errorf("Expected unique error codes, but '%s' is used twice", errorCode.code)
+		}
+		seen[errorCode.code] = true
+	}
+
+	wrapped := fmt.Errorf("%w : %s", ErrImageNotFound, "lake.jpg")
+	if code := ErrorCode(wrapped); code != "image_not_found" {
+		t.Errorf("Expected code 'image_not_found', but got '%s' instead", code)
+	}
+	if code := ErrorCode(errors.New("other")); code != "error" {
+		t.Errorf("Expected code 'error', but got '%s' instead", code)
+	}
+}
+
+func TestWriteStructured(t *testing.T) {
+	defer SetOutputFormat("text")
+
+	history := []HistoryEntry{{Image: "/images/lake.jpg", SetAt: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), Source: "fuzzy"}}
+	testCases := []struct {
+		format string
+		exp    string
+	}{
+		{format: "json", exp: "[\n  {\n    \"image\": \"/images/lake.jpg\",\n    \"set_at\": \"2024-01-01T08:00:00Z\",\n    \"source\": \"fuzzy\"\n  }\n]\n"},
+		{format: "yaml", exp: "- image: /images/lake.jpg\n  set_at: \"2024-01-01T08:00:00Z\"\n  source: fuzzy\n"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.format, func(t *testing.T) {
+			if err := SetOutputFormat(testCase.format); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if err := writeStructured(&out, history); err != nil {
+				t.Fatalf("Expected NO error, but got '%v' instead", err)
+			}
+			if out.String() != testCase.exp {
+				t.Errorf("Expected:\n%s\nbut got:\n%s", testCase.exp, out.String())
+			}
+		})
+	}
+
+	if err := SetOutputFormat("xml"); !errors.Is(err, ErrInvalidOutputFormat) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidOutputFormat, err)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	defer SetOutputFormat("text")
+
+	err := fmt.Errorf("%w : %s", ErrProfileNotFound, "home")
+	var out bytes.Buffer
+	WriteError(&out, err)
+	if out.String() != "Error: No profile found with that name : home\n" {
+		t.Errorf("Expected the text error, but got '%s' instead", out.String())
+	}
+
+	SetOutputFormat("json")
+	out.Reset()
+	WriteError(&out, err)
+	exp := "{\n  \"error\": {\n    \"code\": \"profile_not_found\",\n    \"message\": \"No profile found with that name : home\"\n  }\n}\n"
+	if out.String() != exp {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", exp, out.String())
+	}
+}
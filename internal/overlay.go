@@ -0,0 +1,454 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/spf13/viper"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	overlayConfigKey = "Overlay"
+	overlayQuality   = 92
+	// overlayBaseHeight is the screen height sizes are given for, text is scaled
+	// with the image so it looks the same on every display.
+	overlayBaseHeight = 1080
+)
+
+var (
+	OverlayKinds   = []string{"hostname", "quote", "calendar", "text"}
+	OverlayCorners = []string{"top-left", "top-right", "bottom-left", "bottom-right", "center"}
+	OverlayFonts   = []string{"regular", "bold", "mono"}
+
+	// The Go fonts are compiled in, so overlays work without any fonts installed.
+	overlayFontFiles = map[string][]byte{
+		"regular": goregular.TTF,
+		"bold":    gobold.TTF,
+		"mono":    gomono.TTF,
+	}
+
+	getHostname = os.Hostname
+	overlayNow  = time.Now
+)
+
+// OverlayOptions describe text drawn onto every wallpaper before it is applied. Text
+// is a template for the "text" kind and Quotes the file quotes are picked from for
+// the "quote" kind. Size is in pixels on a 1080 pixel tall screen.
+type OverlayOptions struct {
+	Kind   string  `mapstructure:"kind" yaml:"kind,omitempty" json:"kind,omitempty"`
+	Text   string  `mapstructure:"text" yaml:"text,omitempty" json:"text,omitempty"`
+	Quotes string  `mapstructure:"quotes" yaml:"quotes,omitempty" json:"quotes,omitempty"`
+	Corner string  `mapstructure:"corner" yaml:"corner,omitempty" json:"corner,omitempty"`
+	Font   string  `mapstructure:"font" yaml:"font,omitempty" json:"font,omitempty"`
+	Size   float64 `mapstructure:"size" yaml:"size,omitempty" json:"size,omitempty"`
+	Color  string  `mapstructure:"color" yaml:"color,omitempty" json:"color,omitempty"`
+}
+
+func (o OverlayOptions) Enabled() bool {
+	return o.Kind != ""
+}
+
+func (o OverlayOptions) validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	if !slices.Contains(OverlayKinds, o.Kind) {
+		return fmt.Errorf("%w : unknown kind '%s', expected one of %v", ErrInvalidOverlay, o.Kind, OverlayKinds)
+	}
+	if !slices.Contains(OverlayCorners, o.Corner) {
+		return fmt.Errorf("%w : unknown corner '%s', expected one of %v", ErrInvalidOverlay, o.Corner, OverlayCorners)
+	}
+	if _, ok := overlayFontFiles[o.Font]; !ok {
+		return fmt.Errorf("%w : unknown font '%s', expected one of %v", ErrInvalidOverlay, o.Font, OverlayFonts)
+	}
+	if o.Size < 4 || o.Size > 400 {
+		return fmt.Errorf("%w : size must be between 4 and 400 pixels", ErrInvalidOverlay)
+	}
+	if _, _, _, err := os_Specifics.ParseHexColor(o.Color); err != nil {
+		return fmt.Errorf("%w : %v", ErrInvalidOverlay, err)
+	}
+	if o.Kind == "quote" && o.Quotes == "" {
+		return fmt.Errorf("%w : quotes need a file to pick them from", ErrInvalidOverlay)
+	}
+	if o.Kind == "text" {
+		if _, err := template.New("overlay").Parse(o.Text); err != nil {
+			return fmt.Errorf("%w : %v", ErrInvalidOverlay, err)
+		}
+	}
+	return nil
+}
+
+// withDefaults fills in what was left out, colors are accepted without "#" like
+// the generate command does.
+func (o OverlayOptions) withDefaults() OverlayOptions {
+	if o.Corner == "" {
+		o.Corner = "bottom-right"
+	}
+	if o.Font == "" {
+		o.Font = "regular"
+	}
+	if o.Size == 0 {
+		o.Size = 24
+	}
+	if o.Color == "" {
+		o.Color = "#ffffff"
+	}
+	o.Color = "#" + strings.TrimPrefix(o.Color, "#")
+	return o
+}
+
+// overlayData is what text templates can use, e.g. "{{.Hostname}} {{.Now.Format "15:04"}}".
+type overlayData struct {
+	Hostname  string
+	User      string
+	Wallpaper string
+	Now       time.Time
+}
+
+func getOverlayOptions() (OverlayOptions, error) {
+	var opts OverlayOptions
+	if err := viper.UnmarshalKey(overlayConfigKey, &opts); err != nil {
+		return OverlayOptions{}, fmt.Errorf("%w : %v", ErrInvalidOverlay, err)
+	}
+	return opts, opts.validate()
+}
+
+// overlayText returns the text to draw for the wallpaper at the time.
+func overlayText(opts OverlayOptions, wallpaper string, now time.Time) (string, error) {
+	switch opts.Kind {
+	case "hostname":
+		return getHostname()
+	case "quote":
+		return quoteOfTheDay(opts.Quotes, now)
+	case "calendar":
+		return calendarText(now), nil
+	}
+
+	data := overlayData{Wallpaper: filepath.Base(sourceWallpaper(wallpaper)), Now: now}
+	data.Hostname, _ = getHostname()
+	if current, err := user.Current(); err == nil {
+		data.User = current.Username
+	}
+
+	var text strings.Builder
+	tmpl, err := template.New("overlay").Parse(opts.Text)
+	if err != nil {
+		return "", fmt.Errorf("%w : %v", ErrInvalidOverlay, err)
+	}
+	if err := tmpl.Execute(&text, data); err != nil {
+		return "", fmt.Errorf("%w : %v", ErrInvalidOverlay, err)
+	}
+	return text.String(), nil
+}
+
+// quoteOfTheDay picks a quote from the file, a new one every day. Quotes are
+// separated by lines with a single "%" like fortune files, or are one per line.
+func quoteOfTheDay(quotesFile string, now time.Time) (string, error) {
+	content, err := os.ReadFile(quotesFile)
+	if err != nil {
+		return "", err
+	}
+
+	normalized := "\n" + strings.ReplaceAll(string(content), "\r\n", "\n") + "\n"
+	separator := "\n"
+	if strings.Contains(normalized, "\n%\n") {
+		separator = "\n%\n"
+	}
+
+	var quotes []string
+	for _, quote := range strings.Split(normalized, separator) {
+		if quote = strings.TrimSpace(quote); quote != "" {
+			quotes = append(quotes, quote)
+		}
+	}
+
+	if len(quotes) == 0 {
+		return "", fmt.Errorf("%w : no quotes found in %s", ErrInvalidOverlay, quotesFile)
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+	return quotes[int(day)%len(quotes)], nil
+}
+
+// calendarText lays out the month like cal, with weeks starting on Monday.
+func calendarText(now time.Time) string {
+	var calendar strings.Builder
+	title := now.Format("January 2006")
+	fmt.Fprintf(&calendar, "%*s\n", (20+len(title))/2, title)
+	calendar.WriteString("Mo Tu We Th Fr Sa Su\n")
+
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	days := first.AddDate(0, 1, -1).Day()
+	column := (int(first.Weekday()) + 6) % 7
+	calendar.WriteString(strings.Repeat("   ", column))
+	for day := 1; day <= days; day++ {
+		fmt.Fprintf(&calendar, "%2d", day)
+		if column = (column + 1) % 7; column == 0 || day == days {
+			calendar.WriteString("\n")
+		} else {
+			calendar.WriteString(" ")
+		}
+	}
+	return strings.TrimRight(calendar.String(), "\n")
+}
+
+func overlayFace(name string, size float64) (font.Face, error) {
+	parsed, err := opentype.Parse(overlayFontFiles[name])
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// drawOverlay draws the text onto a copy of the image with a shadow so it stays
+// readable on busy images. Lines longer than half of the image are wrapped.
+func drawOverlay(img image.Image, text string, opts OverlayOptions) (*image.RGBA, error) {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	fontName := opts.Font
+	if opts.Kind == "calendar" {
+		// Columns only line up with a monospaced font.
+		fontName = "mono"
+	}
+	size := opts.Size * float64(bounds.Dy()) / overlayBaseHeight
+	face, err := overlayFace(fontName, max(size, 1))
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	lines := wrapOverlayText(face, text, fixed.I(bounds.Dx()/2))
+	if len(lines) == 0 {
+		return dst, nil
+	}
+
+	metrics := face.Metrics()
+	lineHeight := metrics.Height.Ceil()
+	widths := make([]int, len(lines))
+	for i, line := range lines {
+		widths[i] = font.MeasureString(face, line).Ceil()
+	}
+	blockWidth, blockHeight := slices.Max(widths), lineHeight*len(lines)
+
+	margin := int(size * 1.5)
+	var left, top int
+	switch opts.Corner {
+	case "top-left":
+		left, top = margin, margin
+	case "top-right":
+		left, top = bounds.Dx()-margin-blockWidth, margin
+	case "bottom-left":
+		left, top = margin, bounds.Dy()-margin-blockHeight
+	case "bottom-right":
+		left, top = bounds.Dx()-margin-blockWidth, bounds.Dy()-margin-blockHeight
+	default:
+		left, top = (bounds.Dx()-blockWidth)/2, (bounds.Dy()-blockHeight)/2
+	}
+
+	textColor := rgbColor(opts.Color)
+	shadowColor := color.RGBA{A: 160}
+	if (textColor[0]*299+textColor[1]*587+textColor[2]*114)/1000 < 128 {
+		shadowColor = color.RGBA{R: 160, G: 160, B: 160, A: 160}
+	}
+	shadowOffset := max(int(size/16), 1)
+
+	for _, layer := range []struct {
+		color  color.Color
+		offset int
+	}{
+		{shadowColor, shadowOffset},
+		{color.RGBA{R: uint8(textColor[0]), G: uint8(textColor[1]), B: uint8(textColor[2]), A: 255}, 0},
+	} {
+		drawer := font.Drawer{Dst: dst, Src: image.NewUniform(layer.color), Face: face}
+		for i, line := range lines {
+			x := left
+			switch {
+			case opts.Kind == "calendar":
+				// Lines are already padded into columns.
+			case opts.Corner == "top-right" || opts.Corner == "bottom-right":
+				x += blockWidth - widths[i]
+			case opts.Corner == "center":
+				x += (blockWidth - widths[i]) / 2
+			}
+			drawer.Dot = fixed.P(x+layer.offset, top+i*lineHeight+metrics.Ascent.Ceil()+layer.offset)
+			drawer.DrawString(line)
+		}
+	}
+	return dst, nil
+}
+
+// wrapOverlayText splits the text into lines no wider than the width, breaking
+// between words.
+func wrapOverlayText(face font.Face, text string, width fixed.Int26_6) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		words := strings.Split(paragraph, " ")
+		line := words[0]
+		for _, word := range words[1:] {
+			if font.MeasureString(face, line+" "+word) > width && strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line += " " + word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// renderOverlay returns a copy of the wallpaper with the overlay drawn on it, or the
+// wallpaper itself when overlays are off or it is a slideshow or not an image. Copies
+// are cached by the image and the text, so a template with the time renders again
+// once the time changes.
+func renderOverlay(wallpaper string, opts OverlayOptions) (string, error) {
+	if !opts.Enabled() || wallpaper == "" || strings.Contains(wallpaper, "://") || os_Specifics.IsSlideShowFile(wallpaper) {
+		return wallpaper, nil
+	}
+
+	text, err := overlayText(opts, wallpaper, overlayNow())
+	if err != nil {
+		return "", err
+	}
+
+	overlayPath, err := getCachePath("overlay")
+	if err != nil {
+		return "", err
+	}
+
+	key, err := contentCacheKey(wallpaper, opts.Kind, opts.Corner, opts.Font, opts.Size, opts.Color, text)
+	if err != nil {
+		return "", err
+	}
+
+	rendered := filepath.Join(overlayPath, key+".jpg")
+	if _, err := os.Stat(rendered); err == nil {
+		return rendered, nil
+	}
+
+	img, err := decodeImageFile(wallpaper)
+	if errors.Is(err, ErrUnsupportedImage) {
+		return wallpaper, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	composed, err := drawOverlay(img, text, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := writeJPEG(rendered, composed, overlayQuality); err != nil {
+		return "", err
+	}
+	if err := writeDerivedSource(rendered, wallpaper); err != nil {
+		return "", err
+	}
+	return rendered, nil
+}
+
+// EnableOverlay saves the overlay and applies the current wallpaper again with it.
+func EnableOverlay(out io.Writer, opts OverlayOptions) error {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.Kind == "quote" {
+		quotes, err := filepath.Abs(opts.Quotes)
+		if err != nil {
+			return err
+		}
+		if _, err := quoteOfTheDay(quotes, overlayNow()); err != nil {
+			return err
+		}
+		opts.Quotes = quotes
+	}
+
+	viper.Set(overlayConfigKey, opts)
+	if err := writeConfig(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "The %s will be drawn in the %s of every wallpaper.\n", opts.Kind, opts.Corner)
+	return reapplyCurrentWallpaper(out)
+}
+
+func DisableOverlay(out io.Writer) error {
+	viper.Set(overlayConfigKey, OverlayOptions{})
+	if err := writeConfig(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Wallpapers will be applied without an overlay.")
+	return reapplyCurrentWallpaper(out)
+}
+
+func OverlayStatus(out io.Writer) error {
+	opts, err := getOverlayOptions()
+	if err != nil {
+		return err
+	}
+
+	if !opts.Enabled() {
+		fmt.Fprintln(out, "No overlay is drawn, enable one with 'backdrop overlay hostname' or the other overlay commands.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Kind:\t%s\n", opts.Kind)
+	switch opts.Kind {
+	case "quote":
+		fmt.Fprintf(out, "Quotes:\t%s\n", opts.Quotes)
+	case "text":
+		fmt.Fprintf(out, "Text:\t%s\n", opts.Text)
+	}
+	fmt.Fprintf(out, "Corner:\t%s\nFont:\t%s\nSize:\t%g\nColor:\t%s\n", opts.Corner, opts.Font, opts.Size, opts.Color)
+	return nil
+}
+
+// reapplyCurrentWallpaper applies the wallpaper on screen again from its original,
+// so a changed overlay shows up right away.
+func reapplyCurrentWallpaper(out io.Writer) error {
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+
+	wallpaper, err := backend.CurrentWallpaper()
+	if err != nil || wallpaper == "" || strings.Contains(wallpaper, "://") || os_Specifics.IsSlideShowFile(wallpaper) {
+		// Nothing backdrop can draw on, the next wallpaper gets the overlay.
+		return nil
+	}
+
+	wallpaper = sourceWallpaper(wallpaper)
+	if light, _, err := findPair(wallpaper); err == nil {
+		wallpaper = light
+	} else if !errors.Is(err, ErrPairNotFound) {
+		return err
+	}
+
+	if _, err := os.Stat(wallpaper); err != nil {
+		fmt.Fprintf(out, "Could not apply %s again, the next wallpaper gets the change.\n", wallpaper)
+		return nil
+	}
+	return applyWallpaper(out, backend, allOutputs, wallpaper, Fit{}, nil)
+}
@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestCalendarText(t *testing.T) {
+	exp := `    October 2026
+Mo Tu We Th Fr Sa Su
+          1  2  3  4
+ 5  6  7  8  9 10 11
+12 13 14 15 16 17 18
+19 20 21 22 23 24 25
+26 27 28 29 30 31`
+
+	if got := calendarText(time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)); got != exp {
+		t.Errorf("Expected calendar:\n%s\nbut got:\n%s", exp, got)
+	}
+}
+
+func TestQuoteOfTheDay(t *testing.T) {
+	dir := t.TempDir()
+	monday := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		content string
+		exp     [2]string
+	}{
+		{name: "Lines", content: "First quote\n\nSecond quote\n", exp: [2]string{"Second quote", "First quote"}},
+		{name: "Fortune", content: "First quote\n  -- someone\n%\nSecond quote\n%\n", exp: [2]string{"Second quote", "First quote\n  -- someone"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			quotesFile := filepath.Join(dir, testCase.name)
+			if err := os.WriteFile(quotesFile, []byte(testCase.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, day := range []time.Time{monday, monday.AddDate(0, 0, 1)} {
+				got, err := quoteOfTheDay(quotesFile, day)
+				if err != nil {
+					t.Fatalf("Expected NO error, but got '%v' instead", err)
+				}
+				if got != testCase.exp[i] {
+					t.Errorf("Expected quote '%s' on %s, but got '%s' instead", testCase.exp[i], day.Weekday(), got)
+				}
+			}
+		})
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n%\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quoteOfTheDay(empty, monday); !errors.Is(err, ErrInvalidOverlay) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidOverlay, err)
+	}
+}
+
+func TestOverlayText(t *testing.T) {
+	originalGetHostname := getHostname
+	getHostname = func() (string, error) { return "lab-07", nil }
+	defer func() { getHostname = originalGetHostname }()
+
+	now := time.Date(2026, time.October, 19, 9, 30, 0, 0, time.UTC)
+	opts := OverlayOptions{Kind: "text", Text: `{{.Hostname}} {{.Wallpaper}} {{.Now.Format "Mon 15:04"}}`}
+	if got, err := overlayText(opts, "/images/lake.jpg", now); err != nil || got != "lab-07 lake.jpg Mon 09:30" {
+		t.Errorf("Expected text 'lab-07 lake.jpg Mon 09:30', but got '%s' and '%v'", got, err)
+	}
+	if got, err := overlayText(OverlayOptions{Kind: "hostname"}, "/images/lake.jpg", now); err != nil || got != "lab-07" {
+		t.Errorf("Expected text 'lab-07', but got '%s' and '%v'", got, err)
+	}
+}
+
+func TestDrawOverlay(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 320, 180))
+	for i := range src.Pix {
+		src.Pix[i] = 40
+	}
+
+	inked := func(img *image.RGBA, area image.Rectangle) bool {
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				if pixelAt(img, x, y) != [3]uint8{40, 40, 40} {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	quarters := map[string]image.Rectangle{
+		"top-left":     image.Rect(0, 0, 160, 90),
+		"top-right":    image.Rect(160, 0, 320, 90),
+		"bottom-left":  image.Rect(0, 90, 160, 180),
+		"bottom-right": image.Rect(160, 90, 320, 180),
+	}
+
+	for corner := range quarters {
+		t.Run(corner, func(t *testing.T) {
+			opts := OverlayOptions{Kind: "hostname", Corner: corner}.withDefaults()
+			got, err := drawOverlay(src, "lab-07", opts)
+			if err != nil {
+				t.Fatalf("Expected NO error, but got '%v' instead", err)
+			}
+
+			for quarter, area := range quarters {
+				if inked(got, area) != (quarter == corner) {
+					t.Errorf("Expected text only in the %s quarter, but the %s quarter was %v", corner, quarter, inked(got, area))
+				}
+			}
+		})
+	}
+
+	if pixelAt(src, 300, 170) != [3]uint8{40, 40, 40} {
+		t.Error("Expected the source image to be left untouched")
+	}
+}
+
+func TestRenderOverlay(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	wallpaper := filepath.Join(t.TempDir(), "wallpaper.png")
+	writeTestPNG(t, wallpaper, color.RGBA{R: 20, G: 30, B: 60, A: 255})
+
+	if got, err := renderOverlay(wallpaper, OverlayOptions{}); err != nil || got != wallpaper {
+		t.Fatalf("Expected the original image without an overlay, but got '%s' and '%v'", got, err)
+	}
+
+	opts := OverlayOptions{Kind: "text", Text: "hello"}.withDefaults()
+	rendered, err := renderOverlay(wallpaper, opts)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if rendered == wallpaper || sourceWallpaper(rendered) != wallpaper {
+		t.Errorf("Expected a copy of '%s', but got '%s' instead", wallpaper, rendered)
+	}
+
+	opts.Text = "goodbye"
+	if changed, err := renderOverlay(wallpaper, opts); err != nil || changed == rendered {
+		t.Errorf("Expected a new copy once the text changes, but got '%s' and '%v'", changed, err)
+	}
+}
+
+func TestOverlayOptionsValidate(t *testing.T) {
+	valid := OverlayOptions{Kind: "text", Text: "{{.Hostname}}"}.withDefaults()
+	if err := valid.validate(); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	for name, opts := range map[string]OverlayOptions{
+		"Kind":     {Kind: "weather"},
+		"Corner":   {Kind: "hostname", Corner: "middle"},
+		"Font":     {Kind: "hostname", Font: "comic"},
+		"Size":     {Kind: "hostname", Size: 1},
+		"Color":    {Kind: "hostname", Color: "white"},
+		"Quotes":   {Kind: "quote"},
+		"Template": {Kind: "text", Text: "{{.Hostname"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := opts.withDefaults().validate(); !errors.Is(err, ErrInvalidOverlay) {
+				t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidOverlay, err)
+			}
+		})
+	}
+}
+
+func TestOverlayKeepsSlideShows(t *testing.T) {
+	library := setupManagedLibrary(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	originalOverlay := viper.Get(overlayConfigKey)
+	t.Cleanup(func() { viper.Set(overlayConfigKey, originalOverlay) })
+	viper.Set(overlayConfigKey, OverlayOptions{Kind: "text", Text: "hello"}.withDefaults())
+
+	slideShow, err := configureSlideShow("lake.png;tower.png", library, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend, _ := getBackend()
+
+	if err := setWallpaper(io.Discard, slideShow); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if err := reapplyCurrentWallpaper(io.Discard); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if current, _ := backend.CurrentWallpaper(); current != slideShow {
+		t.Errorf("Expected slideshow '%s' to stay set, but got '%s' instead", slideShow, current)
+	}
+
+	notAnImage := filepath.Join(library, "notes.txt")
+	if err := os.WriteFile(notAnImage, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := OverlayOptions{Kind: "text", Text: "hello"}.withDefaults()
+	if rendered, err := renderOverlay(notAnImage, opts); err != nil || rendered != notAnImage {
+		t.Errorf("Expected '%s' unchanged, but got '%s' and '%v'", notAnImage, rendered, err)
+	}
+}
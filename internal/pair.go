@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// darkVariantBackend is implemented by backends that keep a light and a dark image
+// and switch between them when the color scheme changes.
+type darkVariantBackend interface {
+	HasDarkVariant() bool
+	SetWallpaperPair(output, light, dark string) error
+}
+
+func hasDarkVariant(backend wallpaperBackend) bool {
+	darkBackend, ok := backend.(darkVariantBackend)
+	return ok && darkBackend.HasDarkVariant()
+}
+
+// setWallpaperVariant hands the pair to backends that switch images themselves, the
+// others get the image matching the current color scheme.
+func setWallpaperVariant(backend wallpaperBackend, output, light, dark string) error {
+	if dark == "" {
+		return backend.SetWallpaper(output, light)
+	}
+
+	if hasDarkVariant(backend) {
+		return backend.(darkVariantBackend).SetWallpaperPair(output, light, dark)
+	}
+
+	return backend.SetWallpaper(output, activeVariant(light, dark))
+}
+
+// activeVariant returns the image of the pair matching the current color scheme.
+func activeVariant(light, dark string) string {
+	if dark == "" {
+		return light
+	}
+	if colorScheme, err := getColorScheme(); err == nil && colorScheme == ColorSchemeDark {
+		return dark
+	}
+	return light
+}
+
+func setWallpaperPairFit(out io.Writer, light, dark string, fit Fit, effects Effects) error {
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+
+	metadata, _ := getImageMetadata(light)
+	if fit.IsZero() {
+		fit = metadata.Fit
+	}
+	if effects == nil {
+		effects = savedEffects(metadata)
+	}
+	return applyWallpaperPair(out, backend, allOutputs, light, dark, fit, effects)
+}
+
+// handlePairSelection lets the user pick two images, the darker one is shown while
+// the desktop is in dark mode.
+func handlePairSelection(out io.Writer, wallpapersPath string, wallpapers []string, imageSelection FuzzySelection, fit Fit, effects Effects) error {
+	for hasConfirmed := false; !hasConfirmed; {
+		selection, err := imageSelection(wallpapers)
+		if err != nil {
+			return err
+		}
+
+		selectedImages := strings.Split(selection, ";")
+		if len(selectedImages) != 2 {
+			return fmt.Errorf("%w : selected %d images, select two with 'Tab'", ErrInvalidPair, len(selectedImages))
+		}
+
+		light, dark, err := orderPairByBrightness(libraryImagePath(wallpapersPath, selectedImages[0]), libraryImagePath(wallpapersPath, selectedImages[1]))
+		if err != nil {
+			return err
+		}
+
+		hasConfirmed, err = previewPair(out, light, dark, fit, effects)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handlePairFiles previews the pair given with --light and --dark.
+func handlePairFiles(out io.Writer, wallpapersPath, light, dark string, fit Fit, effects Effects) error {
+	lightPath, err := resolvePairImage(wallpapersPath, light)
+	if err != nil {
+		return err
+	}
+
+	darkPath, err := resolvePairImage(wallpapersPath, dark)
+	if err != nil {
+		return err
+	}
+
+	if lightPath == darkPath {
+		return fmt.Errorf("%w : light and dark are both %s", ErrInvalidPair, lightPath)
+	}
+
+	_, err = previewPair(out, lightPath, darkPath, fit, effects)
+	return err
+}
+
+func previewPair(out io.Writer, light, dark string, fit Fit, effects Effects) (bool, error) {
+	previousState, err := captureWallpaperState()
+	if err != nil {
+		return false, err
+	}
+
+	if err := setWallpaperPairFit(out, light, dark, fit, effects); err != nil {
+		return false, err
+	}
+
+	fmt.Fprintf(out, "Light: %s\nDark:  %s\n", light, dark)
+	return handleSelectionConfirmation(previousState, out, &SelectionOptions{
+		SuccessMessage: "Successfully saved light and dark background images!",
+		Cleanup:        func() {},
+		Wallpaper:      light,
+		Dark:           dark,
+		Source:         historySourceFuzzy,
+		Fit:            fit,
+		Effects:        effects,
+	})
+}
+
+// resolvePairImage accepts paths to any image and names of images in the
+// wallpapers path.
+func resolvePairImage(wallpapersPath, image string) (string, error) {
+	for _, path := range []string{image, libraryImagePath(wallpapersPath, image)} {
+		if stats, err := os.Stat(path); err == nil && stats.Mode().IsRegular() {
+			return filepath.Abs(path)
+		}
+	}
+	return "", fmt.Errorf("%w : %s", ErrImageNotFound, image)
+}
+
+// orderPairByBrightness returns the brighter image first.
+func orderPairByBrightness(first, second string) (string, string, error) {
+	firstLuminance, err := meanLuminance(first)
+	if err != nil {
+		return "", "", err
+	}
+
+	secondLuminance, err := meanLuminance(second)
+	if err != nil {
+		return "", "", err
+	}
+
+	if secondLuminance > firstLuminance {
+		return second, first, nil
+	}
+	return first, second, nil
+}
+
+// meanLuminance returns the average relative luminance of the image between 0 and 255.
+func meanLuminance(path string) (float64, error) {
+	img, err := decodeImageFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	small := resizeImage(img, 32, 32)
+	var sum float64
+	for i := 0; i < len(small.Pix); i += 4 {
+		sum += 0.2126*float64(small.Pix[i]) + 0.7152*float64(small.Pix[i+1]) + 0.0722*float64(small.Pix[i+2])
+	}
+	return sum / float64(len(small.Pix)/4), nil
+}
+
+// findPair returns the light image of the pair the image belongs to, whichever
+// variant it is.
+func findPair(image string) (string, ImageMetadata, error) {
+	metadata, err := loadMetadata()
+	if err != nil {
+		return "", ImageMetadata{}, err
+	}
+
+	if imageMetadata, ok := metadata[image]; ok && imageMetadata.Dark != "" {
+		return image, imageMetadata, nil
+	}
+	for light, imageMetadata := range metadata {
+		if imageMetadata.Dark == image {
+			return light, imageMetadata, nil
+		}
+	}
+	return "", ImageMetadata{}, fmt.Errorf("%w : %s", ErrPairNotFound, image)
+}
+
+func ListPairs(out io.Writer) error {
+	metadata, err := loadMetadata()
+	if err != nil {
+		return err
+	}
+
+	var lights []string
+	for light, imageMetadata := range metadata {
+		if imageMetadata.Dark != "" {
+			lights = append(lights, light)
+		}
+	}
+	sort.Strings(lights)
+
+	if len(lights) == 0 {
+		fmt.Fprintln(out, "No light and dark pairs saved, pick one with 'backdrop --pair'.")
+		return nil
+	}
+
+	for _, light := range lights {
+		fmt.Fprintf(out, "%s\t%s\n", light, metadata[light].Dark)
+	}
+	return nil
+}
+
+// RemovePair forgets the pair of an image, both images stay in the library.
+func RemovePair(out io.Writer, image string) error {
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+
+	imagePath, err := resolvePairImage(wallpapersPath, image)
+	if err != nil {
+		return err
+	}
+
+	light, imageMetadata, err := findPair(imagePath)
+	if err != nil {
+		return err
+	}
+
+	if err := updateImageMetadata(light, func(metadata *ImageMetadata) { metadata.Dark = "" }); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Removed the pair of %s and %s.\n", light, imageMetadata.Dark)
+	return nil
+}
+
+// WatchPairs switches the current pair to the variant matching the color scheme
+// whenever it changes, for desktops that can't do it themselves and no running daemon.
+func WatchPairs(out io.Writer) error {
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+
+	if hasDarkVariant(backend) {
+		fmt.Fprintf(out, "The %s desktop switches between light and dark images itself, nothing to watch.\n", backend.Name())
+		return nil
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := reapplyCurrentPair(out, backend); err != nil {
+		fmt.Fprintf(out, "Could not switch wallpaper variant: %v\n", err)
+	}
+
+	fmt.Fprintf(out, "Watching the color scheme to switch wallpapers on the %s backend...\n", backend.Name())
+	return watchColorScheme(ctx, conn, func(colorScheme ColorScheme) {
+		fmt.Fprintf(out, "Color scheme changed to %s.\n", colorScheme)
+		if err := reapplyCurrentPair(out, backend); err != nil {
+			fmt.Fprintf(out, "Could not switch wallpaper variant: %v\n", err)
+		}
+	})
+}
+
+func reapplyCurrentPair(out io.Writer, backend wallpaperBackend) error {
+	wallpaper, err := backend.CurrentWallpaper()
+	if err != nil {
+		return err
+	}
+
+	light, _, err := findPair(sourceWallpaper(wallpaper))
+	if errors.Is(err, ErrPairNotFound) {
+		// Images without a dark variant look the same in both modes.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return applyWallpaper(out, backend, allOutputs, light, Fit{}, nil)
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type fakePairBackend struct {
+	*fakeBackend
+	pairs chan [2]string
+}
+
+func (b *fakePairBackend) HasDarkVariant() bool {
+	return true
+}
+
+func (b *fakePairBackend) SetWallpaperPair(output, light, dark string) error {
+	b.pairs <- [2]string{light, dark}
+	return nil
+}
+
+func writeTestPNG(t *testing.T, path string, c color.Color) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderPairByBrightness(t *testing.T) {
+	dir := t.TempDir()
+	bright := filepath.Join(dir, "bright.png")
+	dark := filepath.Join(dir, "dark.png")
+	writeTestPNG(t, bright, color.RGBA{R: 230, G: 220, B: 200, A: 255})
+	writeTestPNG(t, dark, color.RGBA{R: 20, G: 30, B: 60, A: 255})
+
+	for _, order := range [][2]string{{bright, dark}, {dark, bright}} {
+		light, gotDark, err := orderPairByBrightness(order[0], order[1])
+		if err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+		if light != bright || gotDark != dark {
+			t.Errorf("Expected light '%s' and dark '%s', but got '%s' and '%s' instead", bright, dark, light, gotDark)
+		}
+	}
+}
+
+func TestApplyWallpaperPair(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	light, dark := "/images/day.jpg", "/images/night.jpg"
+	if err := updateImageMetadata(light, func(metadata *ImageMetadata) { metadata.Dark = dark }); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	colorScheme := ColorSchemeDefault
+	originalGetColorScheme := getColorScheme
+	getColorScheme = func() (ColorScheme, error) { return colorScheme, nil }
+	defer func() { getColorScheme = originalGetColorScheme }()
+
+	t.Run("ColorScheme", func(t *testing.T) {
+		backend := newFakeBackend(allOutputs)
+		for _, testCase := range []struct {
+			colorScheme ColorScheme
+			exp         string
+		}{
+			{ColorSchemeDefault, light},
+			{ColorSchemeLight, light},
+			{ColorSchemeDark, dark},
+		} {
+			colorScheme = testCase.colorScheme
+			if err := applyWallpaper(io.Discard, backend, allOutputs, light, Fit{}, nil); err != nil {
+				t.Fatalf("Expected NO error, but got '%v' instead", err)
+			}
+			backend.expect(t, fakeWallpaper{allOutputs, testCase.exp})
+		}
+	})
+
+	t.Run("DarkVariantBackend", func(t *testing.T) {
+		colorScheme = ColorSchemeDark
+		backend := &fakePairBackend{fakeBackend: newFakeBackend(allOutputs), pairs: make(chan [2]string, 1)}
+		if err := applyWallpaper(io.Discard, backend, allOutputs, light, Fit{}, nil); err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+		if got := <-backend.pairs; got != [2]string{light, dark} {
+			t.Errorf("Expected pair '%v', but got '%v' instead", [2]string{light, dark}, got)
+		}
+		backend.expectNothing(t)
+	})
+
+	t.Run("FindPair", func(t *testing.T) {
+		for _, image := range []string{light, dark} {
+			if got, _, err := findPair(image); err != nil || got != light {
+				t.Errorf("Expected pair of '%s' to be '%s', but got '%s' and '%v'", image, light, got, err)
+			}
+		}
+		if _, _, err := findPair("/images/other.jpg"); !errors.Is(err, ErrPairNotFound) {
+			t.Errorf("Expected error '%v', but got '%v' instead", ErrPairNotFound, err)
+		}
+	})
+}
+
+func TestColorSchemeFromVariant(t *testing.T) {
+	testCases := []struct {
+		name   string
+		value  dbus.Variant
+		exp    ColorScheme
+		expErr bool
+	}{
+		{name: "ReadOne", value: dbus.MakeVariant(uint32(1)), exp: ColorSchemeDark},
+		{name: "Read", value: dbus.MakeVariant(dbus.MakeVariant(uint32(2))), exp: ColorSchemeLight},
+		{name: "Invalid", value: dbus.MakeVariant("dark"), expErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := colorSchemeFromVariant(testCase.value)
+			if testCase.expErr != (err != nil) {
+				t.Fatalf("Expected error %v, but got '%v' instead", testCase.expErr, err)
+			}
+			if got != testCase.exp {
+				t.Errorf("Expected color scheme '%s', but got '%s' instead", testCase.exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,526 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/spf13/viper"
+)
+
+const (
+	paletteConfigKey = "Palette"
+	// paletteSampleSize is the longest side of the copy colors are picked from.
+	paletteSampleSize = 128
+	paletteBaseColors = 8
+)
+
+// PaletteOptions turn on writing a terminal palette every time a wallpaper is
+// applied. The hook runs afterwards to reload programs using the palette.
+type PaletteOptions struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	Hook    string `mapstructure:"hook" yaml:"hook,omitempty" json:"hook,omitempty"`
+}
+
+// Palette holds 16 terminal colors picked from a wallpaper, in the order of the
+// ANSI colors: 0-7 are the regular colors and 8-15 their bright variants.
+type Palette struct {
+	Wallpaper  string
+	Background string
+	Foreground string
+	Cursor     string
+	Colors     [16]string
+}
+
+type paletteJSON struct {
+	Wallpaper string            `json:"wallpaper"`
+	Special   map[string]string `json:"special"`
+	Colors    map[string]string `json:"colors"`
+}
+
+// MarshalJSON uses the layout of pywal's colors.json, which many tools read.
+func (p Palette) MarshalJSON() ([]byte, error) {
+	colors := make(map[string]string, len(p.Colors))
+	for i, color := range p.Colors {
+		colors[fmt.Sprintf("color%d", i)] = color
+	}
+	return json.Marshal(paletteJSON{
+		Wallpaper: p.Wallpaper,
+		Special:   map[string]string{"background": p.Background, "foreground": p.Foreground, "cursor": p.Cursor},
+		Colors:    colors,
+	})
+}
+
+func (p *Palette) UnmarshalJSON(content []byte) error {
+	var decoded paletteJSON
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		return err
+	}
+
+	p.Wallpaper = decoded.Wallpaper
+	p.Background = decoded.Special["background"]
+	p.Foreground = decoded.Special["foreground"]
+	p.Cursor = decoded.Special["cursor"]
+	for i := range p.Colors {
+		p.Colors[i] = decoded.Colors[fmt.Sprintf("color%d", i)]
+	}
+	return nil
+}
+
+type rgb [3]float64
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", uint8(math.Round(c[0])), uint8(math.Round(c[1])), uint8(math.Round(c[2])))
+}
+
+func (c rgb) luminance() float64 {
+	return 0.2126*c[0] + 0.7152*c[1] + 0.0722*c[2]
+}
+
+func (c rgb) hue() float64 {
+	r, g, b := c[0], c[1], c[2]
+	high, low := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	if high == low {
+		return 0
+	}
+
+	var hue float64
+	switch high {
+	case r:
+		hue = math.Mod((g-b)/(high-low), 6)
+	case g:
+		hue = (b-r)/(high-low) + 2
+	default:
+		hue = (r-g)/(high-low) + 4
+	}
+	return math.Mod(hue*60+360, 360)
+}
+
+// mix moves the color towards the target by the amount from 0 to 1.
+func (c rgb) mix(target rgb, amount float64) rgb {
+	for channel := range c {
+		c[channel] += (target[channel] - c[channel]) * amount
+	}
+	return c
+}
+
+var (
+	black = rgb{0, 0, 0}
+	white = rgb{255, 255, 255}
+)
+
+// extractPalette picks the main colors of the image with median cut and turns them
+// into a readable terminal palette: a dark background, a light foreground and the
+// other colors in between ordered by hue.
+func extractPalette(img image.Image) Palette {
+	bounds := img.Bounds()
+	scale := float64(paletteSampleSize) / float64(max(bounds.Dx(), bounds.Dy(), 1))
+	sample := resizeImage(img, max(int(float64(bounds.Dx())*scale), 1), max(int(float64(bounds.Dy())*scale), 1))
+
+	pixels := make([]rgb, 0, len(sample.Pix)/4)
+	for i := 0; i < len(sample.Pix); i += 4 {
+		pixels = append(pixels, rgb{float64(sample.Pix[i]), float64(sample.Pix[i+1]), float64(sample.Pix[i+2])})
+	}
+
+	base := medianCut(pixels, paletteBaseColors)
+	sort.SliceStable(base, func(i, j int) bool {
+		return base[i].luminance() < base[j].luminance()
+	})
+
+	background := base[0].mix(black, 0.6)
+	foreground := base[len(base)-1].mix(white, 0.6)
+
+	accents := append([]rgb(nil), base[1:len(base)-1]...)
+	sort.SliceStable(accents, func(i, j int) bool {
+		return accents[i].hue() < accents[j].hue()
+	})
+
+	var palette Palette
+	palette.Background = background.hex()
+	palette.Foreground = foreground.hex()
+	palette.Cursor = foreground.hex()
+	palette.Colors[0] = background.hex()
+	palette.Colors[7] = foreground.mix(background, 0.15).hex()
+	palette.Colors[8] = background.mix(foreground, 0.3).hex()
+	palette.Colors[15] = foreground.hex()
+	for i, accent := range accents {
+		palette.Colors[i+1] = accent.hex()
+		palette.Colors[i+9] = accent.mix(white, 0.2).hex()
+	}
+	return palette
+}
+
+// medianCut splits the pixels into boxes of similar colors, always splitting the box
+// with the widest channel at its median, and returns the average of every box.
+// Images with fewer colors are padded with shades between their darkest and
+// lightest color.
+func medianCut(pixels []rgb, colors int) []rgb {
+	boxes := [][]rgb{pixels}
+	for len(boxes) < colors {
+		widest, widestChannel, widestRange := -1, 0, 0.0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for channel := 0; channel < 3; channel++ {
+				low, high := box[0][channel], box[0][channel]
+				for _, pixel := range box {
+					low, high = math.Min(low, pixel[channel]), math.Max(high, pixel[channel])
+				}
+				if high-low > widestRange {
+					widest, widestChannel, widestRange = i, channel, high-low
+				}
+			}
+		}
+		if widest < 0 {
+			break
+		}
+
+		box := boxes[widest]
+		sort.SliceStable(box, func(i, j int) bool {
+			return box[i][widestChannel] < box[j][widestChannel]
+		})
+		middle := len(box) / 2
+		boxes = append(boxes[:widest], append([][]rgb{box[:middle], box[middle:]}, boxes[widest+1:]...)...)
+	}
+
+	averages := make([]rgb, 0, colors)
+	for _, box := range boxes {
+		var sum rgb
+		for _, pixel := range box {
+			for channel := range sum {
+				sum[channel] += pixel[channel]
+			}
+		}
+		for channel := range sum {
+			sum[channel] /= float64(max(len(box), 1))
+		}
+		averages = append(averages, sum)
+	}
+
+	sort.SliceStable(averages, func(i, j int) bool {
+		return averages[i].luminance() < averages[j].luminance()
+	})
+	for darkest, lightest := averages[0], averages[len(averages)-1]; len(averages) < colors; {
+		averages = append(averages, darkest.mix(lightest, float64(len(averages))/float64(colors-1)))
+	}
+	return averages
+}
+
+// paletteExports are written next to each other, named like pywal's files so tools
+// made for it find them.
+var paletteExports = []struct {
+	fileName string
+	template *template.Template
+}{
+	{"colors.sh", template.Must(template.New("sh").Funcs(paletteFuncs).Parse(`# Generated by backdrop from {{.Wallpaper}}
+export wallpaper={{shellQuote .Wallpaper}}
+export background='{{.Background}}'
+export foreground='{{.Foreground}}'
+export cursor='{{.Cursor}}'
+{{- range $i, $color := .Colors}}
+export color{{$i}}='{{$color}}'
+{{- end}}
+`))},
+	{"colors.Xresources", template.Must(template.New("Xresources").Parse(`! Generated by backdrop from {{.Wallpaper}}
+*.background: {{.Background}}
+*.foreground: {{.Foreground}}
+*.cursorColor: {{.Cursor}}
+{{- range $i, $color := .Colors}}
+*.color{{$i}}: {{$color}}
+{{- end}}
+`))},
+	{"colors-kitty.conf", template.Must(template.New("kitty").Parse(`# Generated by backdrop from {{.Wallpaper}}
+background {{.Background}}
+foreground {{.Foreground}}
+cursor {{.Cursor}}
+{{- range $i, $color := .Colors}}
+color{{$i}} {{$color}}
+{{- end}}
+`))},
+	{"colors-alacritty.toml", template.Must(template.New("alacritty").Parse(`# Generated by backdrop from {{.Wallpaper}}
+[colors.primary]
+background = "{{.Background}}"
+foreground = "{{.Foreground}}"
+
+[colors.cursor]
+cursor = "{{.Cursor}}"
+
+[colors.normal]
+black = "{{index .Colors 0}}"
+red = "{{index .Colors 1}}"
+green = "{{index .Colors 2}}"
+yellow = "{{index .Colors 3}}"
+blue = "{{index .Colors 4}}"
+magenta = "{{index .Colors 5}}"
+cyan = "{{index .Colors 6}}"
+white = "{{index .Colors 7}}"
+
+[colors.bright]
+black = "{{index .Colors 8}}"
+red = "{{index .Colors 9}}"
+green = "{{index .Colors 10}}"
+yellow = "{{index .Colors 11}}"
+blue = "{{index .Colors 12}}"
+magenta = "{{index .Colors 13}}"
+cyan = "{{index .Colors 14}}"
+white = "{{index .Colors 15}}"
+`))},
+	{"colors-foot.ini", template.Must(template.New("foot").Funcs(paletteFuncs).Parse(`# Generated by backdrop from {{.Wallpaper}}
+[colors]
+background={{bare .Background}}
+foreground={{bare .Foreground}}
+{{- range $i, $color := .Colors}}
+{{if lt $i 8}}regular{{$i}}{{else}}bright{{subtract $i 8}}{{end}}={{bare $color}}
+{{- end}}
+`))},
+	{"colors.css", template.Must(template.New("css").Funcs(paletteFuncs).Parse(`/* Generated by backdrop from {{.Wallpaper}} */
+:root {
+  --wallpaper: url({{cssQuote .Wallpaper}});
+  --background: {{.Background}};
+  --foreground: {{.Foreground}};
+  --cursor: {{.Cursor}};
+{{- range $i, $color := .Colors}}
+  --color{{$i}}: {{$color}};
+{{- end}}
+}
+`))},
+}
+
+var paletteFuncs = template.FuncMap{
+	"bare":       func(color string) string { return strings.TrimPrefix(color, "#") },
+	"subtract":   func(a, b int) int { return a - b },
+	"shellQuote": func(s string) string { return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'" },
+	"cssQuote": func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `).Replace(s) + `"`
+	},
+}
+
+func getPaletteOptions() (PaletteOptions, error) {
+	var opts PaletteOptions
+	if err := viper.UnmarshalKey(paletteConfigKey, &opts); err != nil {
+		return PaletteOptions{}, err
+	}
+	return opts, nil
+}
+
+func getPalettePath() (string, error) {
+	return getCachePath("palette")
+}
+
+// writePalette writes the palette in every export format.
+func writePalette(palettePath string, palette Palette) error {
+	content, err := json.MarshalIndent(palette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(palettePath, "colors.json"), append(content, '\n'), 0644); err != nil {
+		return err
+	}
+
+	for _, export := range paletteExports {
+		var content strings.Builder
+		if err := export.template.Execute(&content, palette); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(palettePath, export.fileName), []byte(content.String()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getPalette returns the palette of the image, extracted palettes are cached by the
+// contents of the image.
+func getPalette(wallpaper string) (Palette, error) {
+	cachePath, err := getCachePath("palettes")
+	if err != nil {
+		return Palette{}, err
+	}
+
+	key, err := contentCacheKey(wallpaper, paletteBaseColors)
+	if err != nil {
+		return Palette{}, err
+	}
+
+	var palette Palette
+	cached := filepath.Join(cachePath, key+".json")
+	if content, err := os.ReadFile(cached); err == nil && json.Unmarshal(content, &palette) == nil {
+		palette.Wallpaper = wallpaper
+		return palette, nil
+	}
+
+	img, err := decodeImageFile(wallpaper)
+	if err != nil {
+		return Palette{}, err
+	}
+
+	palette = extractPalette(img)
+	palette.Wallpaper = wallpaper
+	content, err := json.Marshal(palette)
+	if err != nil {
+		return Palette{}, err
+	}
+	return palette, writeFileAtomic(cached, content, 0644)
+}
+
+// updatePalette writes the palette of the wallpaper and runs the hook, when turned on.
+// Slideshows and files that aren't images keep the palette as it is.
+func updatePalette(wallpaper string) error {
+	opts, err := getPaletteOptions()
+	if err != nil || !opts.Enabled || wallpaper == "" || strings.Contains(wallpaper, "://") || os_Specifics.IsSlideShowFile(wallpaper) {
+		return err
+	}
+
+	if err := exportPalette(opts, wallpaper); err != nil && !errors.Is(err, ErrUnsupportedImage) {
+		return err
+	}
+	return nil
+}
+
+// applyPalette updates the palette for the variant on screen. The wallpaper is already
+// on screen, a palette that can't be written is not worth failing for.
+func applyPalette(out io.Writer, light, dark string) {
+	if err := updatePalette(activeVariant(light, dark)); err != nil {
+		fmt.Fprintf(out, "Could not update the palette: %v\n", err)
+	}
+}
+
+func exportPalette(opts PaletteOptions, wallpaper string) error {
+	palette, err := getPalette(wallpaper)
+	if err != nil {
+		return err
+	}
+
+	palettePath, err := getPalettePath()
+	if err != nil {
+		return err
+	}
+
+	palette.Wallpaper = sourceWallpaper(wallpaper)
+	if err := writePalette(palettePath, palette); err != nil {
+		return err
+	}
+
+	if opts.Hook == "" {
+		return nil
+	}
+	return runPaletteHook(opts.Hook, palettePath, palette.Wallpaper)
+}
+
+// runPaletteHook runs the hook through the shell with the palette directory and the
+// wallpaper in its environment.
+func runPaletteHook(hook, palettePath, wallpaper string) error {
+	cmd := exec.Command("sh", "-c", hook)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", hook)
+	}
+	cmd.Env = append(os.Environ(), "BACKDROP_PALETTE_DIR="+palettePath, "BACKDROP_WALLPAPER="+wallpaper)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("palette hook '%s' failed: %w : %s", hook, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func EnablePalette(out io.Writer, hook string) error {
+	viper.Set(paletteConfigKey, PaletteOptions{Enabled: true, Hook: hook})
+	if err := writeConfig(); err != nil {
+		return err
+	}
+
+	palettePath, err := getPalettePath()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "A palette of every wallpaper applied will be written to %s.\n", palettePath)
+	return nil
+}
+
+func DisablePalette(out io.Writer) error {
+	viper.Set(paletteConfigKey, PaletteOptions{})
+	if err := writeConfig(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Palettes will no longer be written.")
+	return nil
+}
+
+// ExportPalette writes the palette of the image, or of the current wallpaper, and
+// runs the hook whether or not palettes are turned on.
+func ExportPalette(out io.Writer, image string) error {
+	wallpaper, err := paletteWallpaper(image)
+	if err != nil {
+		return err
+	}
+
+	opts, err := getPaletteOptions()
+	if err != nil {
+		return err
+	}
+	if err := exportPalette(opts, wallpaper); err != nil {
+		return err
+	}
+
+	palettePath, err := getPalettePath()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote the palette of %s to %s.\n", sourceWallpaper(wallpaper), palettePath)
+	return nil
+}
+
+// ShowPalette prints the palette of the image, or of the current wallpaper, with a
+// swatch of every color.
+func ShowPalette(out io.Writer, image string) error {
+	wallpaper, err := paletteWallpaper(image)
+	if err != nil {
+		return err
+	}
+
+	palette, err := getPalette(wallpaper)
+	if err != nil {
+		return err
+	}
+
+	printSwatch := func(name, color string) {
+		c := rgbColor(color)
+		fmt.Fprintf(out, "\x1b[48;2;%d;%d;%dm      \x1b[0m %-11s %s\n", c[0], c[1], c[2], name, color)
+	}
+	printSwatch("background", palette.Background)
+	printSwatch("foreground", palette.Foreground)
+	for i, color := range palette.Colors {
+		printSwatch(fmt.Sprintf("color%d", i), color)
+	}
+	return nil
+}
+
+// paletteWallpaper resolves the image argument of the palette commands, the image
+// on screen is used when none is given.
+func paletteWallpaper(image string) (string, error) {
+	if image == "" {
+		backend, err := getBackend()
+		if err != nil {
+			return "", err
+		}
+		return backend.CurrentWallpaper()
+	}
+
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return "", err
+	}
+	return resolvePairImage(wallpapersPath, image)
+}
@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func stripedImage(colors ...color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16*len(colors), 16))
+	for x := 0; x < img.Bounds().Dx(); x++ {
+		for y := 0; y < 16; y++ {
+			img.SetRGBA(x, y, colors[x/16])
+		}
+	}
+	return img
+}
+
+func TestExtractPalette(t *testing.T) {
+	img := stripedImage(
+		color.RGBA{R: 10, G: 12, B: 30, A: 255},
+		color.RGBA{R: 200, G: 40, B: 40, A: 255},
+		color.RGBA{R: 40, G: 160, B: 60, A: 255},
+		color.RGBA{R: 40, G: 80, B: 200, A: 255},
+		color.RGBA{R: 240, G: 235, B: 220, A: 255},
+	)
+
+	palette := extractPalette(img)
+	if palette != extractPalette(img) {
+		t.Error("Expected the same image to give the same palette")
+	}
+
+	hexColor := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	for i, c := range palette.Colors {
+		if !hexColor.MatchString(c) {
+			t.Errorf("Expected color%d to be a hex color, but got '%s'", i, c)
+		}
+	}
+
+	background, foreground := rgbColor(palette.Background), rgbColor(palette.Foreground)
+	luminance := func(c [3]int) float64 { return rgb{float64(c[0]), float64(c[1]), float64(c[2])}.luminance() }
+	if luminance(background) > 40 || luminance(foreground) < 200 {
+		t.Errorf("Expected a dark background and a light foreground, but got '%s' and '%s'", palette.Background, palette.Foreground)
+	}
+	if palette.Colors[0] != palette.Background || palette.Colors[15] != palette.Foreground {
+		t.Errorf("Expected color0 and color15 to be the background and foreground, but got %v", palette.Colors)
+	}
+
+	// The accents are ordered by hue, so red comes before green and blue.
+	red := rgbColor(palette.Colors[1])
+	if red[0] < red[1] || red[0] < red[2] {
+		t.Errorf("Expected color1 to be red, but got '%s'", palette.Colors[1])
+	}
+}
+
+func TestExtractPaletteSingleColor(t *testing.T) {
+	palette := extractPalette(stripedImage(color.RGBA{R: 90, G: 90, B: 90, A: 255}))
+	for i, c := range palette.Colors {
+		if c == "" {
+			t.Errorf("Expected color%d to be padded, but got an empty color", i)
+		}
+	}
+}
+
+func TestWritePaletteGolden(t *testing.T) {
+	palette := Palette{Wallpaper: "/images/it's.jpg", Background: "#101218", Foreground: "#f0ece4", Cursor: "#f0ece4"}
+	for i := range palette.Colors {
+		palette.Colors[i] = rgb{float64(i * 16), float64(255 - i*16), float64(i * 8)}.hex()
+	}
+
+	palettePath := t.TempDir()
+	if err := writePalette(palettePath, palette); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	fileNames := []string{"colors.json"}
+	for _, export := range paletteExports {
+		fileNames = append(fileNames, export.fileName)
+	}
+	for _, fileName := range fileNames {
+		t.Run(fileName, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(palettePath, fileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			goldenFile := filepath.Join("..", "test", "testData", "palette", fileName)
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(goldenFile), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenFile, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			exp, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("Error reading golden file, run 'go test ./internal -run TestWritePaletteGolden -update': %v", err)
+			}
+			if string(got) != string(exp) {
+				t.Errorf("Expected:\n%s\nbut got:\n%s", exp, got)
+			}
+		})
+	}
+}
+
+func TestUpdatePalette(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	originalPalette := viper.Get(paletteConfigKey)
+	defer viper.Set(paletteConfigKey, originalPalette)
+
+	wallpaper := filepath.Join(t.TempDir(), "wallpaper.png")
+	writeTestPNG(t, wallpaper, color.RGBA{R: 40, G: 80, B: 200, A: 255})
+
+	palettePath, err := getPalettePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set(paletteConfigKey, PaletteOptions{})
+	if err := updatePalette(wallpaper); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if _, err := os.Stat(filepath.Join(palettePath, "colors.json")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no palette while disabled, but got '%v'", err)
+	}
+
+	hookOutput := filepath.Join(t.TempDir(), "hook")
+	viper.Set(paletteConfigKey, PaletteOptions{Enabled: true, Hook: `printf '%s' "$BACKDROP_WALLPAPER" > ` + hookOutput})
+	if err := updatePalette(wallpaper); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if _, err := os.Stat(filepath.Join(palettePath, "colors-kitty.conf")); err != nil {
+		t.Errorf("Expected the palette to be written, but got '%v'", err)
+	}
+	if got, _ := os.ReadFile(hookOutput); string(got) != wallpaper {
+		t.Errorf("Expected the hook to get wallpaper '%s', but got '%s'", wallpaper, got)
+	}
+
+	// Slideshows and files that aren't images keep the palette.
+	notAnImage := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(notAnImage, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, wallpaper := range []string{filepath.Join(t.TempDir(), "backdrop_settings.xml"), notAnImage} {
+		var out bytes.Buffer
+		applyPalette(&out, wallpaper, "")
+		if out.Len() > 0 {
+			t.Errorf("Expected no palette warning for '%s', but got '%s'", wallpaper, out.String())
+		}
+	}
+
+	// A failing hook is reported on out, the wallpaper is set anyway.
+	viper.Set(paletteConfigKey, PaletteOptions{Enabled: true, Hook: "exit 3"})
+	var out bytes.Buffer
+	applyPalette(&out, wallpaper, "")
+	if !strings.Contains(out.String(), "Could not update the palette: palette hook 'exit 3' failed") {
+		t.Errorf("Expected the hook failure on out, but got '%s'", out.String())
+	}
+}
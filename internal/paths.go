@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+// getRuntimePath returns the directory for backdrop's pidfile and sockets.
+func getRuntimePath() (string, error) {
+	runtimePath := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimePath == "" {
+		runtimePath = filepath.Join(os.TempDir(), fmt.Sprintf("backdrop-%d", os.Getuid()))
+	} else {
+		runtimePath = filepath.Join(runtimePath, "backdrop")
+	}
+
+	if err := os.MkdirAll(runtimePath, 0700); err != nil {
+		return "", fmt.Errorf("failed to create runtime directory %s: %w", runtimePath, err)
+	}
+
+	return runtimePath, nil
+}
+
+// getStatePath returns the directory for backdrop's history and other state.
+func getStatePath() (string, error) {
+	var statePath string
+	switch runtime.GOOS {
+	case "windows":
+		// %APPDATA%\Backdrop doubles as an images path, keep state out of it.
+		statePath = filepath.Join(os.Getenv("LOCALAPPDATA"), "Backdrop")
+	default:
+		var err error
+		if statePath, err = os_Specifics.GetLinuxStatePath(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(statePath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create state directory %s: %w", statePath, err)
+	}
+
+	return statePath, nil
+}
+
+// getCachePath returns a directory under backdrop's cache for generated files
+// such as thumbnails, they can be deleted at any time.
+func getCachePath(name string) (string, error) {
+	cachePath, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	cachePath = filepath.Join(cachePath, "backdrop", name)
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory %s: %w", cachePath, err)
+	}
+
+	return cachePath, nil
+}
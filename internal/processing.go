@@ -0,0 +1,456 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	processingConfigKey = "Processing"
+	processedQuality    = 92
+	// edgeSampleSize is the longest side of the copy used to find the focus of an image.
+	edgeSampleSize = 256
+)
+
+var CropModes = []string{"center", "thirds", "entropy"}
+
+// ProcessingOptions turn on scaling and cropping images to the output resolution
+// before they are applied. An empty resolution is detected from the outputs.
+type ProcessingOptions struct {
+	Crop       string `mapstructure:"crop" yaml:"crop,omitempty" json:"crop,omitempty"`
+	Resolution string `mapstructure:"resolution" yaml:"resolution,omitempty" json:"resolution,omitempty"`
+}
+
+func (o ProcessingOptions) Enabled() bool {
+	return o.Crop != ""
+}
+
+func (o ProcessingOptions) validate() error {
+	if o.Crop != "" && !slices.Contains(CropModes, o.Crop) {
+		return fmt.Errorf("%w : crop '%s', expected one of %v", ErrInvalidProcessing, o.Crop, CropModes)
+	}
+	if o.Resolution != "" {
+		if _, err := parseResolution(o.Resolution); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getProcessingOptions() (ProcessingOptions, error) {
+	var opts ProcessingOptions
+	if err := viper.UnmarshalKey(processingConfigKey, &opts); err != nil {
+		return ProcessingOptions{}, fmt.Errorf("%w : %v", ErrInvalidProcessing, err)
+	}
+	return opts, opts.validate()
+}
+
+func EnableProcessing(out io.Writer, opts ProcessingOptions) error {
+	if opts.Crop == "" {
+		opts.Crop = CropModes[0]
+	}
+	if opts.Resolution == "auto" {
+		opts.Resolution = ""
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	viper.Set(processingConfigKey, opts)
+	if err := writeConfig(); err != nil {
+		return err
+	}
+
+	resolution := opts.Resolution
+	if resolution == "" {
+		resolution = "the resolution of each output"
+	}
+	fmt.Fprintf(out, "Images will be cropped (%s) and scaled to %s before they are applied.\n", opts.Crop, resolution)
+	return nil
+}
+
+func DisableProcessing(out io.Writer) error {
+	viper.Set(processingConfigKey, ProcessingOptions{})
+	if err := writeConfig(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "Images will be applied as they are.")
+	return nil
+}
+
+func ProcessingStatus(out io.Writer) error {
+	opts, err := getProcessingOptions()
+	if err != nil {
+		return err
+	}
+
+	if !opts.Enabled() {
+		fmt.Fprintln(out, "Processing is disabled, enable it with 'backdrop process enable'.")
+		return nil
+	}
+	fmt.Fprintf(out, "Crop:\t%s\n", opts.Crop)
+
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+
+	outputs, err := backend.Outputs()
+	if err != nil {
+		return err
+	}
+
+	for _, output := range outputs {
+		name := output
+		if name == allOutputs {
+			name = "all outputs"
+		}
+
+		size, err := targetResolution(backend, output, opts)
+		if err != nil {
+			fmt.Fprintf(out, "%s:\tunknown resolution, images are applied as they are (%v)\n", name, err)
+			continue
+		}
+		fmt.Fprintf(out, "%s:\t%dx%d\n", name, size.X, size.Y)
+	}
+	return nil
+}
+
+// parseResolution parses sizes such as "2560x1440".
+func parseResolution(resolution string) (image.Point, error) {
+	width, height, found := strings.Cut(strings.ToLower(strings.TrimSpace(resolution)), "x")
+	x, widthErr := strconv.Atoi(width)
+	y, heightErr := strconv.Atoi(height)
+	if !found || widthErr != nil || heightErr != nil || x <= 0 || y <= 0 {
+		return image.Point{}, fmt.Errorf("%w : resolution '%s', expected WIDTHxHEIGHT", ErrInvalidProcessing, resolution)
+	}
+	return image.Pt(x, y), nil
+}
+
+// resolutionBackend is implemented by backends that know the size of each output.
+type resolutionBackend interface {
+	OutputResolution(output string) (image.Point, error)
+}
+
+var getDisplayResolution = detectDisplayResolution
+
+func targetResolution(backend wallpaperBackend, output string, opts ProcessingOptions) (image.Point, error) {
+	if opts.Resolution != "" {
+		return parseResolution(opts.Resolution)
+	}
+
+	if resolutionBackend, ok := backend.(resolutionBackend); ok {
+		return resolutionBackend.OutputResolution(output)
+	}
+	return getDisplayResolution()
+}
+
+// detectDisplayResolution returns the resolution of the primary, or else the
+// largest, display.
+func detectDisplayResolution() (image.Point, error) {
+	switch runtime.GOOS {
+	case "windows":
+		out, err := commandOutput("powershell", "-Command", `Add-Type -AssemblyName System.Windows.Forms; $b = [System.Windows.Forms.Screen]::PrimaryScreen.Bounds; "$($b.Width)x$($b.Height)"`)
+		if err != nil {
+			return image.Point{}, err
+		}
+		return parseResolution(out)
+	default:
+		out, err := commandOutput("xrandr", "--current")
+		if err != nil {
+			return image.Point{}, err
+		}
+		return parseXrandrResolution(out)
+	}
+}
+
+// parseXrandrResolution reads lines such as "eDP-1 connected primary 1920x1080+0+0 ...".
+func parseXrandrResolution(out string) (image.Point, error) {
+	var largest image.Point
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "connected" {
+			continue
+		}
+
+		for _, field := range fields[2:] {
+			geometry, _, found := strings.Cut(field, "+")
+			if !found {
+				continue
+			}
+
+			size, err := parseResolution(geometry)
+			if err != nil {
+				continue
+			}
+			if fields[2] == "primary" {
+				return size, nil
+			}
+			if size.X*size.Y > largest.X*largest.Y {
+				largest = size
+			}
+			break
+		}
+	}
+
+	if largest == (image.Point{}) {
+		return image.Point{}, errors.New("no active display found in xrandr output")
+	}
+	return largest, nil
+}
+
+// processWallpaper returns a copy of the image cropped and scaled to the output,
+// or the image itself when processing is off or not possible. Processed images are
+// cached by the contents of the source and the processing parameters.
+func processWallpaper(backend wallpaperBackend, output, wallpaper string) (string, error) {
+	opts, err := getProcessingOptions()
+	if err != nil || !opts.Enabled() || wallpaper == "" || strings.Contains(wallpaper, "://") {
+		return wallpaper, err
+	}
+
+	size, err := targetResolution(backend, output, opts)
+	if err != nil {
+		// Without a known resolution the desktop scales the image as it always did.
+		return wallpaper, nil
+	}
+
+	processedPath, err := getCachePath("processed")
+	if err != nil {
+		return "", err
+	}
+
+	key, err := contentCacheKey(wallpaper, opts.Crop, size)
+	if err != nil {
+		return "", err
+	}
+
+	processed := filepath.Join(processedPath, key+".jpg")
+	if _, err := os.Stat(processed); err == nil {
+		return processed, nil
+	}
+
+	img, err := decodeImageFile(wallpaper)
+	if errors.Is(err, ErrUnsupportedImage) {
+		return wallpaper, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Images that already fit the output need neither cropping nor scaling.
+	if bounds := img.Bounds(); cropRectangle(img, size, opts.Crop) == bounds && bounds.Dx() <= size.X {
+		return wallpaper, nil
+	}
+
+	if err := writeJPEG(processed, processImage(img, size, opts.Crop), processedQuality); err != nil {
+		return "", err
+	}
+	if err := writeDerivedSource(processed, wallpaper); err != nil {
+		return "", err
+	}
+	return processed, nil
+}
+
+// derivedCaches hold the images backdrop generates from the library, each one
+// remembers its source next to it.
+var derivedCaches = []string{"processed", "effects", "overlay"}
+
+func writeDerivedSource(derived, source string) error {
+	return os.WriteFile(derived+".source", []byte(source), 0644)
+}
+
+// sourceWallpaper returns the library image a processed copy was made from, other
+// images are returned as they are.
+func sourceWallpaper(wallpaper string) string {
+	for depth := 0; depth < len(derivedCaches); depth++ {
+		derived := false
+		for _, name := range derivedCaches {
+			cachePath, err := getCachePath(name)
+			if err == nil && filepath.Dir(wallpaper) == cachePath {
+				derived = true
+			}
+		}
+		if !derived {
+			break
+		}
+
+		source, err := os.ReadFile(wallpaper + ".source")
+		if err != nil {
+			break
+		}
+		wallpaper = string(source)
+	}
+	return wallpaper
+}
+
+// contentCacheKey is cacheKey for files whose path and modification time say
+// nothing about their contents, such as downloaded or copied images.
+func contentCacheKey(path string, params ...any) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	for _, param := range params {
+		fmt.Fprintf(hash, "|%v", param)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32], nil
+}
+
+// processImage crops the image to the aspect ratio of the size and scales it down
+// to it. Smaller images are only cropped, the desktop upscales them as before.
+func processImage(src image.Image, size image.Point, crop string) *image.RGBA {
+	rect := cropRectangle(src, size, crop)
+	cropped := toRGBA(src).SubImage(rect)
+	if rect.Dx() <= size.X {
+		return toRGBA(cropped)
+	}
+	return resizeImage(cropped, size.X, size.Y)
+}
+
+// cropRectangle returns the largest part of the image with the aspect ratio of the
+// size. Only one direction is ever cropped, the crop mode decides where.
+func cropRectangle(src image.Image, size image.Point, crop string) image.Rectangle {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	cropWidth, cropHeight := width, height
+	horizontal := width*size.Y > height*size.X
+	if horizontal {
+		cropWidth = max(height*size.X/size.Y, 1)
+	} else {
+		cropHeight = max(width*size.Y/size.X, 1)
+	}
+
+	length, cropLength := height, cropHeight
+	if horizontal {
+		length, cropLength = width, cropWidth
+	}
+
+	offset := (length - cropLength) / 2
+	if cropLength < length {
+		switch crop {
+		case "thirds":
+			offset = thirdsOffset(edgeProfile(src, horizontal), length, cropLength)
+		case "entropy":
+			offset = entropyOffset(edgeProfile(src, horizontal), length, cropLength)
+		}
+	}
+
+	if horizontal {
+		return image.Rect(offset, 0, offset+cropWidth, cropHeight).Add(bounds.Min)
+	}
+	return image.Rect(0, offset, cropWidth, offset+cropHeight).Add(bounds.Min)
+}
+
+// edgeProfile sums the edge strength of a small grayscale copy of the image for
+// every column, or every row when not horizontal. Detailed parts of a photo have
+// strong edges, skies and blurred backgrounds don't.
+func edgeProfile(src image.Image, horizontal bool) []float64 {
+	bounds := src.Bounds()
+	scale := float64(edgeSampleSize) / float64(max(bounds.Dx(), bounds.Dy()))
+	width := max(int(float64(bounds.Dx())*scale), 2)
+	height := max(int(float64(bounds.Dy())*scale), 2)
+	sample := resizeImage(src, width, height)
+
+	gray := make([]float64, width*height)
+	for i := range gray {
+		pixel := sample.Pix[i*4 : i*4+3]
+		gray[i] = 0.2126*float64(pixel[0]) + 0.7152*float64(pixel[1]) + 0.0722*float64(pixel[2])
+	}
+
+	profile := make([]float64, height)
+	if horizontal {
+		profile = make([]float64, width)
+	}
+	for y := 0; y < height-1; y++ {
+		for x := 0; x < width-1; x++ {
+			i := y*width + x
+			energy := abs(gray[i+1]-gray[i]) + abs(gray[i+width]-gray[i])
+			if horizontal {
+				profile[x] += energy
+			} else {
+				profile[y] += energy
+			}
+		}
+	}
+	return profile
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// entropyOffset slides the crop over the profile and keeps the position holding the
+// most detail, ties go to the position closest to the center.
+func entropyOffset(profile []float64, length, cropLength int) int {
+	n := len(profile)
+	window := min(max(cropLength*n/length, 1), n)
+
+	sums := make([]float64, n+1)
+	for i, energy := range profile {
+		sums[i+1] = sums[i] + energy
+	}
+
+	center := (n - window) / 2
+	best := center
+	for start := 0; start+window <= n; start++ {
+		energy, bestEnergy := sums[start+window]-sums[start], sums[best+window]-sums[best]
+		closer := absInt(start-center) < absInt(best-center)
+		if energy > bestEnergy || (energy == bestEnergy && closer) {
+			best = start
+		}
+	}
+
+	return clampOffset(best*length/n, length, cropLength)
+}
+
+// thirdsOffset puts the center of detail on the nearest third line of the crop.
+func thirdsOffset(profile []float64, length, cropLength int) int {
+	var total, weighted float64
+	for i, energy := range profile {
+		total += energy
+		weighted += (float64(i) + 0.5) * energy
+	}
+	if total == 0 {
+		return (length - cropLength) / 2
+	}
+
+	focus := int(weighted / total * float64(length) / float64(len(profile)))
+	if focus < length/2 {
+		return clampOffset(focus-cropLength/3, length, cropLength)
+	}
+	return clampOffset(focus-cropLength*2/3, length, cropLength)
+}
+
+func clampOffset(offset, length, cropLength int) int {
+	return min(max(offset, 0), length-cropLength)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
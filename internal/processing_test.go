@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseResolution(t *testing.T) {
+	testCases := []struct {
+		resolution string
+		exp        image.Point
+		expErr     error
+	}{
+		{resolution: "2560x1440", exp: image.Pt(2560, 1440)},
+		{resolution: " 1920X1080\n", exp: image.Pt(1920, 1080)},
+		{resolution: "1920", expErr: ErrInvalidProcessing},
+		{resolution: "0x1080", expErr: ErrInvalidProcessing},
+		{resolution: "widexhigh", expErr: ErrInvalidProcessing},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.resolution, func(t *testing.T) {
+			got, err := parseResolution(testCase.resolution)
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+			if got != testCase.exp {
+				t.Errorf("Expected resolution '%v', but got '%v' instead", testCase.exp, got)
+			}
+		})
+	}
+}
+
+func TestParseXrandrResolution(t *testing.T) {
+	const xrandr = `Screen 0: minimum 320 x 200, current 4480 x 1440, maximum 16384 x 16384
+eDP-1 connected 1920x1080+2560+0 (normal left inverted right x axis y axis) 309mm x 174mm
+   1920x1080     60.05*+
+HDMI-1 connected primary 2560x1440+0+0 (normal left inverted right x axis y axis) 597mm x 336mm
+   2560x1440     59.95*+
+DP-1 disconnected (normal left inverted right x axis y axis)
+`
+	got, err := parseXrandrResolution(xrandr)
+	if err != nil || got != image.Pt(2560, 1440) {
+		t.Errorf("Expected the primary display 2560x1440, but got '%v' and '%v'", got, err)
+	}
+
+	if _, err := parseXrandrResolution("Screen 0: minimum 320 x 200\n"); err == nil {
+		t.Error("Expected an error without connected displays, but got none")
+	}
+}
+
+// detailedImage is a flat gray image with a checkerboard between from and to along
+// the long side.
+func detailedImage(width, height, from, to int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			position := x
+			if height > width {
+				position = y
+			}
+
+			c := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+			if position >= from && position < to && (x/4+y/4)%2 == 0 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCropRectangle(t *testing.T) {
+	testCases := []struct {
+		name string
+		img  image.Image
+		size image.Point
+		crop string
+		exp  image.Rectangle
+	}{
+		{name: "Center", img: detailedImage(400, 100, 280, 340), size: image.Pt(100, 100), crop: "center", exp: image.Rect(150, 0, 250, 100)},
+		{name: "Thirds", img: detailedImage(400, 100, 280, 340), size: image.Pt(100, 100), crop: "thirds", exp: image.Rect(243, 0, 343, 100)},
+		{name: "Entropy", img: detailedImage(400, 100, 280, 340), size: image.Pt(100, 100), crop: "entropy", exp: image.Rect(240, 0, 340, 100)},
+		{name: "EntropyVertical", img: detailedImage(100, 400, 20, 80), size: image.Pt(200, 100), crop: "entropy", exp: image.Rect(0, 26, 100, 76)},
+		{name: "SameAspectRatio", img: detailedImage(400, 200, 0, 0), size: image.Pt(200, 100), crop: "entropy", exp: image.Rect(0, 0, 400, 200)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := cropRectangle(testCase.img, testCase.size, testCase.crop)
+			if got != testCase.exp {
+				t.Errorf("Expected crop '%v', but got '%v' instead", testCase.exp, got)
+			}
+		})
+	}
+}
+
+func TestProcessWallpaper(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() { viper.Set(processingConfigKey, nil) })
+
+	wallpaper := filepath.Join(t.TempDir(), "wide.png")
+	file, err := os.Create(wallpaper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, detailedImage(400, 100, 280, 340)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	backend := newFakeBackend(allOutputs)
+	if got, err := processWallpaper(backend, allOutputs, wallpaper); err != nil || got != wallpaper {
+		t.Fatalf("Expected the original image while disabled, but got '%s' and '%v'", got, err)
+	}
+
+	viper.Set(processingConfigKey, ProcessingOptions{Crop: "entropy", Resolution: "50x50"})
+	processed, err := processWallpaper(backend, allOutputs, wallpaper)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if processed == wallpaper {
+		t.Fatal("Expected a processed copy, but got the original image")
+	}
+
+	img, err := decodeImageFile(processed)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(50, 50) {
+		t.Errorf("Expected a 50x50 image, but got '%v' instead", size)
+	}
+
+	if cached, err := processWallpaper(backend, allOutputs, wallpaper); err != nil || cached != processed {
+		t.Errorf("Expected cached copy '%s', but got '%s' and '%v'", processed, cached, err)
+	}
+	if source := sourceWallpaper(processed); source != wallpaper {
+		t.Errorf("Expected source '%s', but got '%s' instead", wallpaper, source)
+	}
+
+	viper.Set(processingConfigKey, ProcessingOptions{Crop: "diagonal"})
+	if _, err := processWallpaper(backend, allOutputs, wallpaper); !errors.Is(err, ErrInvalidProcessing) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidProcessing, err)
+	}
+}
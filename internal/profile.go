@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const activeProfileConfigKey = "ActiveProfile"
+
+// profileScheduleName is the schedule installed for the active profile, using another
+// profile replaces it.
+const profileScheduleName = "profile"
+
+// Profile is a named set of settings switched together, e.g. office and home images.
+type Profile struct {
+	// Paths replace WallpapersPath and LibraryRoots while the profile is active, written
+	// like LibraryRoots.
+	Paths []string `mapstructure:"Paths" yaml:"Paths,omitempty" json:"Paths,omitempty"`
+	// Tags limit the library to images tagged with at least one of them.
+	Tags []string `mapstructure:"Tags" yaml:"Tags,omitempty" json:"Tags,omitempty"`
+	// Fit and Effects are used for images without their own.
+	Fit     Fit    `mapstructure:"Fit" yaml:"Fit,omitempty" json:"Fit,omitempty"`
+	Effects string `mapstructure:"Effects" yaml:"Effects,omitempty" json:"Effects,omitempty"`
+	// SlideShow is a saved slideshow applied when the profile is used.
+	SlideShow string          `mapstructure:"SlideShow" yaml:"SlideShow,omitempty" json:"SlideShow,omitempty"`
+	Schedule  ProfileSchedule `mapstructure:"Schedule" yaml:"Schedule,omitempty" json:"Schedule,omitempty"`
+}
+
+// ProfileSchedule is installed as a schedule when the profile is used, its fields are
+// the flags of 'backdrop schedule install'.
+type ProfileSchedule struct {
+	Every string `mapstructure:"Every" yaml:"Every,omitempty" json:"Every,omitempty"`
+	Daily string `mapstructure:"Daily" yaml:"Daily,omitempty" json:"Daily,omitempty"`
+	Mode  string `mapstructure:"Mode" yaml:"Mode,omitempty" json:"Mode,omitempty"`
+}
+
+func (s ProfileSchedule) isZero() bool {
+	return s == ProfileSchedule{}
+}
+
+func (s ProfileSchedule) options() (*ScheduleOptions, error) {
+	opts := &ScheduleOptions{Name: profileScheduleName, Daily: s.Daily, Mode: s.Mode}
+	if opts.Mode == "" {
+		opts.Mode = "random"
+	}
+	if s.Every != "" {
+		every, err := time.ParseDuration(s.Every)
+		if err != nil {
+			return nil, fmt.Errorf("%w : every expects a duration like 30m, got '%s'", ErrInvalidSchedule, s.Every)
+		}
+		opts.Every = every
+	}
+	return opts, opts.validate()
+}
+
+func (p Profile) validate(slideShows []SavedSlideShow) error {
+	for _, path := range p.Paths {
+		if strings.TrimSpace(path) == "" {
+			return fmt.Errorf("Paths can't contain empty paths")
+		}
+	}
+	if _, err := parseTags(p.Tags); err != nil {
+		return err
+	}
+	if err := validateFit(p.Fit); err != nil {
+		return err
+	}
+	if _, err := ParseEffects(p.Effects); err != nil {
+		return err
+	}
+
+	if p.SlideShow != "" && !slices.ContainsFunc(slideShows, func(slideShow SavedSlideShow) bool {
+		return strings.EqualFold(slideShow.Name, p.SlideShow)
+	}) {
+		return fmt.Errorf("%w : %s", ErrSlideShowNotFound, p.SlideShow)
+	}
+
+	if !p.Schedule.isZero() {
+		if _, err := p.Schedule.options(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileOverride is the profile chosen with --profile, it is used for this run only.
+var profileOverride string
+
+// SetProfile uses the profile for this run instead of the one chosen with 'backdrop
+// profile use'.
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// findProfile looks the profile up without case, viper writes the names in lower case.
+func findProfile(settings Settings, name string) (string, Profile, error) {
+	for profileName, profile := range settings.Profiles {
+		if strings.EqualFold(profileName, name) {
+			return profileName, profile, nil
+		}
+	}
+	return "", Profile{}, fmt.Errorf("%w : %s", ErrProfileNotFound, name)
+}
+
+// settingsProfile returns the profile in use, an empty name means none is.
+func settingsProfile(settings Settings) (string, Profile, error) {
+	name := profileOverride
+	if name == "" {
+		name = settings.ActiveProfile
+	}
+	if name == "" {
+		return "", Profile{}, nil
+	}
+	return findProfile(settings, name)
+}
+
+func activeProfile() (string, Profile, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return "", Profile{}, err
+	}
+	return settingsProfile(settings)
+}
+
+// profileImageFilter returns whether a library image is part of the active profile,
+// only profiles with tags leave images out.
+func profileImageFilter() (func(path string) bool, error) {
+	_, profile, err := activeProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := parseTags(profile.Tags)
+	if err != nil || len(tags) == 0 {
+		return func(string) bool { return true }, err
+	}
+
+	metadata, err := loadMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return func(path string) bool { return hasAnyTag(metadata[path], tags) }, nil
+}
+
+// UseProfile makes the profile the active one. Its schedule replaces the previous
+// profile's, then its slideshow is applied, or a random image of its library when it
+// has none.
+func UseProfile(out io.Writer, name string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	name, profile, err := findProfile(settings, name)
+	if err != nil {
+		return err
+	}
+	_, previous, _ := findProfile(settings, settings.ActiveProfile)
+
+	settings.ActiveProfile = name
+	if err := saveSettings(settings, activeProfileConfigKey); err != nil {
+		return err
+	}
+	profileOverride = name
+	fmt.Fprintf(out, "Using profile '%s'.\n", name)
+
+	if err := switchProfileSchedule(out, previous, profile); err != nil {
+		return err
+	}
+
+	if profile.SlideShow != "" {
+		return UseSlideShow(out, profile.SlideShow)
+	}
+
+	image, err := setRandomWallpaper(out, historySourceProfile)
+	if errors.Is(err, ErrEmptyPlaylist) {
+		fmt.Fprintln(out, "The profile has no images to show, the wallpaper was kept.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Set %s.\n", image)
+	return nil
+}
+
+// ClearProfile stops using profiles, the settings outside of them apply again.
+func ClearProfile(out io.Writer) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	if settings.ActiveProfile == "" {
+		fmt.Fprintln(out, "No profile is in use.")
+		return nil
+	}
+	_, previous, _ := findProfile(settings, settings.ActiveProfile)
+
+	settings.ActiveProfile = ""
+	if err := saveSettings(settings, activeProfileConfigKey); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "No profile is in use anymore.")
+
+	return switchProfileSchedule(out, previous, Profile{})
+}
+
+func switchProfileSchedule(out io.Writer, previous, next Profile) error {
+	if next.Schedule.isZero() {
+		if previous.Schedule.isZero() {
+			return nil
+		}
+		return RemoveSchedule(out, profileScheduleName)
+	}
+
+	opts, err := next.Schedule.options()
+	if err != nil {
+		return err
+	}
+	return InstallSchedule(out, opts)
+}
+
+func ListProfiles(out io.Writer) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	if len(settings.Profiles) == 0 {
+		fmt.Fprintln(out, "No profiles defined, add them under 'Profiles' with 'backdrop config edit'.")
+		return nil
+	}
+	active, _, _ := settingsProfile(settings)
+
+	names := make([]string, 0, len(settings.Profiles))
+	for name := range settings.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tPATHS\tTAGS\tSLIDESHOW")
+	for _, name := range names {
+		profile := settings.Profiles[name]
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\n", marker, name, orDash(strings.Join(profile.Paths, ", ")), orDash(strings.Join(profile.Tags, ", ")), orDash(profile.SlideShow))
+	}
+	return w.Flush()
+}
+
+// ShowProfile prints the settings of the profile, or of the active one without a name.
+func ShowProfile(out io.Writer, name string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+
+	var profile Profile
+	if name == "" {
+		name, profile, err = settingsProfile(settings)
+		if name == "" && err == nil {
+			fmt.Fprintln(out, "No profile is in use.")
+			return nil
+		}
+	} else {
+		name, profile, err = findProfile(settings, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(map[string]Profile{name: profile})
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(content)
+	return err
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
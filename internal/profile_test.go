@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// setupProfiles creates an office and a home library, with one office image tagged
+// "neutral", and a "work" profile for the office images with that tag.
+func setupProfiles(t *testing.T) (office, home string) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(imagePathEnv, "")
+	original := viper.AllSettings()
+	t.Cleanup(func() {
+		for _, key := range []string{wallpapersPathConfigKey, activeProfileConfigKey, "Profiles"} {
+			viper.Set(key, original[key])
+		}
+		SetProfile("")
+	})
+
+	office, home = t.TempDir(), t.TempDir()
+	for _, image := range []string{filepath.Join(office, "logo.png"), filepath.Join(office, "party.jpg"), filepath.Join(home, "lake.jpg")} {
+		if err := os.WriteFile(image, []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := updateImageMetadata(filepath.Join(office, "logo.png"), func(metadata *ImageMetadata) {
+		metadata.Tags = []string{"neutral"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set(wallpapersPathConfigKey, home)
+	viper.Set(activeProfileConfigKey, "")
+	viper.Set("Profiles", map[string]any{
+		"work": map[string]any{
+			"Paths": []string{office},
+			"Tags":  []string{"neutral"},
+			"Fit":   map[string]any{"mode": "zoom"},
+		},
+	})
+	return office, home
+}
+
+func TestProfileLibrary(t *testing.T) {
+	office, _ := setupProfiles(t)
+
+	testCases := []struct {
+		name      string
+		profile   string
+		expImages []string
+		expErr    error
+	}{
+		{name: "NoProfile", expImages: []string{"lake.jpg"}},
+		{name: "Work", profile: "work", expImages: []string{"logo.png"}},
+		{name: "NamesWithoutCase", profile: "Work", expImages: []string{"logo.png"}},
+		{name: "Unknown", profile: "home", expErr: ErrProfileNotFound},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			SetProfile(testCase.profile)
+
+			images, err := getLibraryWallpapers()
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+			if !slices.Equal(images, testCase.expImages) {
+				t.Errorf("Expected images %v, but got %v instead", testCase.expImages, images)
+			}
+		})
+	}
+
+	SetProfile("work")
+	libraryImages, err := getLibraryImages()
+	if err != nil || len(libraryImages) != 1 || libraryImages[0].Path != filepath.Join(office, "logo.png") {
+		t.Errorf("Expected the library to only list the tagged office image, but got %v, '%v'", libraryImages, err)
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	office, _ := setupProfiles(t)
+
+	backend := newFakeBackend(allOutputs)
+	originalGetBackend := getBackend
+	getBackend = func() (wallpaperBackend, error) { return backend, nil }
+	defer func() { getBackend = originalGetBackend }()
+
+	var out bytes.Buffer
+	if err := UseProfile(&out, "WORK"); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	image := filepath.Join(office, "logo.png")
+	backend.expect(t, fakeWallpaper{allOutputs, image})
+	select {
+	case fit := <-backend.fitted:
+		if fit != (Fit{Mode: "zoom"}) {
+			t.Errorf("Expected the profile's fit, but got '%+v' instead", fit)
+		}
+	default:
+		t.Error("Expected the profile's fit to be applied, but nothing was applied")
+	}
+
+	if got := viper.GetString(activeProfileConfigKey); got != "work" {
+		t.Errorf("Expected 'work' to be the active profile, but got '%s' instead", got)
+	}
+	SetProfile("")
+	if name, _, err := activeProfile(); name != "work" || err != nil {
+		t.Errorf("Expected 'work' to stay active, but got '%s', '%v'", name, err)
+	}
+
+	if err := UseProfile(&out, "home"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrProfileNotFound, err)
+	}
+
+	if err := ClearProfile(&out); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if name, _, err := activeProfile(); name != "" || err != nil {
+		t.Errorf("Expected no profile after clearing, but got '%s', '%v'", name, err)
+	}
+}
+
+func TestTagImage(t *testing.T) {
+	office, _ := setupProfiles(t)
+	viper.Set(wallpapersPathConfigKey, office)
+
+	var out bytes.Buffer
+	if err := TagImage(&out, "party.jpg", []string{"Fun", "neutral", "fun"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if err := UntagImage(&out, "logo.png", []string{"neutral"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	metadata, err := loadMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := metadata[filepath.Join(office, "party.jpg")].Tags; !slices.Equal(tags, []string{"fun", "neutral"}) {
+		t.Errorf("Expected tags [fun neutral], but got %v instead", tags)
+	}
+	if _, ok := metadata[filepath.Join(office, "logo.png")]; ok {
+		t.Error("Expected an image without tags to be dropped from the metadata")
+	}
+
+	if err := TagImage(&out, "party.jpg", []string{"two words"}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrInvalidTag, err)
+	}
+	if err := TagImage(&out, "missing.jpg", []string{"fun"}); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrImageNotFound, err)
+	}
+}
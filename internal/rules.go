@@ -0,0 +1,366 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/spf13/viper"
+)
+
+// ruleActions are what a rule can use, followed by a name.
+var ruleActions = []string{"profile", "slideshow"}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Rule uses a profile or a saved slideshow while all of its conditions hold, the
+// first matching rule wins.
+type Rule struct {
+	Name string         `mapstructure:"Name" yaml:"Name,omitempty" json:"Name,omitempty"`
+	When RuleConditions `mapstructure:"When" yaml:"When" json:"When"`
+	// Use is "profile <name>" or "slideshow <name>".
+	Use string `mapstructure:"Use" yaml:"Use" json:"Use"`
+}
+
+// RuleConditions must all hold for a rule to match, empty conditions always hold.
+type RuleConditions struct {
+	// SSID is the name of the connected Wi-Fi network.
+	SSID string `mapstructure:"SSID" yaml:"SSID,omitempty" json:"SSID,omitempty"`
+	// Outputs is the number of connected displays.
+	Outputs int `mapstructure:"Outputs" yaml:"Outputs,omitempty" json:"Outputs,omitempty"`
+	// Weekday is a day, a comma separated list or a range, e.g. "sat,sun" or "mon-fri".
+	Weekday string `mapstructure:"Weekday" yaml:"Weekday,omitempty" json:"Weekday,omitempty"`
+	// Time is a range of the day, e.g. "09:00-17:00", ranges past midnight wrap.
+	Time string `mapstructure:"Time" yaml:"Time,omitempty" json:"Time,omitempty"`
+}
+
+// RuleContext is what rules are evaluated against.
+type RuleContext struct {
+	SSID    string
+	Outputs int
+	Now     time.Time
+}
+
+func (r Rule) action() (string, string, error) {
+	action, name, _ := strings.Cut(strings.TrimSpace(r.Use), " ")
+	action, name = strings.ToLower(action), strings.TrimSpace(name)
+	if !slices.Contains(ruleActions, action) || name == "" {
+		return "", "", fmt.Errorf("%w : use must be one of %v followed by a name, got '%s'", ErrInvalidRule, ruleActions, r.Use)
+	}
+	return action, name, nil
+}
+
+func (r Rule) validate(settings Settings) error {
+	if _, err := parseWeekdays(r.When.Weekday); err != nil {
+		return err
+	}
+	if _, _, err := parseTimeRange(r.When.Time); err != nil {
+		return err
+	}
+	if r.When.Outputs < 0 {
+		return fmt.Errorf("%w : outputs can't be negative", ErrInvalidRule)
+	}
+
+	action, name, err := r.action()
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "profile":
+		if _, _, err := findProfile(settings, name); err != nil {
+			return err
+		}
+	case "slideshow":
+		if !slices.ContainsFunc(settings.SlideShows, func(slideShow SavedSlideShow) bool {
+			return strings.EqualFold(slideShow.Name, name)
+		}) {
+			return fmt.Errorf("%w : %s", ErrSlideShowNotFound, name)
+		}
+	}
+	return nil
+}
+
+// describe names the rule by its name, or by its position when it has none.
+func (r Rule) describe(index int) string {
+	if r.Name != "" {
+		return fmt.Sprintf("'%s'", r.Name)
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// parseWeekdays returns which days of the week are included, an empty spec includes
+// none of them.
+func parseWeekdays(spec string) ([7]bool, error) {
+	var days [7]bool
+	if strings.TrimSpace(spec) == "" {
+		return days, nil
+	}
+
+	weekday := func(name string) (int, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) >= 3 {
+			if index := slices.Index(weekdayNames, name[:3]); index >= 0 {
+				return index, nil
+			}
+		}
+		return 0, fmt.Errorf("%w : unknown weekday '%s', expected one of %v", ErrInvalidRule, name, weekdayNames)
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := weekday(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = weekday(to); err != nil {
+				return days, err
+			}
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseTimeRange returns the start and end of a range like "09:00-17:00" as minutes
+// since midnight.
+func parseTimeRange(spec string) (int, int, error) {
+	if strings.TrimSpace(spec) == "" {
+		return 0, 0, nil
+	}
+
+	from, to, ok := strings.Cut(spec, "-")
+	start, startErr := time.Parse("15:04", strings.TrimSpace(from))
+	end, endErr := time.Parse("15:04", strings.TrimSpace(to))
+	if !ok || startErr != nil || endErr != nil {
+		return 0, 0, fmt.Errorf("%w : time expects a range like 09:00-17:00, got '%s'", ErrInvalidRule, spec)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+func (c RuleConditions) matches(context RuleContext) bool {
+	if c.SSID != "" && c.SSID != context.SSID {
+		return false
+	}
+	if c.Outputs != 0 && c.Outputs != context.Outputs {
+		return false
+	}
+
+	if c.Weekday != "" {
+		days, err := parseWeekdays(c.Weekday)
+		if err != nil || !days[context.Now.Weekday()] {
+			return false
+		}
+	}
+
+	if c.Time != "" {
+		start, end, err := parseTimeRange(c.Time)
+		if err != nil {
+			return false
+		}
+		now := context.Now.Hour()*60 + context.Now.Minute()
+		if start <= end && (now < start || now >= end) {
+			return false
+		}
+		if start > end && now < start && now >= end {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRule returns the index of the first rule matching the context, or -1.
+func matchRule(rules []Rule, context RuleContext) int {
+	return slices.IndexFunc(rules, func(rule Rule) bool { return rule.When.matches(context) })
+}
+
+// loadRules re-reads the config file, so rules edited while watching are used.
+func loadRules() ([]Rule, error) {
+	if err := viper.ReadInConfig(); err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if len(settings.Rules) == 0 {
+		return nil, ErrNoRules
+	}
+	return settings.Rules, settings.validate()
+}
+
+func readRuleContext(clock clock) RuleContext {
+	// A missing network or display count only stops the rules that need them.
+	ssid, _ := getNetworkSSID()
+	outputs, _ := countOutputs()
+	return RuleContext{SSID: ssid, Outputs: outputs, Now: clock.Now()}
+}
+
+func applyRule(out io.Writer, rule Rule) error {
+	action, name, err := rule.action()
+	if err != nil {
+		return err
+	}
+	if action == "slideshow" {
+		return UseSlideShow(out, name)
+	}
+	return UseProfile(out, name)
+}
+
+// ruleWatcher applies a rule when it starts to match. A rule is applied once, so
+// changes made by hand stay until another rule matches.
+type ruleWatcher struct {
+	out     io.Writer
+	clock   clock
+	context func() RuleContext
+	apply   func(out io.Writer, rule Rule) error
+	applied *Rule
+}
+
+func newRuleWatcher(out io.Writer, clock clock) *ruleWatcher {
+	return &ruleWatcher{
+		out:     out,
+		clock:   clock,
+		context: func() RuleContext { return readRuleContext(clock) },
+		apply:   applyRule,
+	}
+}
+
+// run evaluates the rules every minute and whenever something is sent on changes.
+func (w *ruleWatcher) run(ctx context.Context, changes <-chan struct{}) {
+	ticker := w.clock.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		w.evaluate()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		case <-changes:
+		}
+	}
+}
+
+func (w *ruleWatcher) evaluate() {
+	rules, err := loadRules()
+	if err != nil {
+		fmt.Fprintf(w.out, "Could not load rules: %v\n", err)
+		return
+	}
+
+	index := matchRule(rules, w.context())
+	if index < 0 {
+		w.applied = nil
+		return
+	}
+
+	rule := rules[index]
+	if w.applied != nil && *w.applied == rule {
+		return
+	}
+	w.applied = &rule
+
+	fmt.Fprintf(w.out, "Rule %s matches, using %s.\n", rule.describe(index), rule.Use)
+	if err := w.apply(w.out, rule); err != nil {
+		fmt.Fprintf(w.out, "Could not apply rule %s: %v\n", rule.describe(index), err)
+	}
+}
+
+// WatchRules applies the rules from the config file until interrupted. The network is
+// watched through NetworkManager, displays are polled and time rules are checked every
+// minute.
+func WatchRules(out io.Writer) error {
+	if _, err := loadRules(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	if conn, err := dbus.ConnectSystemBus(); err != nil {
+		fmt.Fprintf(out, "D-Bus system bus not available, Wi-Fi changes are noticed within a minute: %v\n", err)
+	} else {
+		defer conn.Close()
+		go func() {
+			if err := watchNetwork(ctx, conn, notify); err != nil {
+				fmt.Fprintf(out, "Could not watch NetworkManager, Wi-Fi changes are noticed within a minute: %v\n", err)
+			}
+		}()
+	}
+	go watchOutputs(ctx, realClock{}, notify)
+
+	fmt.Fprintln(out, "Watching rules, press Ctrl+C to stop.")
+	newRuleWatcher(out, realClock{}).run(ctx, changes)
+	return nil
+}
+
+// CheckRules prints what the rules are evaluated against right now and which rule
+// would be used.
+func CheckRules(out io.Writer) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	if len(settings.Rules) == 0 {
+		return ErrNoRules
+	}
+
+	context := readRuleContext(realClock{})
+	ssid := context.SSID
+	if ssid == "" {
+		ssid = "-"
+	}
+	fmt.Fprintf(out, "Wi-Fi network:\t%s\n", ssid)
+	fmt.Fprintf(out, "Outputs:\t%d\n", context.Outputs)
+	fmt.Fprintf(out, "Time:\t\t%s\n\n", context.Now.Format("Mon 15:04"))
+
+	index := matchRule(settings.Rules, context)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tUSE\tSTATE")
+	for i, rule := range settings.Rules {
+		state := "-"
+		switch {
+		case i == index:
+			state = "used"
+		case rule.When.matches(context):
+			state = "matches, after the used rule"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", rule.describe(i), rule.Use, state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if index < 0 {
+		fmt.Fprintln(out, "\nNo rule matches right now.")
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	networkManagerServiceName = "org.freedesktop.NetworkManager"
+	networkManagerObjectPath  = "/org/freedesktop/NetworkManager"
+	wirelessConnectionType    = "802-11-wireless"
+)
+
+// outputsPollInterval is how often connected displays are counted, sysfs has no
+// notifications without udev.
+const outputsPollInterval = 5 * time.Second
+
+var (
+	getNetworkSSID = readNetworkManagerSSID
+	countOutputs   = countDRMOutputs
+)
+
+// readNetworkManagerSSID returns the SSID of the active Wi-Fi connection, or an empty
+// string without one.
+func readNetworkManagerSSID() (string, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return "", err
+	}
+
+	property := func(path dbus.ObjectPath, name string) (dbus.Variant, error) {
+		return conn.Object(networkManagerServiceName, path).GetProperty(networkManagerServiceName + "." + name)
+	}
+
+	value, err := property(networkManagerObjectPath, "ActiveConnections")
+	if err != nil {
+		return "", fmt.Errorf("failed to read active connections from NetworkManager: %w", err)
+	}
+	connections, _ := value.Value().([]dbus.ObjectPath)
+
+	for _, connection := range connections {
+		if value, err := property(connection, "Connection.Active.Type"); err != nil || value.Value() != wirelessConnectionType {
+			continue
+		}
+
+		value, err := property(connection, "Connection.Active.Devices")
+		if err != nil {
+			continue
+		}
+		devices, _ := value.Value().([]dbus.ObjectPath)
+		for _, device := range devices {
+			value, err := property(device, "Device.Wireless.ActiveAccessPoint")
+			if err != nil {
+				continue
+			}
+			accessPoint, _ := value.Value().(dbus.ObjectPath)
+			if accessPoint == "" || accessPoint == "/" {
+				continue
+			}
+
+			if value, err := property(accessPoint, "AccessPoint.Ssid"); err == nil {
+				ssid, _ := value.Value().([]byte)
+				return string(ssid), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// watchNetwork calls onChange whenever NetworkManager's connections change, until the
+// context is canceled.
+func watchNetwork(ctx context.Context, conn *dbus.Conn, onChange func()) error {
+	options := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(networkManagerObjectPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, networkManagerServiceName),
+	}
+	if err := conn.AddMatchSignal(options...); err != nil {
+		return err
+	}
+	defer conn.RemoveMatchSignal(options...)
+
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case signal := <-signals:
+			if signal.Path != networkManagerObjectPath || len(signal.Body) < 2 {
+				continue
+			}
+			changed, _ := signal.Body[1].(map[string]dbus.Variant)
+			if _, ok := changed["ActiveConnections"]; ok {
+				onChange()
+			} else if _, ok := changed["PrimaryConnection"]; ok {
+				onChange()
+			}
+		}
+	}
+}
+
+// countDRMOutputs counts the connected displays the kernel knows about, whatever the
+// desktop.
+func countDRMOutputs() (int, error) {
+	if runtime.GOOS != "linux" {
+		return 0, fmt.Errorf("counting outputs is not supported on %s", runtime.GOOS)
+	}
+
+	statuses, err := filepath.Glob("/sys/class/drm/card*-*/status")
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, status := range statuses {
+		content, err := os.ReadFile(status)
+		if err == nil && strings.TrimSpace(string(content)) == "connected" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// watchOutputs calls onChange whenever the number of connected displays changes, until
+// the context is canceled.
+func watchOutputs(ctx context.Context, clock clock, onChange func()) {
+	ticker := clock.NewTicker(outputsPollInterval)
+	defer ticker.Stop()
+
+	last, _ := countOutputs()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+			if count, err := countOutputs(); err == nil && count != last {
+				last = count
+				onChange()
+			}
+		}
+	}
+}
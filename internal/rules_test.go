@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestMatchRule(t *testing.T) {
+	// Monday the 1st of January 2024, 08:00.
+	monday := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	rules := []Rule{
+		{Name: "office", When: RuleConditions{SSID: "CorpWiFi", Weekday: "mon-fri"}, Use: "profile work"},
+		{Name: "desk", When: RuleConditions{Outputs: 3}, Use: "slideshow desk"},
+		{Name: "night", When: RuleConditions{Time: "22:00-06:00"}, Use: "profile night"},
+		{Name: "weekend", When: RuleConditions{Weekday: "sat,sun"}, Use: "profile home"},
+	}
+
+	testCases := []struct {
+		name     string
+		context  RuleContext
+		expIndex int
+	}{
+		{name: "Office", context: RuleContext{SSID: "CorpWiFi", Outputs: 3, Now: monday}, expIndex: 0},
+		{name: "OfficeOnWeekend", context: RuleContext{SSID: "CorpWiFi", Now: monday.AddDate(0, 0, 5)}, expIndex: 3},
+		{name: "Outputs", context: RuleContext{SSID: "Home", Outputs: 3, Now: monday}, expIndex: 1},
+		{name: "NightWrapsMidnight", context: RuleContext{Now: monday.Add(-3 * time.Hour)}, expIndex: 2},
+		{name: "NightEndsAtSix", context: RuleContext{Now: monday.Add(-2 * time.Hour)}, expIndex: -1},
+		{name: "Nothing", context: RuleContext{SSID: "Cafe", Outputs: 1, Now: monday}, expIndex: -1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := matchRule(rules, testCase.context); got != testCase.expIndex {
+				t.Errorf("Expected rule %d to match, but got %d instead", testCase.expIndex, got)
+			}
+		})
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	testCases := []struct {
+		spec    string
+		expDays [7]bool
+		expErr  error
+	}{
+		{spec: "sat", expDays: [7]bool{6: true}},
+		{spec: "Saturday, sun", expDays: [7]bool{0: true, 6: true}},
+		{spec: "mon-fri", expDays: [7]bool{1: true, 2: true, 3: true, 4: true, 5: true}},
+		{spec: "fri-mon", expDays: [7]bool{0: true, 1: true, 5: true, 6: true}},
+		{spec: "someday", expErr: ErrInvalidRule},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.spec, func(t *testing.T) {
+			days, err := parseWeekdays(testCase.spec)
+			if !errors.Is(err, testCase.expErr) {
+				t.Fatalf("Expected error '%v', but got '%v' instead", testCase.expErr, err)
+			}
+			if err == nil && days != testCase.expDays {
+				t.Errorf("Expected days %v, but got %v instead", testCase.expDays, days)
+			}
+		})
+	}
+}
+
+func TestRuleWatcher(t *testing.T) {
+	original := viper.Get("Rules")
+	defer viper.Set("Rules", original)
+	viper.Set("Rules", []map[string]any{
+		{"When": map[string]any{"SSID": "CorpWiFi"}, "Use": "slideshow office"},
+	})
+	originalSlideShows := viper.Get("SlideShows")
+	defer viper.Set("SlideShows", originalSlideShows)
+	viper.Set("SlideShows", []map[string]any{{"name": "office", "images": []string{"/images/logo.png"}, "duration": 60}})
+
+	var mu sync.Mutex
+	ssid := "Home"
+	var applied []string
+
+	clock := newFakeClock()
+	watcher := newRuleWatcher(io.Discard, clock)
+	watcher.context = func() RuleContext {
+		mu.Lock()
+		defer mu.Unlock()
+		return RuleContext{SSID: ssid, Now: clock.Now()}
+	}
+	watcher.apply = func(out io.Writer, rule Rule) error {
+		mu.Lock()
+		defer mu.Unlock()
+		applied = append(applied, rule.Use)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.run(ctx, changes)
+	}()
+
+	setSSID := func(value string) {
+		mu.Lock()
+		ssid = value
+		mu.Unlock()
+		changes <- struct{}{}
+	}
+	setSSID("CorpWiFi")
+	// A rule that keeps matching is only applied once.
+	setSSID("CorpWiFi")
+	setSSID("Home")
+	setSSID("CorpWiFi")
+	cancel()
+	<-done
+
+	if len(applied) != 2 {
+		t.Errorf("Expected the rule to be applied twice, once per time it started to match, but got %v", applied)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	originalRules, originalProfiles := viper.Get("Rules"), viper.Get("Profiles")
+	defer func() {
+		viper.Set("Rules", originalRules)
+		viper.Set("Profiles", originalProfiles)
+	}()
+	originalSSID, originalCount := getNetworkSSID, countOutputs
+	defer func() { getNetworkSSID, countOutputs = originalSSID, originalCount }()
+	getNetworkSSID = func() (string, error) { return "CorpWiFi", nil }
+	countOutputs = func() (int, error) { return 2, nil }
+
+	var out bytes.Buffer
+	viper.Set("Rules", nil)
+	if err := CheckRules(&out); !errors.Is(err, ErrNoRules) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrNoRules, err)
+	}
+
+	viper.Set("Profiles", map[string]any{"work": map[string]any{}})
+	viper.Set("Rules", []map[string]any{
+		{"Name": "desk", "When": map[string]any{"Outputs": 3}, "Use": "profile work"},
+		{"Name": "office", "When": map[string]any{"SSID": "CorpWiFi"}, "Use": "profile work"},
+	})
+	if err := CheckRules(&out); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	for _, exp := range []string{"CorpWiFi", "'office'  profile work  used", "'desk'    profile work  -"} {
+		if !bytes.Contains(out.Bytes(), []byte(exp)) {
+			t.Errorf("Expected the output to contain '%s', but got '%s'", exp, out.String())
+		}
+	}
+}
@@ -0,0 +1,538 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ScheduleModes are the ways a scheduled run picks the next wallpaper.
+var ScheduleModes = []string{"random", "next", "daily"}
+
+const (
+	scheduleUnitPrefix = "backdrop-"
+	cronMarkerPrefix   = "# backdrop-schedule:"
+)
+
+// cronEnvironment lists the variables a cron job needs to reach the desktop session,
+// systemd user units get them from the session instead.
+var cronEnvironment = []string{"DISPLAY", "WAYLAND_DISPLAY", "DBUS_SESSION_BUS_ADDRESS", "XDG_RUNTIME_DIR", "XDG_CURRENT_DESKTOP"}
+
+var scheduleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+var systemdAvailable = func() bool {
+	stat, err := os.Stat("/run/systemd/system")
+	return err == nil && stat.IsDir() && commandExist("systemctl")
+}
+
+var serviceTemplate = template.Must(template.New("service").Parse(`[Unit]
+Description={{.UnitDescription}}
+After=graphical-session.target
+
+[Service]
+Type=oneshot
+ExecStart={{.ExecStart}}
+`))
+
+var timerTemplate = template.Must(template.New("timer").Parse(`[Unit]
+Description={{.UnitDescription}}
+
+[Timer]
+{{- if .Daily}}
+OnCalendar=*-*-* {{.Daily}}:00
+Persistent=true
+{{- else}}
+OnStartupSec=1min
+OnUnitActiveSec={{.EverySeconds}}s
+{{- end}}
+Unit={{.UnitName}}.service
+
+[Install]
+WantedBy=timers.target
+`))
+
+var cronTemplate = template.Must(template.New("cron").Parse(`{{.CronMarker}} {{.Description}}
+{{.CronSpec}} {{.CronCommand}}
+`))
+
+type ScheduleOptions struct {
+	Name      string
+	Every     time.Duration
+	Daily     string
+	Mode      string
+	SlideShow string
+}
+
+// scheduleUnit holds everything the unit and cron templates need.
+type scheduleUnit struct {
+	Name        string
+	Executable  string
+	Mode        string
+	SlideShow   string
+	Every       time.Duration
+	Daily       string
+	Environment []string
+}
+
+// name returns the name of the schedule, which defaults to its mode.
+func (opts *ScheduleOptions) name() string {
+	if opts.Name == "" {
+		return opts.Mode
+	}
+	return opts.Name
+}
+
+func (opts *ScheduleOptions) validate() error {
+	if !scheduleNamePattern.MatchString(opts.name()) {
+		return fmt.Errorf("%w : name '%s' may only contain letters, digits, '-' and '_'", ErrInvalidSchedule, opts.name())
+	}
+
+	if !slices.Contains(ScheduleModes, opts.Mode) {
+		return fmt.Errorf("%w : mode must be one of %v", ErrInvalidSchedule, ScheduleModes)
+	}
+
+	if (opts.Every == 0) == (opts.Daily == "") {
+		return fmt.Errorf("%w : use exactly one of --every or --daily", ErrInvalidSchedule)
+	}
+	if opts.Daily == "" && opts.Every < time.Minute {
+		return fmt.Errorf("%w : --every must be at least 1m", ErrInvalidSchedule)
+	}
+	if opts.Daily != "" {
+		if _, err := time.Parse("15:04", opts.Daily); err != nil {
+			return fmt.Errorf("%w : --daily expects a time like 08:00", ErrInvalidSchedule)
+		}
+	}
+	return nil
+}
+
+func newScheduleUnit(opts *ScheduleOptions) (*scheduleUnit, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	var environment []string
+	for _, key := range cronEnvironment {
+		if value := os.Getenv(key); value != "" {
+			environment = append(environment, key+"="+value)
+		}
+	}
+
+	return &scheduleUnit{
+		Name:        opts.name(),
+		Executable:  executable,
+		Mode:        opts.Mode,
+		SlideShow:   opts.SlideShow,
+		Every:       opts.Every,
+		Daily:       opts.Daily,
+		Environment: environment,
+	}, nil
+}
+
+func (u *scheduleUnit) UnitName() string {
+	return scheduleUnitPrefix + u.Name
+}
+
+func (u *scheduleUnit) Description() string {
+	when := "at " + u.Daily + " every day"
+	if u.Daily == "" {
+		when = "every " + formatInterval(u.Every)
+	}
+
+	description := fmt.Sprintf("Backdrop %s wallpaper change %s", u.Mode, when)
+	if u.SlideShow != "" {
+		description += fmt.Sprintf(" from slideshow '%s'", u.SlideShow)
+	}
+	return description
+}
+
+// UnitDescription escapes the description for unit files, where '%' starts a specifier.
+func (u *scheduleUnit) UnitDescription() string {
+	return strings.ReplaceAll(u.Description(), "%", "%%")
+}
+
+func (u *scheduleUnit) EverySeconds() int64 {
+	return int64(u.Every / time.Second)
+}
+
+func (u *scheduleUnit) args() []string {
+	args := []string{u.Executable, "schedule", "run", "--mode", u.Mode}
+	if u.SlideShow != "" {
+		args = append(args, "--slideshow", u.SlideShow)
+	}
+	return args
+}
+
+func (u *scheduleUnit) ExecStart() string {
+	args := u.args()
+	for i, arg := range args {
+		args[i] = systemdQuote(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+func (u *scheduleUnit) CronMarker() string {
+	return cronMarkerPrefix + u.Name
+}
+
+// CronSpec only supports intervals cron can express exactly, anything else would
+// silently drift from what the user asked for.
+func (u *scheduleUnit) CronSpec() (string, error) {
+	if u.Daily != "" {
+		daily, _ := time.Parse("15:04", u.Daily)
+		return fmt.Sprintf("%d %d * * *", daily.Minute(), daily.Hour()), nil
+	}
+
+	switch {
+	case u.Every%time.Minute != 0:
+	case u.Every == time.Minute:
+		return "* * * * *", nil
+	case u.Every < time.Hour && time.Hour%u.Every == 0:
+		return fmt.Sprintf("*/%d * * * *", int(u.Every/time.Minute)), nil
+	case u.Every == time.Hour:
+		return "0 * * * *", nil
+	case u.Every%time.Hour == 0 && u.Every < 24*time.Hour && (24*time.Hour)%u.Every == 0:
+		return fmt.Sprintf("0 */%d * * *", int(u.Every/time.Hour)), nil
+	}
+	return "", fmt.Errorf("%w : cron cannot run every %s, use a divisor of 1h or 24h", ErrInvalidSchedule, formatInterval(u.Every))
+}
+
+func (u *scheduleUnit) CronCommand() string {
+	command := make([]string, 0, len(u.Environment)+len(u.args())+1)
+	if len(u.Environment) > 0 {
+		command = append(command, "env")
+		for _, variable := range u.Environment {
+			command = append(command, cronQuote(variable))
+		}
+	}
+	for _, arg := range u.args() {
+		command = append(command, cronQuote(arg))
+	}
+	return strings.Join(command, " ")
+}
+
+func renderTemplate(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// systemdQuote quotes an ExecStart argument, '%' starts a specifier in unit files.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(arg) + `"`
+}
+
+// cronQuote quotes a shell argument, an unescaped '%' ends the command in crontabs.
+func cronQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;$`&|<>()*?[]#~%") {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, "'", `'\''`)
+	return "'" + strings.ReplaceAll(arg, "%", `\%`) + "'"
+}
+
+// formatInterval drops the zero units time.Duration prints, 30m0s becomes 30m.
+func formatInterval(d time.Duration) string {
+	interval := d.String()
+	if strings.HasSuffix(interval, "m0s") {
+		interval = strings.TrimSuffix(interval, "0s")
+	}
+	if strings.HasSuffix(interval, "h0m") {
+		interval = strings.TrimSuffix(interval, "0m")
+	}
+	return interval
+}
+
+func getSystemdUserPath() (string, error) {
+	configPath, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, "systemd", "user"), nil
+}
+
+func InstallSchedule(out io.Writer, opts *ScheduleOptions) error {
+	unit, err := newScheduleUnit(opts)
+	if err != nil {
+		return err
+	}
+
+	if systemdAvailable() {
+		return installSystemdSchedule(out, unit)
+	}
+	if commandExist("crontab") {
+		return installCronSchedule(out, unit)
+	}
+	return fmt.Errorf("%w : systemctl or crontab", ErrCommandNotFound)
+}
+
+func installSystemdSchedule(out io.Writer, unit *scheduleUnit) error {
+	unitPath, err := getSystemdUserPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(unitPath, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", unitPath, err)
+	}
+
+	for extension, tmpl := range map[string]*template.Template{".service": serviceTemplate, ".timer": timerTemplate} {
+		content, err := renderTemplate(tmpl, unit)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(unitPath, unit.UnitName()+extension), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	if err := systemctlUser("daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctlUser("enable", "--now", unit.UnitName()+".timer"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Installed %s.timer: %s.\n", unit.UnitName(), unit.Description())
+	return nil
+}
+
+func installCronSchedule(out io.Writer, unit *scheduleUnit) error {
+	if _, err := unit.CronSpec(); err != nil {
+		return err
+	}
+
+	entry, err := renderTemplate(cronTemplate, unit)
+	if err != nil {
+		return err
+	}
+
+	crontab, err := readCrontab()
+	if err != nil {
+		return err
+	}
+
+	crontab = removeCronEntry(crontab, unit.Name) + entry
+	if err := writeCrontab(crontab); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Installed cron entry '%s': %s.\n", unit.Name, unit.Description())
+	return nil
+}
+
+func ListSchedules(out io.Writer) error {
+	found := false
+
+	unitPath, err := getSystemdUserPath()
+	if err != nil {
+		return err
+	}
+	timers, err := filepath.Glob(filepath.Join(unitPath, scheduleUnitPrefix+"*.timer"))
+	if err != nil {
+		return err
+	}
+	for _, timer := range timers {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(timer), scheduleUnitPrefix), ".timer")
+		fmt.Fprintf(out, "%s\tsystemd\t%s\n", name, readUnitDescription(timer))
+		found = true
+	}
+
+	if commandExist("crontab") {
+		crontab, err := readCrontab()
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(crontab, "\n") {
+			if marker, ok := strings.CutPrefix(line, cronMarkerPrefix); ok {
+				name, description, _ := strings.Cut(marker, " ")
+				fmt.Fprintf(out, "%s\tcron\t%s\n", name, description)
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		fmt.Fprintln(out, "No schedules installed, add one with 'backdrop schedule install'.")
+	}
+	return nil
+}
+
+func readUnitDescription(unitFile string) string {
+	file, err := os.Open(unitFile)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if description, ok := strings.CutPrefix(scanner.Text(), "Description="); ok {
+			return strings.ReplaceAll(description, "%%", "%")
+		}
+	}
+	return ""
+}
+
+func RemoveSchedule(out io.Writer, name string) error {
+	removed := false
+
+	unitPath, err := getSystemdUserPath()
+	if err != nil {
+		return err
+	}
+	unitName := scheduleUnitPrefix + name
+	timer := filepath.Join(unitPath, unitName+".timer")
+	if _, err := os.Stat(timer); err == nil {
+		if systemdAvailable() {
+			systemctlUser("disable", "--now", unitName+".timer")
+		}
+		for _, unitFile := range []string{timer, filepath.Join(unitPath, unitName+".service")} {
+			if err := os.Remove(unitFile); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if systemdAvailable() {
+			if err := systemctlUser("daemon-reload"); err != nil {
+				return err
+			}
+		}
+		removed = true
+	}
+
+	if commandExist("crontab") {
+		crontab, err := readCrontab()
+		if err != nil {
+			return err
+		}
+		if updated := removeCronEntry(crontab, name); updated != crontab {
+			if err := writeCrontab(updated); err != nil {
+				return err
+			}
+			removed = true
+		}
+	}
+
+	if !removed {
+		return fmt.Errorf("%w : %s", ErrScheduleNotFound, name)
+	}
+
+	fmt.Fprintf(out, "Schedule '%s' has been removed.\n", name)
+	return nil
+}
+
+func systemctlUser(args ...string) error {
+	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("systemctl --user %s failed: %w : %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// readCrontab treats a missing crontab as an empty one, crontab -l exits with an
+// error in that case.
+func readCrontab() (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("crontab", "-l")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "no crontab") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read crontab: %w : %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func writeCrontab(crontab string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(crontab)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write crontab: %w : %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// removeCronEntry drops the marker line of the named schedule and the entry after it.
+func removeCronEntry(crontab, name string) string {
+	lines := strings.SplitAfter(crontab, "\n")
+	kept := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		marker, ok := strings.CutPrefix(strings.TrimSuffix(lines[i], "\n"), cronMarkerPrefix)
+		if ok && (marker == name || strings.HasPrefix(marker, name+" ")) {
+			i++
+			continue
+		}
+		kept = append(kept, lines[i])
+	}
+
+	crontab = strings.Join(kept, "")
+	if crontab != "" && !strings.HasSuffix(crontab, "\n") {
+		crontab += "\n"
+	}
+	return crontab
+}
+
+// RunSchedule makes a single wallpaper change, it is what installed units run.
+func RunSchedule(out io.Writer, mode, slideShow string) error {
+	if !slices.Contains(ScheduleModes, mode) {
+		return fmt.Errorf("%w : mode must be one of %v", ErrInvalidSchedule, ScheduleModes)
+	}
+
+	images, err := loadDaemonPlaylist(&DaemonOptions{SlideShow: slideShow})
+	if err != nil {
+		return err
+	}
+
+	currentWallpaper, _ := getPreviousWallpaper()
+	image := pickScheduledImage(mode, images, currentWallpaper, time.Now())
+
+	if err := setWallpaper(out, image); err != nil {
+		return err
+	}
+	if err := recordHistory(image, historySourceSchedule); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Wallpaper set to %s\n", image)
+	return nil
+}
+
+// pickScheduledImage picks from a non empty playlist. Daily mode maps every
+// calendar day to the same image, so reruns on the same day change nothing.
+func pickScheduledImage(mode string, images []string, currentWallpaper string, now time.Time) string {
+	switch mode {
+	case "next":
+		index := slices.Index(images, currentWallpaper)
+		return images[(index+1)%len(images)]
+	case "daily":
+		year, month, day := now.Date()
+		days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+		return images[int(days%int64(len(images)))]
+	default:
+		return pickRandomImage(images, currentWallpaper)
+	}
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in test/testData")
+
+func TestScheduleTemplates(t *testing.T) {
+	everyUnit := &scheduleUnit{
+		Name:       "random",
+		Executable: "/usr/local/bin/backdrop",
+		Mode:       "random",
+		Every:      30 * time.Minute,
+	}
+	dailyUnit := &scheduleUnit{
+		Name:        "morning",
+		Executable:  "/home/user/go/bin/backdrop",
+		Mode:        "daily",
+		SlideShow:   "Work 100%",
+		Daily:       "08:00",
+		Environment: []string{"DISPLAY=:0", "DBUS_SESSION_BUS_ADDRESS=unix:path=/run/user/1000/bus"},
+	}
+
+	testCases := []struct {
+		golden   string
+		template *template.Template
+		unit     *scheduleUnit
+	}{
+		{golden: "every.service", template: serviceTemplate, unit: everyUnit},
+		{golden: "every.timer", template: timerTemplate, unit: everyUnit},
+		{golden: "every.cron", template: cronTemplate, unit: everyUnit},
+		{golden: "daily.service", template: serviceTemplate, unit: dailyUnit},
+		{golden: "daily.timer", template: timerTemplate, unit: dailyUnit},
+		{golden: "daily.cron", template: cronTemplate, unit: dailyUnit},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.golden, func(t *testing.T) {
+			got, err := renderTemplate(testCase.template, testCase.unit)
+			if err != nil {
+				t.Fatalf("Expected NO error, but got '%v' instead", err)
+			}
+
+			goldenFile := filepath.Join("..", "test", "testData", "schedule", testCase.golden)
+			if *updateGolden {
+				if err := os.WriteFile(goldenFile, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			exp, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("Error reading golden file, run 'go test ./internal -run TestScheduleTemplates -update': %v", err)
+			}
+			if got != string(exp) {
+				t.Errorf("Expected content:\n%s\nbut got:\n%s", exp, got)
+			}
+		})
+	}
+}
+
+func TestScheduleCronSpec(t *testing.T) {
+	testCases := []struct {
+		every   time.Duration
+		expSpec string
+		expErr  bool
+	}{
+		{every: time.Minute, expSpec: "* * * * *"},
+		{every: 15 * time.Minute, expSpec: "*/15 * * * *"},
+		{every: time.Hour, expSpec: "0 * * * *"},
+		{every: 6 * time.Hour, expSpec: "0 */6 * * *"},
+		{every: 45 * time.Minute, expErr: true},
+		{every: 90 * time.Second, expErr: true},
+		{every: 5 * time.Hour, expErr: true},
+	}
+
+	for _, testCase := range testCases {
+		spec, err := (&scheduleUnit{Every: testCase.every}).CronSpec()
+		if testCase.expErr {
+			if err == nil {
+				t.Errorf("Expected error for %s, but got '%s'", testCase.every, spec)
+			}
+			continue
+		}
+		if err != nil || spec != testCase.expSpec {
+			t.Errorf("Expected '%s' for %s, but got '%s' and '%v' instead", testCase.expSpec, testCase.every, spec, err)
+		}
+	}
+}
+
+func TestPickScheduledImage(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 23, 30, 0, 0, time.Local)
+
+	if got := pickScheduledImage("next", testPlaylist, "two.jpg", now); got != "three.jpg" {
+		t.Errorf("Expected 'three.jpg', but got '%s' instead", got)
+	}
+	if got := pickScheduledImage("next", testPlaylist, "three.jpg", now); got != "one.jpg" {
+		t.Errorf("Expected 'one.jpg', but got '%s' instead", got)
+	}
+	if got := pickScheduledImage("next", testPlaylist, "elsewhere.jpg", now); got != "one.jpg" {
+		t.Errorf("Expected 'one.jpg', but got '%s' instead", got)
+	}
+
+	daily := pickScheduledImage("daily", testPlaylist, "", now)
+	if got := pickScheduledImage("daily", testPlaylist, daily, now.Add(-23*time.Hour)); got != daily {
+		t.Errorf("Expected the same image all day, but got '%s' and '%s'", daily, got)
+	}
+	if got := pickScheduledImage("daily", testPlaylist, "", now.Add(time.Hour)); got == daily {
+		t.Errorf("Expected a different image the next day, but got '%s' again", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		if got := pickScheduledImage("random", testPlaylist, "one.jpg", now); got == "one.jpg" {
+			t.Fatalf("Expected random to skip the current wallpaper, but got '%s'", got)
+		}
+	}
+}
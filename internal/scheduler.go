@@ -0,0 +1,406 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxTickInterval bounds how long a rotation waits before looking at the wall clock
+// again, so rotations catch up shortly after the machine resumes from suspend.
+const maxTickInterval = 30 * time.Second
+
+type clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) ticker
+}
+
+type ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) Chan() <-chan time.Time {
+	return t.C
+}
+
+// rotation tracks the playlist position of a single output. The position is derived
+// from the wall clock elapsed since the anchor instead of counting ticks, which keeps
+// it right across suspend/resume and clock jumps.
+type rotation struct {
+	output   string
+	images   []string
+	anchor   time.Time
+	offset   int
+	current  int
+	override string
+}
+
+// scheduler rotates the wallpaper of every output through the active backend.
+type scheduler struct {
+	out      io.Writer
+	backend  wallpaperBackend
+	clock    clock
+	interval time.Duration
+
+	mu        sync.Mutex
+	rotations map[string]*rotation
+	paused    bool
+	pausedAt  time.Time
+
+	// onChange hooks are called with the output and image after every wallpaper change.
+	onChange []func(output, image string)
+}
+
+func newScheduler(out io.Writer, backend wallpaperBackend, clock clock, interval time.Duration) *scheduler {
+	return &scheduler{
+		out:       out,
+		backend:   backend,
+		clock:     clock,
+		interval:  interval,
+		rotations: make(map[string]*rotation),
+	}
+}
+
+// load replaces the playlist of every output. Outputs keep their anchor across
+// reloads; each output starts at a different image so screens don't all match.
+func (s *scheduler) load(images []string, outputs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now().Round(0)
+	rotations := make(map[string]*rotation, len(outputs))
+	for i, output := range outputs {
+		r, ok := s.rotations[output]
+		if !ok {
+			r = &rotation{output: output, anchor: now, offset: i}
+		}
+		r.images = images
+		r.current = -1
+		r.override = ""
+		rotations[output] = r
+	}
+	s.rotations = rotations
+}
+
+func (s *scheduler) outputs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outputs := make([]string, 0, len(s.rotations))
+	for output := range s.rotations {
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+// now returns the wall clock, frozen at the moment the rotation was paused.
+func (s *scheduler) now() time.Time {
+	if s.paused {
+		return s.pausedAt
+	}
+	return s.clock.Now().Round(0)
+}
+
+func (s *scheduler) slot(r *rotation, now time.Time) int {
+	elapsed := now.Round(0).Sub(r.anchor)
+	if elapsed < 0 {
+		return 0
+	}
+	return int(elapsed / s.interval)
+}
+
+func (s *scheduler) index(r *rotation, now time.Time) int {
+	n := len(r.images)
+	return ((s.slot(r, now)+r.offset)%n + n) % n
+}
+
+func (s *scheduler) nextChange(r *rotation, now time.Time) time.Time {
+	return r.anchor.Add(time.Duration(s.slot(r, now)+1) * s.interval)
+}
+
+// apply sets the image due on the output if it is not the one already shown.
+func (s *scheduler) apply(output string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rotations[output]
+	if !ok || len(r.images) == 0 {
+		return nil
+	}
+
+	index := s.index(r, s.now())
+	if index == r.current {
+		return nil
+	}
+
+	if err := s.setWallpaper(output, r.images[index]); err != nil {
+		return err
+	}
+	r.current = index
+	r.override = ""
+	return nil
+}
+
+func (s *scheduler) addOnChange(onChange func(output, image string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onChange = append(s.onChange, onChange)
+}
+
+// setWallpaper must be called with the lock held.
+func (s *scheduler) setWallpaper(output, image string) error {
+	if err := applyWallpaper(s.out, s.backend, output, image, Fit{}, nil); err != nil {
+		return err
+	}
+
+	for _, onChange := range s.onChange {
+		onChange(output, image)
+	}
+	return nil
+}
+
+func (s *scheduler) applyAll() error {
+	for _, output := range s.outputs() {
+		if err := s.apply(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reapply sets the images shown again without moving the rotation, so paired images
+// switch to the variant of the current color scheme.
+func (s *scheduler) reapply() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for output, r := range s.rotations {
+		image := r.override
+		if image == "" && r.current >= 0 && r.current < len(r.images) {
+			image = r.images[r.current]
+		}
+		if image == "" {
+			continue
+		}
+
+		if err := applyWallpaper(s.out, s.backend, output, image, Fit{}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step moves every output forward, or backwards with a negative delta, right away.
+func (s *scheduler) step(delta int) error {
+	s.mu.Lock()
+	for _, r := range s.rotations {
+		r.offset += delta
+	}
+	s.mu.Unlock()
+
+	return s.applyAll()
+}
+
+func (s *scheduler) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		s.pausedAt = s.clock.Now().Round(0)
+		s.paused = true
+	}
+}
+
+// resume shifts the anchors by the paused time so every output continues from the
+// image it was showing.
+func (s *scheduler) resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		return
+	}
+
+	pausedFor := s.clock.Now().Round(0).Sub(s.pausedAt)
+	for _, r := range s.rotations {
+		r.anchor = r.anchor.Add(pausedFor)
+	}
+	s.paused = false
+}
+
+// show sets the image on every output. Images in the playlist move the rotation to
+// them, other images stay on screen until the next change.
+func (s *scheduler) show(image string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for output, r := range s.rotations {
+		if len(r.images) == 0 {
+			continue
+		}
+
+		for i, playlistImage := range r.images {
+			if playlistImage == image {
+				s.moveTo(r, i)
+				break
+			}
+		}
+
+		if err := s.setWallpaper(output, image); err != nil {
+			return err
+		}
+		r.current = s.index(r, s.now())
+		r.override = ""
+		if r.images[r.current] != image {
+			r.override = image
+		}
+	}
+
+	return nil
+}
+
+// random moves every output to a random image other than the one it shows.
+func (s *scheduler) random() error {
+	s.mu.Lock()
+	for _, r := range s.rotations {
+		if len(r.images) < 2 {
+			continue
+		}
+
+		index := rand.Intn(len(r.images) - 1)
+		if index >= s.index(r, s.now()) {
+			index++
+		}
+		s.moveTo(r, index)
+	}
+	s.mu.Unlock()
+
+	return s.applyAll()
+}
+
+// moveTo shifts the rotation offset so the image at index is the one due now.
+func (s *scheduler) moveTo(r *rotation, index int) {
+	r.offset += index - s.index(r, s.now())
+}
+
+// playlist returns the images of the first output, every output shares the playlist.
+func (s *scheduler) playlist() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.rotations {
+		return append([]string(nil), r.images...)
+	}
+	return nil
+}
+
+type OutputStatus struct {
+	Output     string    `json:"output"`
+	Image      string    `json:"image"`
+	Position   int       `json:"position"`
+	Total      int       `json:"total"`
+	NextChange time.Time `json:"next_change"`
+	Paused     bool      `json:"paused"`
+}
+
+func (s *scheduler) status() []OutputStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]OutputStatus, 0, len(s.rotations))
+	for output, r := range s.rotations {
+		if len(r.images) == 0 {
+			continue
+		}
+
+		now := s.now()
+		index := s.index(r, now)
+		status := OutputStatus{
+			Output:   output,
+			Image:    r.images[index],
+			Position: index + 1,
+			Total:    len(r.images),
+			Paused:   s.paused,
+		}
+		if r.override != "" {
+			status.Image = r.override
+		}
+		if !s.paused {
+			status.NextChange = s.nextChange(r, now)
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Output < statuses[j].Output
+	})
+	return statuses
+}
+
+func (s *scheduler) tickInterval() time.Duration {
+	if s.interval < maxTickInterval {
+		return s.interval
+	}
+	return maxTickInterval
+}
+
+// run starts a ticker per output and blocks until the context is canceled.
+func (s *scheduler) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, output := range s.outputs() {
+		wg.Add(1)
+		go func(output string) {
+			defer wg.Done()
+			s.runOutput(ctx, output)
+		}(output)
+	}
+	wg.Wait()
+}
+
+func (s *scheduler) runOutput(ctx context.Context, output string) {
+	s.logError(output, s.apply(output))
+
+	t := s.clock.NewTicker(s.tickInterval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.Chan():
+			s.logError(output, s.apply(output))
+		}
+	}
+}
+
+// logError reports failures without stopping the rotation, the next tick retries.
+func (s *scheduler) logError(output string, err error) {
+	if err == nil {
+		return
+	}
+
+	if output == allOutputs {
+		output = "all outputs"
+	}
+	fmt.Fprintf(s.out, "Could not rotate wallpaper on %s: %v\n", output, err)
+}
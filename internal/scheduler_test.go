@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{c: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the wall clock and fires every ticker once, like a ticker
+// catching up after the machine resumes.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+type fakeTicker struct {
+	c chan time.Time
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {}
+
+type fakeWallpaper struct {
+	output    string
+	wallpaper string
+}
+
+type fakeBackend struct {
+	outputs []string
+	applied chan fakeWallpaper
+	fitted  chan Fit
+
+	mu         sync.Mutex
+	current    string
+	currentFit Fit
+}
+
+func newFakeBackend(outputs ...string) *fakeBackend {
+	return &fakeBackend{outputs: outputs, applied: make(chan fakeWallpaper, 16), fitted: make(chan Fit, 16)}
+}
+
+func (b *fakeBackend) Name() string {
+	return "fake"
+}
+
+func (b *fakeBackend) Outputs() ([]string, error) {
+	return b.outputs, nil
+}
+
+func (b *fakeBackend) SetWallpaper(output, wallpaper string) error {
+	b.mu.Lock()
+	b.current = wallpaper
+	b.mu.Unlock()
+
+	b.applied <- fakeWallpaper{output: output, wallpaper: wallpaper}
+	return nil
+}
+
+func (b *fakeBackend) CurrentWallpaper() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current, nil
+}
+
+func (b *fakeBackend) SetFit(output string, fit Fit) error {
+	b.mu.Lock()
+	b.currentFit = fit
+	b.mu.Unlock()
+
+	select {
+	case b.fitted <- fit:
+	default:
+	}
+	return nil
+}
+
+func (b *fakeBackend) CurrentFit() (Fit, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentFit, nil
+}
+
+func (b *fakeBackend) expect(t *testing.T, exp fakeWallpaper) {
+	t.Helper()
+
+	select {
+	case got := <-b.applied:
+		if got != exp {
+			t.Errorf("Expected wallpaper '%+v', but got '%+v' instead", exp, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected wallpaper '%+v' to be applied, but nothing was applied", exp)
+	}
+}
+
+func (b *fakeBackend) expectNothing(t *testing.T) {
+	t.Helper()
+
+	select {
+	case got := <-b.applied:
+		t.Errorf("Expected NO wallpaper to be applied, but got '%+v'", got)
+	default:
+	}
+}
+
+var testPlaylist = []string{"one.jpg", "two.jpg", "three.jpg"}
+
+func TestSchedulerRotatesPerOutput(t *testing.T) {
+	clock := newFakeClock()
+	backend := newFakeBackend("eDP-1", "HDMI-1")
+	s := newScheduler(&bytes.Buffer{}, backend, clock, 10*time.Minute)
+	s.load(testPlaylist, []string{"eDP-1", "HDMI-1"})
+
+	s.apply("eDP-1")
+	backend.expect(t, fakeWallpaper{"eDP-1", "one.jpg"})
+	s.apply("HDMI-1")
+	backend.expect(t, fakeWallpaper{"HDMI-1", "two.jpg"})
+
+	clock.Advance(5 * time.Minute)
+	s.apply("eDP-1")
+	backend.expectNothing(t)
+
+	clock.Advance(5 * time.Minute)
+	s.apply("eDP-1")
+	backend.expect(t, fakeWallpaper{"eDP-1", "two.jpg"})
+	s.apply("HDMI-1")
+	backend.expect(t, fakeWallpaper{"HDMI-1", "three.jpg"})
+}
+
+func TestSchedulerReanchorsAfterSuspend(t *testing.T) {
+	clock := newFakeClock()
+	backend := newFakeBackend(allOutputs)
+	s := newScheduler(&bytes.Buffer{}, backend, clock, 10*time.Minute)
+	s.load(testPlaylist, []string{allOutputs})
+
+	s.apply(allOutputs)
+	backend.expect(t, fakeWallpaper{allOutputs, "one.jpg"})
+
+	// Seven slots pass while suspended, the position follows the wall clock.
+	clock.Advance(70 * time.Minute)
+	s.apply(allOutputs)
+	backend.expect(t, fakeWallpaper{allOutputs, "two.jpg"})
+
+	r := s.rotations[allOutputs]
+	expNext := clock.Now().Add(10 * time.Minute)
+	if next := s.nextChange(r, clock.Now()); !next.Equal(expNext) {
+		t.Errorf("Expected next change '%v', but got '%v' instead", expNext, next)
+	}
+}
+
+func TestSchedulerReloadKeepsPosition(t *testing.T) {
+	clock := newFakeClock()
+	backend := newFakeBackend(allOutputs)
+	s := newScheduler(&bytes.Buffer{}, backend, clock, 10*time.Minute)
+	s.load(testPlaylist, []string{allOutputs})
+
+	clock.Advance(10 * time.Minute)
+	s.apply(allOutputs)
+	backend.expect(t, fakeWallpaper{allOutputs, "two.jpg"})
+
+	s.load([]string{"four.jpg", "five.jpg"}, []string{allOutputs})
+	s.apply(allOutputs)
+	backend.expect(t, fakeWallpaper{allOutputs, "five.jpg"})
+}
+
+func TestSchedulerRun(t *testing.T) {
+	clock := newFakeClock()
+	backend := newFakeBackend(allOutputs)
+	s := newScheduler(&bytes.Buffer{}, backend, clock, time.Minute)
+	s.load(testPlaylist, []string{allOutputs})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(ctx)
+	}()
+
+	backend.expect(t, fakeWallpaper{allOutputs, "one.jpg"})
+
+	// Wait for the ticker before moving the clock.
+	for deadline := time.Now().Add(time.Second); ; {
+		clock.mu.Lock()
+		ready := len(clock.tickers) == 1
+		clock.mu.Unlock()
+		if ready || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(time.Minute)
+	backend.expect(t, fakeWallpaper{allOutputs, "two.jpg"})
+
+	cancel()
+	<-done
+}
+
+func TestDaemonReloadsPlaylist(t *testing.T) {
+	wallpapersPath := t.TempDir()
+	for _, image := range []string{"a.jpg", "b.jpg"} {
+		if err := os.WriteFile(filepath.Join(wallpapersPath, image), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	originalPath := viper.Get("WallpapersPath")
+	viper.Set("WallpapersPath", wallpapersPath)
+	defer viper.Set("WallpapersPath", originalPath)
+
+	clock := newFakeClock()
+	backend := newFakeBackend(allOutputs)
+	d := newDaemon(&bytes.Buffer{}, &DaemonOptions{Interval: time.Hour}, backend, clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reload := make(chan os.Signal, 1)
+	done := make(chan error)
+	go func() {
+		done <- d.run(ctx, reload)
+	}()
+
+	backend.expect(t, fakeWallpaper{allOutputs, filepath.Join(wallpapersPath, "a.jpg")})
+
+	if err := os.Remove(filepath.Join(wallpapersPath, "a.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	reload <- syscall.SIGHUP
+	backend.expect(t, fakeWallpaper{allOutputs, filepath.Join(wallpapersPath, "b.jpg")})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected NO error, but got '%v' instead", err)
+	}
+}
@@ -0,0 +1,368 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+type ServeOptions struct {
+	Listen string
+	Token  string
+}
+
+// apiServer serves the JSON API under /api/. Requests that change the wallpaper or
+// the config file hold mu exclusively since viper is not safe for concurrent use.
+type apiServer struct {
+	out   io.Writer
+	token string
+	mu    sync.RWMutex
+}
+
+type setWallpaperRequest struct {
+	Image string `json:"image"`
+}
+
+type slideShowRequest struct {
+	Name            string   `json:"name"`
+	Images          []string `json:"images"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+type wallpaperResponse struct {
+	Image string `json:"image"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func Serve(out io.Writer, opts *ServeOptions) error {
+	token := opts.Token
+	if token == "" {
+		var err error
+		if token, err = generateToken(); err != nil {
+			return err
+		}
+	}
+
+	listener, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", opts.Listen, err)
+	}
+
+	server := &http.Server{
+		Handler:           newServeHandler(out, token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(out, "Serving backdrop on http://%s/#token=%s\n", listener.Addr(), token)
+	fmt.Fprintln(out, "Press Ctrl+C to stop.")
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func newServeHandler(out io.Writer, token string) http.Handler {
+	api := &apiServer{out: out, token: token}
+	static, _ := fs.Sub(webFiles, "web")
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", api.authenticate(http.HandlerFunc(api.route)))
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	return mux
+}
+
+// authenticate accepts the token as a bearer token or as a "token" query parameter,
+// the latter is needed for <img> tags in the web UI.
+func (api *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (api *apiServer) route(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/api/"), "/")
+	parts := strings.Split(path, "/")
+	// Parts are split before unescaping, image names of other library roots
+	// contain an escaped "/".
+	for i, part := range parts {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			parts[i] = unescaped
+		}
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		api.mu.RLock()
+		defer api.mu.RUnlock()
+	} else {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+	}
+
+	switch {
+	case path == "images" && r.Method == http.MethodGet:
+		api.listImages(w, r)
+	case len(parts) == 2 && parts[0] == "images" && r.Method == http.MethodGet:
+		api.getImage(w, r, parts[1], false)
+	case len(parts) == 3 && parts[0] == "images" && parts[2] == "thumbnail" && r.Method == http.MethodGet:
+		api.getImage(w, r, parts[1], true)
+	case path == "wallpaper" && r.Method == http.MethodGet:
+		api.getWallpaper(w, r)
+	case path == "wallpaper" && r.Method == http.MethodPost:
+		api.setWallpaper(w, r)
+	case path == "wallpaper/random" && r.Method == http.MethodPost:
+		api.setRandomWallpaper(w, r)
+	case path == "history" && r.Method == http.MethodGet:
+		api.listHistory(w, r)
+	case path == "slideshows" && r.Method == http.MethodGet:
+		api.listSlideShows(w, r)
+	case path == "slideshows" && r.Method == http.MethodPost:
+		api.saveSlideShow(w, r, "")
+	case len(parts) == 2 && parts[0] == "slideshows" && r.Method == http.MethodGet:
+		api.getSlideShow(w, r, parts[1])
+	case len(parts) == 2 && parts[0] == "slideshows" && r.Method == http.MethodPut:
+		api.saveSlideShow(w, r, parts[1])
+	case len(parts) == 2 && parts[0] == "slideshows" && r.Method == http.MethodDelete:
+		api.deleteSlideShow(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "slideshows" && parts[2] == "use" && r.Method == http.MethodPost:
+		api.useSlideShow(w, r, parts[1])
+	default:
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (api *apiServer) listImages(w http.ResponseWriter, r *http.Request) {
+	images, err := getLibraryImages()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, images)
+}
+
+func (api *apiServer) getImage(w http.ResponseWriter, r *http.Request, name string, thumbnail bool) {
+	image, err := findLibraryImage(name)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	path := image.Path
+	if thumbnail {
+		if path, err = getThumbnail(image.Path); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	http.ServeFile(w, r, path)
+}
+
+func (api *apiServer) getWallpaper(w http.ResponseWriter, r *http.Request) {
+	currentWallpaper, err := getPreviousWallpaper()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, wallpaperResponse{Image: currentWallpaper})
+}
+
+func (api *apiServer) setWallpaper(w http.ResponseWriter, r *http.Request) {
+	var request setWallpaperRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	image, err := findLibraryImage(request.Image)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := setWallpaper(api.out, image.Path); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if err := recordHistory(image.Path, historySourceServe); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wallpaperResponse{Image: image.Path})
+}
+
+func (api *apiServer) setRandomWallpaper(w http.ResponseWriter, r *http.Request) {
+	image, err := setRandomWallpaper(api.out, historySourceServe)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, wallpaperResponse{Image: image})
+}
+
+// listHistory returns the history newest first.
+func (api *apiServer) listHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := historyNewestFirst(0)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (api *apiServer) listSlideShows(w http.ResponseWriter, r *http.Request) {
+	slideShows, err := getSavedSlideShows()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	if slideShows == nil {
+		slideShows = []SavedSlideShow{}
+	}
+	writeJSON(w, http.StatusOK, slideShows)
+}
+
+func (api *apiServer) getSlideShow(w http.ResponseWriter, r *http.Request, name string) {
+	slideShow, err := findSavedSlideShow(name)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, slideShow)
+}
+
+// saveSlideShow creates a slideshow, or replaces the one at name when given. Images
+// are library names, they are stored as absolute paths like 'slideshow save' does.
+func (api *apiServer) saveSlideShow(w http.ResponseWriter, r *http.Request, name string) {
+	var request slideShowRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if name != "" {
+		request.Name = name
+	}
+	if request.DurationMinutes <= 0 {
+		writeJSONError(w, http.StatusBadRequest, errors.New("duration_minutes must be greater than 0"))
+		return
+	}
+
+	images := make([]string, 0, len(request.Images))
+	for _, name := range request.Images {
+		image, err := findLibraryImage(name)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		images = append(images, image.Path)
+	}
+
+	slideShow := SavedSlideShow{Name: request.Name, Images: images, Duration: request.DurationMinutes * 60000}
+	if err := storeSlideShow(slideShow); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if r.Method == http.MethodPost {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, slideShow)
+}
+
+func (api *apiServer) deleteSlideShow(w http.ResponseWriter, r *http.Request, name string) {
+	if err := DeleteSlideShow(io.Discard, name); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *apiServer) useSlideShow(w http.ResponseWriter, r *http.Request, name string) {
+	if err := UseSlideShow(io.Discard, name); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeJSONBody(r *http.Request, v any) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeAPIError maps backdrop's sentinel errors to HTTP status codes.
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrImageNotFound), errors.Is(err, ErrSlideShowNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalidSlideShowName), errors.Is(err, ErrEmptyPlaylist), errors.Is(err, ErrUnsupportedImage):
+		status = http.StatusBadRequest
+	}
+	writeJSONError(w, status, err)
+}
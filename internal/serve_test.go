@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testServeToken = "test-token"
+
+func setupServeLibrary(t *testing.T) string {
+	t.Helper()
+
+	homePath := t.TempDir()
+	t.Setenv("HOME", homePath)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(homePath, ".config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(homePath, ".cache"))
+
+	wallpapersPath := t.TempDir()
+	testImages, err := filepath.Glob("../test/testData/images/*.jpg")
+	if err != nil || len(testImages) == 0 {
+		t.Fatalf("Expected test images, but got '%v' instead", err)
+	}
+	for _, testImage := range testImages {
+		content, err := os.ReadFile(testImage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(wallpapersPath, filepath.Base(testImage)), content, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	originalPath := viper.Get("WallpapersPath")
+	viper.Set("WallpapersPath", wallpapersPath)
+	t.Cleanup(func() {
+		viper.Set("WallpapersPath", originalPath)
+		viper.Set(slideShowsConfigKey, nil)
+	})
+
+	return wallpapersPath
+}
+
+func serveRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer "+testServeToken)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeServeResponse(t *testing.T, recorder *httptest.ResponseRecorder, expStatus int, v any) {
+	t.Helper()
+
+	if recorder.Code != expStatus {
+		t.Fatalf("Expected status %d, but got %d instead: %s", expStatus, recorder.Code, recorder.Body.String())
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+		t.Fatalf("Expected JSON response, but got '%v' instead", err)
+	}
+}
+
+func TestServeAuthentication(t *testing.T) {
+	setupServeLibrary(t)
+	handler := newServeHandler(io.Discard, testServeToken)
+
+	testCases := []struct {
+		name      string
+		path      string
+		header    string
+		expStatus int
+	}{
+		{name: "NoToken", path: "/api/images", expStatus: http.StatusUnauthorized},
+		{name: "WrongToken", path: "/api/images", header: "Bearer nope", expStatus: http.StatusUnauthorized},
+		{name: "BearerToken", path: "/api/images", header: "Bearer " + testServeToken, expStatus: http.StatusOK},
+		{name: "QueryToken", path: "/api/images?token=" + testServeToken, expStatus: http.StatusOK},
+		{name: "WebUI", path: "/", expStatus: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			if testCase.header != "" {
+				request.Header.Set("Authorization", testCase.header)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != testCase.expStatus {
+				t.Errorf("Expected status %d, but got %d instead", testCase.expStatus, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestServeAPI(t *testing.T) {
+	wallpapersPath := setupServeLibrary(t)
+
+	backend := newFakeBackend(allOutputs)
+	originalGetBackend := getBackend
+	getBackend = func() (wallpaperBackend, error) { return backend, nil }
+	defer func() { getBackend = originalGetBackend }()
+
+	handler := newServeHandler(io.Discard, testServeToken)
+
+	var images []LibraryImage
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodGet, "/api/images", ""), http.StatusOK, &images)
+	if len(images) != 3 || images[0].Name != "testImage.jpg" {
+		t.Fatalf("Expected the 3 test images, but got '%+v' instead", images)
+	}
+
+	thumbnail := serveRequest(t, handler, http.MethodGet, "/api/images/testImage.jpg/thumbnail", "")
+	if thumbnail.Code != http.StatusOK || thumbnail.Header().Get("Content-Type") != "image/jpeg" {
+		t.Errorf("Expected a JPEG thumbnail, but got status %d and '%s'", thumbnail.Code, thumbnail.Header().Get("Content-Type"))
+	}
+
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodGet, "/api/images/missing.jpg", ""), http.StatusNotFound, nil)
+
+	var wallpaper wallpaperResponse
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodPost, "/api/wallpaper", `{"image":"testImage2.jpg"}`), http.StatusOK, &wallpaper)
+	expImage := filepath.Join(wallpapersPath, "testImage2.jpg")
+	if wallpaper.Image != expImage {
+		t.Errorf("Expected wallpaper '%s', but got '%s' instead", expImage, wallpaper.Image)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, expImage})
+
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodPost, "/api/wallpaper/random", ""), http.StatusOK, &wallpaper)
+	backend.expect(t, fakeWallpaper{allOutputs, wallpaper.Image})
+
+	var history []HistoryEntry
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodGet, "/api/history", ""), http.StatusOK, &history)
+	if len(history) != 2 || history[0].Image != wallpaper.Image || history[1].Image != expImage || history[0].Source != historySourceServe {
+		t.Errorf("Expected newest first serve history, but got '%+v' instead", history)
+	}
+
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodPost, "/api/wallpaper", `{"image":"/etc/passwd"}`), http.StatusNotFound, nil)
+	backend.expectNothing(t)
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	var slideShow SavedSlideShow
+	body := `{"name":"Work","images":["testImage.jpg","testImage3.jpg"],"duration_minutes":5}`
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodPost, "/api/slideshows", body), http.StatusCreated, &slideShow)
+	if slideShow.Duration != 300000 || len(slideShow.Images) != 2 || slideShow.Images[1] != filepath.Join(wallpapersPath, "testImage3.jpg") {
+		t.Errorf("Expected saved slideshow with absolute images, but got '%+v' instead", slideShow)
+	}
+
+	var slideShows []SavedSlideShow
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodGet, "/api/slideshows", ""), http.StatusOK, &slideShows)
+	if len(slideShows) != 1 || slideShows[0].Name != "Work" {
+		t.Errorf("Expected slideshow 'Work', but got '%+v' instead", slideShows)
+	}
+
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodPost, "/api/slideshows", `{"name":"Empty","images":[],"duration_minutes":5}`), http.StatusBadRequest, nil)
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodDelete, "/api/slideshows/work", ""), http.StatusNoContent, nil)
+	decodeServeResponse(t, serveRequest(t, handler, http.MethodGet, "/api/slideshows/Work", ""), http.StatusNotFound, nil)
+
+	response := serveRequest(t, handler, http.MethodGet, "/api/slideshows", "")
+	if body, _ := io.ReadAll(response.Body); strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("Expected no slideshows, but got '%s' instead", body)
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,21 +17,30 @@ var (
 	inputDuration io.Reader = os.Stdin
 )
 
-func handleSlideshow(out io.Writer, wallpapersPath string, wallpapers []string, imageSelection FuzzySelection) error {
+func handleSlideshow(out io.Writer, wallpapersPath string, wallpapers []string, imageSelection FuzzySelection, fit Fit) error {
 	for hasConfirmed := false; !hasConfirmed; {
-		if err := processSlideshow(wallpapersPath, wallpapers, imageSelection); err != nil {
+		previousState, err := captureWallpaperState()
+		if err != nil {
 			return err
 		}
 
-		previousWallpaper, err := getPreviousWallpaper()
+		previousFiles, err := captureSlideShowFiles()
 		if err != nil {
 			return err
 		}
 
-		hasConfirmed, err = handleSelectionConfirmation(previousWallpaper, out, &SelectionOptions{
+		if _, _, err := processSlideshow(out, "", wallpapersPath, wallpapers, imageSelection, fit); err != nil {
+			return err
+		}
+
+		hasConfirmed, err = handleSelectionConfirmation(previousState, out, &SelectionOptions{
 			Prompt:         "Save slideshow configuration? [y/N]: ",
 			SuccessMessage: "Slideshow has been set successfully.",
-			Cleanup:        func() {},
+			Cleanup: func() {
+				if err := previousFiles.restore(); err != nil {
+					fmt.Fprintf(out, "Could not restore the slideshow: %v\n", err)
+				}
+			},
 		})
 
 		if err != nil {
@@ -40,32 +50,70 @@ func handleSlideshow(out io.Writer, wallpapersPath string, wallpapers []string,
 	return nil
 }
 
-func processSlideshow(wallpapersPath string, wallpapers []string, imageSelection FuzzySelection) error {
+func processSlideshow(out io.Writer, name, wallpapersPath string, wallpapers []string, imageSelection FuzzySelection, fit Fit) ([]string, int, error) {
 	selectedWallpaper, err := imageSelection(wallpapers)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	duration, err := getDurationFromUser(inputDuration)
+	duration, err := getDurationFromUser(inputDuration, out)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	configuredWallpaper, err := configureSlideShow(selectedWallpaper, wallpapersPath, duration)
+	images := strings.Split(selectedWallpaper, ";")
+	configuredWallpaper, err := configureNamedSlideShow(name, images, wallpapersPath, duration, fit)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	if runtime.GOOS != "windows" {
-		if err := setWallpaper(configuredWallpaper); err != nil {
-			return err
+		if err := setWallpaperFit(out, configuredWallpaper, fit, nil); err != nil {
+			return nil, 0, err
 		}
 	}
-	return nil
+	return images, duration, nil
+}
+
+// slideShowFiles holds the content of the default slideshow files before they are
+// rewritten, nil for files that did not exist.
+type slideShowFiles map[string][]byte
+
+// captureSlideShowFiles reads the default slideshow files, so a rejected slideshow can
+// put them back. Only Linux keeps the slideshow in files backdrop rewrites.
+func captureSlideShowFiles() (slideShowFiles, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	slideShowFile, slideShowConfigFile, err := os_Specifics.GetLinuxSlideShowFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(slideShowFiles, 2)
+	for _, file := range []string{slideShowFile, slideShowConfigFile} {
+		content, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read slideshow file %s: %w", file, err)
+		}
+		files[file] = content
+	}
+	return files, nil
 }
 
-func getDurationFromUser(r io.Reader) (int, error) {
-	fmt.Print("What should be the duration per slide? (In minutes): ")
+func (files slideShowFiles) restore() error {
+	var errs []error
+	for file, content := range files {
+		if err := restoreSlideShowFile(file, content); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func getDurationFromUser(r io.Reader, out io.Writer) (int, error) {
+	fmt.Fprint(out, "What should be the duration per slide? (In minutes): ")
 	input, err := bufio.NewReader(r).ReadString('\n')
 	if err != nil {
 		return 0, fmt.Errorf("failed to read duration input: %w", err)
@@ -81,13 +129,34 @@ func getDurationFromUser(r io.Reader) (int, error) {
 }
 
 func configureSlideShow(imageText, wallpapersPath string, duration int) (string, error) {
-	images := strings.Split(imageText, ";")
+	return configureNamedSlideShow("", strings.Split(imageText, ";"), wallpapersPath, duration, Fit{})
+}
+
+func configureNamedSlideShow(name string, images []string, wallpapersPath string, duration int, fit Fit) (string, error) {
+	// Images of other library roots can't be joined with the wallpapers path.
+	paths := make([]string, 0, len(images))
+	for _, image := range images {
+		paths = append(paths, libraryImagePath(wallpapersPath, image))
+	}
+	images = paths
+
 	switch runtime.GOOS {
 	case "linux":
-		return os_Specifics.ConfigureSlideShowLinux(images, wallpapersPath, duration)
+		return os_Specifics.ConfigureNamedSlideShowLinux(name, images, wallpapersPath, duration, fit)
 	case "windows":
-		return os_Specifics.ConfigureSlideShowWindows(images, wallpapersPath, duration)
+		return os_Specifics.ConfigureNamedSlideShowWindows(name, images, wallpapersPath, duration, fit)
 	default:
 		return "", ErrNoCompatibleOS
 	}
 }
+
+func removeNamedSlideShow(name string) error {
+	switch runtime.GOOS {
+	case "linux":
+		return os_Specifics.RemoveNamedSlideShowLinux(name)
+	case "windows":
+		return os_Specifics.RemoveNamedSlideShowWindows(name)
+	default:
+		return ErrNoCompatibleOS
+	}
+}
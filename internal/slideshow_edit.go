@@ -0,0 +1,243 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+func ListSlideShows(out io.Writer) error {
+	savedSlideShows, err := getSavedSlideShows()
+	if err != nil {
+		return err
+	}
+
+	if len(savedSlideShows) > 0 {
+		fmt.Fprintln(out, "Saved slideshows:")
+		for _, slideShow := range savedSlideShows {
+			fmt.Fprintf(out, "  %s (%d images)\n", slideShow.Name, len(slideShow.Images))
+		}
+	}
+
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	slideShows, err := os_Specifics.ListSlideShowsLinux()
+	if err != nil {
+		return err
+	}
+
+	if len(slideShows) == 0 {
+		fmt.Fprintln(out, "No slideshows installed.")
+		return nil
+	}
+
+	fmt.Fprintln(out, "Installed slideshows:")
+	currentWallpaper, _ := getPreviousWallpaper()
+	for _, slideShow := range slideShows {
+		marker := " "
+		if slideShow.Filename == currentWallpaper {
+			marker = "*"
+		}
+		fmt.Fprintf(out, "%s %s\n    %s\n", marker, slideShow.Name, slideShow.Filename)
+	}
+
+	return nil
+}
+
+func EditSlideShow(out io.Writer) error {
+	if runtime.GOOS != "linux" {
+		return ErrNoCompatibleOS
+	}
+
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+
+	wallpapers, err := getLibraryWallpapers()
+	if err != nil {
+		return err
+	}
+
+	slideShowFile, err := getCurrentSlideShowFile()
+	if err != nil {
+		return err
+	}
+
+	background, err := os_Specifics.ParseBackgroundFile(slideShowFile)
+	if err != nil {
+		return err
+	}
+
+	return handleSlideshowEdit(out, wallpapersPath, wallpapers, background, getPreselectedSelector)
+}
+
+func handleSlideshowEdit(out io.Writer, wallpapersPath string, wallpapers []string, background *os_Specifics.Background, imageSelection PreselectedFuzzySelection) error {
+	currentImages := make([]string, 0, len(background.Statics))
+	for _, image := range background.Images() {
+		currentImages = append(currentImages, libraryImageName(wallpapersPath, image))
+	}
+
+	configFile, err := os_Specifics.GetLinuxSlideShowConfigFile()
+	if err != nil {
+		return err
+	}
+	previousContent, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for hasConfirmed := false; !hasConfirmed; {
+		previousState, err := captureWallpaperState()
+		if err != nil {
+			return err
+		}
+
+		items := slideShowEditItems(wallpapers, currentImages)
+		selectedItems, err := imageSelection(items, func(i int) bool { return i < len(currentImages) })
+		if err != nil {
+			return err
+		}
+
+		images := orderSlideShowImages(currentImages, strings.Split(selectedItems, ";"))
+		if len(images) == 0 {
+			fmt.Fprintln(out, "A slideshow needs at least one image...")
+			continue
+		}
+
+		configuredWallpaper, err := configureSlideShow(strings.Join(images, ";"), wallpapersPath, background.Duration())
+		if err != nil {
+			return err
+		}
+
+		if err := setWallpaper(out, configuredWallpaper); err != nil {
+			return err
+		}
+
+		hasConfirmed, err = handleSelectionConfirmation(previousState, out, &SelectionOptions{
+			Prompt:         "Save slideshow changes? [y/N]: ",
+			SuccessMessage: "Slideshow has been updated successfully.",
+			Cleanup: func() {
+				if err := restoreSlideShowFile(configFile, previousContent); err != nil {
+					fmt.Fprintf(out, "Could not restore the slideshow: %v\n", err)
+				}
+			},
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getCurrentSlideShowFile prefers the slideshow currently set as wallpaper and falls
+// back to the one backdrop wrote last.
+func getCurrentSlideShowFile() (string, error) {
+	currentWallpaper, err := getPreviousWallpaper()
+	if err == nil && os_Specifics.IsSlideShowFile(currentWallpaper) {
+		if _, err := os.Stat(currentWallpaper); err == nil {
+			return currentWallpaper, nil
+		}
+	}
+
+	configFile, err := os_Specifics.GetLinuxSlideShowConfigFile()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(configFile); err != nil {
+		return "", ErrNoSlideShowFound
+	}
+
+	return configFile, nil
+}
+
+// libraryImageName returns the image name relative to the wallpapers path, or the
+// absolute path for images that live outside of it. Images of other library roots
+// are named the way the library lists them.
+func libraryImageName(wallpapersPath, image string) string {
+	if roots, err := getLibraryRoots(); err == nil && len(roots) > 1 {
+		for _, root := range roots {
+			if rel, err := filepath.Rel(root.Path, image); err == nil && !strings.HasPrefix(rel, "..") && !filepath.IsAbs(rel) {
+				return libraryRootName(roots, root, rel)
+			}
+		}
+	}
+
+	rel, err := filepath.Rel(wallpapersPath, image)
+	if err != nil || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+		return image
+	}
+	return rel
+}
+
+// slideShowEditItems lists the slideshow images first, so they can be preselected,
+// followed by the library images not in the slideshow.
+func slideShowEditItems(wallpapers, currentImages []string) []string {
+	included := make(map[string]bool, len(currentImages))
+	for _, image := range currentImages {
+		included[image] = true
+	}
+
+	items := append(make([]string, 0, len(wallpapers)+len(currentImages)), currentImages...)
+	for _, wallpaper := range wallpapers {
+		if !included[wallpaper] {
+			items = append(items, wallpaper)
+		}
+	}
+
+	return items
+}
+
+// orderSlideShowImages keeps the selected slideshow images in their order and
+// appends the newly selected ones.
+func orderSlideShowImages(currentImages, selectedItems []string) []string {
+	selected := make(map[string]bool, len(selectedItems))
+	for _, item := range selectedItems {
+		if item != "" {
+			selected[item] = true
+		}
+	}
+
+	images := make([]string, 0, len(selected))
+	for _, image := range currentImages {
+		if selected[image] {
+			images = append(images, image)
+			delete(selected, image)
+		}
+	}
+
+	for _, item := range selectedItems {
+		if selected[item] {
+			images = append(images, item)
+			delete(selected, item)
+		}
+	}
+
+	return images
+}
+
+// restoreSlideShowFile writes back the content a slideshow file had before it was
+// changed, or removes the file when there was none.
+func restoreSlideShowFile(file string, previousContent []byte) error {
+	if previousContent == nil {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove slideshow file %s: %w", file, err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(file, previousContent, 0777); err != nil {
+		return fmt.Errorf("failed to restore slideshow file %s: %w", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSlideShowEditSelection(t *testing.T) {
+	currentImages := []string{"sea.jpg", "/elsewhere/moon.png", "lake.jpg"}
+	items := slideShowEditItems([]string{"forest.jpg", "lake.jpg", "sea.jpg"}, currentImages)
+
+	if exp := []string{"sea.jpg", "/elsewhere/moon.png", "lake.jpg", "forest.jpg"}; !slices.Equal(items, exp) {
+		t.Errorf("Expected items %v, got %v instead.", exp, items)
+	}
+
+	testCases := []struct {
+		name     string
+		selected []string
+		exp      []string
+	}{
+		{name: "Unchanged", selected: []string{"sea.jpg", "/elsewhere/moon.png", "lake.jpg"}, exp: currentImages},
+		{name: "Removed", selected: []string{"lake.jpg", "sea.jpg"}, exp: []string{"sea.jpg", "lake.jpg"}},
+		{name: "Added", selected: []string{"forest.jpg", "sea.jpg", "lake.jpg"}, exp: []string{"sea.jpg", "lake.jpg", "forest.jpg"}},
+		{name: "Empty", selected: []string{""}, exp: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			images := orderSlideShowImages(currentImages, tc.selected)
+			if !slices.Equal(images, tc.exp) {
+				t.Errorf("Expected images %v, got %v instead.", tc.exp, images)
+			}
+		})
+	}
+}
+
+func TestRestoreSlideShowFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "backdrop_settings.xml")
+
+	if err := os.WriteFile(file, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreSlideShowFile(file, []byte("original")); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "original" {
+		t.Errorf("Expected the original content to be restored, got %q instead.", content)
+	}
+
+	if err := restoreSlideShowFile(file, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("Expected the slideshow file created by the edit to be removed, got %v instead.", err)
+	}
+	if err := restoreSlideShowFile(file, nil); err != nil {
+		t.Errorf("Expected no error removing a missing file, got %v instead.", err)
+	}
+}
@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/spf13/viper"
+)
+
+const slideShowsConfigKey = "SlideShows"
+
+// SavedSlideShow is a named slideshow registered in backdrop's config file.
+type SavedSlideShow struct {
+	Name     string   `mapstructure:"name" yaml:"name" json:"name"`
+	Images   []string `mapstructure:"images" yaml:"images" json:"images"`
+	Duration int      `mapstructure:"duration" yaml:"duration" json:"duration"`
+	Fit      Fit      `mapstructure:"fit" yaml:"fit,omitempty" json:"fit,omitempty"`
+}
+
+func SaveSlideShow(out io.Writer, config *Config, name string) error {
+	if os_Specifics.SlideShowFileName(name) == "" {
+		return ErrInvalidSlideShowName
+	}
+
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return err
+	}
+
+	wallpapers, err := getLibraryWallpapers()
+	if err != nil {
+		return err
+	}
+
+	existing, _ := findSavedSlideShow(name)
+	imageSelection := getSelector(config)
+
+	var images []string
+	var duration int
+	for hasConfirmed := false; !hasConfirmed; {
+		previousState, err := captureWallpaperState()
+		if err != nil {
+			return err
+		}
+
+		images, duration, err = processSlideshow(out, name, wallpapersPath, wallpapers, imageSelection, config.fit)
+		if err != nil {
+			return err
+		}
+
+		hasConfirmed, err = handleSelectionConfirmation(previousState, out, &SelectionOptions{
+			Prompt:         fmt.Sprintf("Save slideshow '%s'? [y/N]: ", name),
+			SuccessMessage: fmt.Sprintf("Slideshow '%s' has been saved successfully.", name),
+			Cleanup: func() {
+				if existing != nil {
+					if _, err := configureNamedSlideShow(existing.Name, existing.Images, "", existing.Duration, existing.Fit); err != nil {
+						fmt.Fprintf(out, "Could not restore slideshow '%s': %v\n", existing.Name, err)
+					}
+					return
+				}
+				if err := removeNamedSlideShow(name); err != nil {
+					fmt.Fprintf(out, "Could not remove slideshow '%s': %v\n", name, err)
+				}
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	absImages := make([]string, 0, len(images))
+	for _, image := range images {
+		absImages = append(absImages, libraryImagePath(wallpapersPath, image))
+	}
+
+	return registerSlideShow(SavedSlideShow{Name: name, Images: absImages, Duration: duration, Fit: config.fit})
+}
+
+func UseSlideShow(out io.Writer, name string) error {
+	slideShow, err := findSavedSlideShow(name)
+	if err != nil {
+		return err
+	}
+
+	configuredWallpaper, err := configureNamedSlideShow(slideShow.Name, slideShow.Images, "", slideShow.Duration, slideShow.Fit)
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := setWallpaperFit(out, configuredWallpaper, slideShow.Fit, nil); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "Slideshow '%s' is now active.\n", slideShow.Name)
+	return nil
+}
+
+func DeleteSlideShow(out io.Writer, name string) error {
+	slideShow, err := findSavedSlideShow(name)
+	if err != nil {
+		return err
+	}
+
+	if err := removeNamedSlideShow(slideShow.Name); err != nil {
+		return err
+	}
+
+	if err := unregisterSlideShow(slideShow.Name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Slideshow '%s' has been deleted.\n", slideShow.Name)
+	return nil
+}
+
+// storeSlideShow writes the slideshow files and registers it without prompting.
+func storeSlideShow(slideShow SavedSlideShow) error {
+	if os_Specifics.SlideShowFileName(slideShow.Name) == "" {
+		return ErrInvalidSlideShowName
+	}
+
+	if len(slideShow.Images) == 0 {
+		return ErrEmptyPlaylist
+	}
+
+	if _, err := configureNamedSlideShow(slideShow.Name, slideShow.Images, "", slideShow.Duration, slideShow.Fit); err != nil {
+		return err
+	}
+
+	return registerSlideShow(slideShow)
+}
+
+func getSavedSlideShows() ([]SavedSlideShow, error) {
+	var slideShows []SavedSlideShow
+	if err := viper.UnmarshalKey(slideShowsConfigKey, &slideShows); err != nil {
+		return nil, fmt.Errorf("failed to read saved slideshows from config: %w", err)
+	}
+	return slideShows, nil
+}
+
+// findSavedSlideShow matches names the same way they are turned into file names,
+// so "Work" and "work" refer to the same slideshow.
+func findSavedSlideShow(name string) (*SavedSlideShow, error) {
+	slideShows, err := getSavedSlideShows()
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := os_Specifics.SlideShowFileName(name)
+	for _, slideShow := range slideShows {
+		if os_Specifics.SlideShowFileName(slideShow.Name) == fileName {
+			return &slideShow, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w : %s", ErrSlideShowNotFound, name)
+}
+
+func registerSlideShow(slideShow SavedSlideShow) error {
+	slideShows, err := getSavedSlideShows()
+	if err != nil {
+		return err
+	}
+
+	fileName := os_Specifics.SlideShowFileName(slideShow.Name)
+	registered := false
+	for i := range slideShows {
+		if os_Specifics.SlideShowFileName(slideShows[i].Name) == fileName {
+			slideShows[i] = slideShow
+			registered = true
+		}
+	}
+
+	if !registered {
+		slideShows = append(slideShows, slideShow)
+	}
+
+	viper.Set(slideShowsConfigKey, slideShows)
+	return writeConfig()
+}
+
+func unregisterSlideShow(name string) error {
+	slideShows, err := getSavedSlideShows()
+	if err != nil {
+		return err
+	}
+
+	fileName := os_Specifics.SlideShowFileName(name)
+	remaining := make([]SavedSlideShow, 0, len(slideShows))
+	for _, slideShow := range slideShows {
+		if os_Specifics.SlideShowFileName(slideShow.Name) != fileName {
+			remaining = append(remaining, slideShow)
+		}
+	}
+
+	viper.Set(slideShowsConfigKey, remaining)
+	return writeConfig()
+}
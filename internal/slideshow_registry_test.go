@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+	"github.com/spf13/viper"
+)
+
+// stubSlideShowInput answers the finder with each selection in turn, then cancels,
+// and answers every duration prompt with a minute.
+func stubSlideShowInput(t *testing.T, confirmation string, selections ...string) {
+	t.Helper()
+
+	originalSelector, originalConfirmation, originalDuration := getSelector, inputConfirmation, inputDuration
+	t.Cleanup(func() {
+		getSelector, inputConfirmation, inputDuration = originalSelector, originalConfirmation, originalDuration
+	})
+
+	getSelector = func(c *Config) FuzzySelection {
+		return func(s []string) (string, error) {
+			if len(selections) == 0 {
+				return "", ErrUserCanceledSelection
+			}
+			selection := selections[0]
+			selections = selections[1:]
+			return selection, nil
+		}
+	}
+	inputConfirmation = strings.NewReader(confirmation)
+	inputDuration = strings.NewReader("1\n")
+}
+
+func TestSavedSlideShows(t *testing.T) {
+	library := setupManagedLibrary(t)
+	lake, tower := filepath.Join(library, "lake.png"), filepath.Join(library, "tower.png")
+
+	defaultFile, err := configureSlideShow("lake.png", library, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultContent, err := os.ReadFile(defaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("SaveReservedName", func(t *testing.T) {
+		stubSlideShowInput(t, "y\n", "tower.png;lake.png")
+
+		var out bytes.Buffer
+		if err := SaveSlideShow(&out, NewConfig("", false, true), "Backdrop Settings"); err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+
+		slideShow, err := findSavedSlideShow("backdrop_settings")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(slideShow.Images, []string{tower, lake}) || slideShow.Duration != 60_000 {
+			t.Errorf("Expected the saved slideshow to show tower and lake for a minute, but got %+v instead", slideShow)
+		}
+		if content, _ := os.ReadFile(defaultFile); !bytes.Equal(content, defaultContent) {
+			t.Errorf("Expected the default slideshow to be left alone, but it now is:\n%s", content)
+		}
+	})
+
+	t.Run("SaveRejected", func(t *testing.T) {
+		stubSlideShowInput(t, "n\n", "tower.png")
+
+		var out bytes.Buffer
+		err := SaveSlideShow(&out, NewConfig("", false, true), "night")
+		if !errors.Is(err, ErrUserCanceledSelection) {
+			t.Fatalf("Expected error '%v', but got '%v' instead", ErrUserCanceledSelection, err)
+		}
+		if strings.Contains(out.String(), "Could not") {
+			t.Errorf("Expected the rejected slideshow to be removed, but got output '%s'", out.String())
+		}
+
+		if _, err := findSavedSlideShow("night"); !errors.Is(err, ErrSlideShowNotFound) {
+			t.Errorf("Expected the rejected slideshow not to be saved, but got '%v'", err)
+		}
+		files, err := os_Specifics.ListLinuxSlideShowConfigFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if strings.Contains(filepath.Base(file), "night") {
+				t.Errorf("Expected the rejected slideshow file to be removed, but found %s", file)
+			}
+		}
+	})
+
+	t.Run("Use", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := UseSlideShow(&out, "BACKDROP SETTINGS"); err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+
+		file := slideShowFile(t, "backdrop_settings")
+		if current, _ := getPreviousWallpaper(); current != file {
+			t.Errorf("Expected the wallpaper to be %s, but got %s instead", file, current)
+		}
+		if !strings.Contains(out.String(), "Slideshow 'Backdrop Settings' is now active.") {
+			t.Errorf("Expected the slideshow to be active, but got output '%s'", out.String())
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		file := slideShowFile(t, "backdrop_settings")
+
+		var out bytes.Buffer
+		if err := DeleteSlideShow(&out, "backdrop settings"); err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, but got '%v'", file, err)
+		}
+		if _, err := os.Stat(defaultFile); err != nil {
+			t.Errorf("Expected the default slideshow to be kept, but got '%v'", err)
+		}
+		if err := DeleteSlideShow(&out, "backdrop settings"); !errors.Is(err, ErrSlideShowNotFound) {
+			t.Errorf("Expected error '%v', but got '%v' instead", ErrSlideShowNotFound, err)
+		}
+
+		var names []string
+		for _, slideShow := range viper.Get(slideShowsConfigKey).([]SavedSlideShow) {
+			names = append(names, slideShow.Name)
+		}
+		if !slices.Equal(names, []string{"water"}) {
+			t.Errorf("Expected only 'water' to stay saved, but got %v instead", names)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+func TestRejectedSlideShowRestoresFiles(t *testing.T) {
+	library := setupManagedLibrary(t)
+	wallpapers := []string{"lake.png", "tower.png"}
+
+	propertiesFile, configFile, err := os_Specifics.GetLinuxSlideShowFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("NewSlideShow", func(t *testing.T) {
+		stubSlideShowInput(t, "n\n", "tower.png")
+
+		var out bytes.Buffer
+		if err := handleSlideshow(&out, library, wallpapers, getSelector(nil), Fit{}); !errors.Is(err, ErrUserCanceledSelection) {
+			t.Fatalf("Expected error '%v', but got '%v' instead", ErrUserCanceledSelection, err)
+		}
+		if strings.Contains(out.String(), "Could not") {
+			t.Errorf("Expected the slideshow to be restored, but got output '%s'", out.String())
+		}
+
+		for _, file := range []string{propertiesFile, configFile} {
+			if _, err := os.Stat(file); !os.IsNotExist(err) {
+				t.Errorf("Expected the rejected %s to be removed, but got '%v'", file, err)
+			}
+		}
+	})
+
+	t.Run("ExistingSlideShow", func(t *testing.T) {
+		if _, err := configureSlideShow("lake.png", library, 60); err != nil {
+			t.Fatal(err)
+		}
+		previousContent := make(map[string][]byte)
+		for _, file := range []string{propertiesFile, configFile} {
+			if previousContent[file], err = os.ReadFile(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stubSlideShowInput(t, "n\n", "tower.png")
+		var out bytes.Buffer
+		if err := handleSlideshow(&out, library, wallpapers, getSelector(nil), Fit{Mode: "zoom"}); !errors.Is(err, ErrUserCanceledSelection) {
+			t.Fatalf("Expected error '%v', but got '%v' instead", ErrUserCanceledSelection, err)
+		}
+
+		for file, exp := range previousContent {
+			if content, _ := os.ReadFile(file); !bytes.Equal(content, exp) {
+				t.Errorf("Expected %s to be restored, but it now is:\n%s", file, content)
+			}
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// parseTag returns the tag in lower case, tags are single words so they can be
+// written as comma separated lists.
+func parseTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+		return "", fmt.Errorf("%w : '%s'", ErrInvalidTag, tag)
+	}
+	return tag, nil
+}
+
+func parseTags(tags []string) ([]string, error) {
+	var parsed []string
+	for _, tag := range tags {
+		tag, err := parseTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(parsed, tag) {
+			parsed = append(parsed, tag)
+		}
+	}
+	return parsed, nil
+}
+
+// hasAnyTag reports whether the image has one of the tags, every image matches no tags.
+func hasAnyTag(metadata ImageMetadata, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(metadata.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+}
+
+// resolveImage finds an image given as a path or as its name in the library.
+func resolveImage(image string) (string, error) {
+	wallpapersPath, err := getUserWallpapersPath()
+	if err != nil {
+		return "", err
+	}
+	return resolvePairImage(wallpapersPath, image)
+}
+
+func TagImage(out io.Writer, image string, tags []string) error {
+	path, err := resolveImage(image)
+	if err != nil {
+		return err
+	}
+	tags, err = parseTags(tags)
+	if err != nil {
+		return err
+	}
+
+	var imageTags []string
+	err = updateImageMetadata(path, func(metadata *ImageMetadata) {
+		for _, tag := range tags {
+			if !slices.Contains(metadata.Tags, tag) {
+				metadata.Tags = append(metadata.Tags, tag)
+			}
+		}
+		slices.Sort(metadata.Tags)
+		imageTags = metadata.Tags
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s is tagged %s.\n", image, strings.Join(imageTags, ", "))
+	return nil
+}
+
+func UntagImage(out io.Writer, image string, tags []string) error {
+	path, err := resolveImage(image)
+	if err != nil {
+		return err
+	}
+	tags, err = parseTags(tags)
+	if err != nil {
+		return err
+	}
+
+	var imageTags []string
+	err = updateImageMetadata(path, func(metadata *ImageMetadata) {
+		metadata.Tags = slices.DeleteFunc(metadata.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+		if len(metadata.Tags) == 0 {
+			metadata.Tags = nil
+		}
+		imageTags = metadata.Tags
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(imageTags) == 0 {
+		fmt.Fprintf(out, "%s has no tags left.\n", image)
+		return nil
+	}
+	fmt.Fprintf(out, "%s is tagged %s.\n", image, strings.Join(imageTags, ", "))
+	return nil
+}
+
+// ListTags prints the tags of the image, or every tag with the number of images
+// tagged with it when no image is given.
+func ListTags(out io.Writer, image string) error {
+	if image != "" {
+		path, err := resolveImage(image)
+		if err != nil {
+			return err
+		}
+		metadata, err := getImageMetadata(path)
+		if err != nil {
+			return err
+		}
+		for _, tag := range metadata.Tags {
+			fmt.Fprintln(out, tag)
+		}
+		return nil
+	}
+
+	metadata, err := loadMetadata()
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, imageMetadata := range metadata {
+		for _, tag := range imageMetadata.Tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		fmt.Fprintln(out, "No images are tagged yet, add tags with 'backdrop tag add'.")
+		return nil
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tIMAGES")
+	for _, tag := range tags {
+		fmt.Fprintf(w, "%s\t%d\n", tag, counts[tag])
+	}
+	return w.Flush()
+}
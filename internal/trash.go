@@ -0,0 +1,332 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+const trashInfoExtension = ".trashinfo"
+
+// Reasons images are moved to the trash, shown by "trash list".
+const (
+	trashReasonRemoved  = "removed"
+	trashReasonRejected = "rejected download"
+)
+
+// trashRecord remembers an image backdrop moved to the trash, where it came from and
+// when is read from its .trashinfo file.
+type trashRecord struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// TrashedImage is an image backdrop moved to the trash.
+type TrashedImage struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Reason    string    `json:"reason"`
+}
+
+// getTrashPath returns the trash directory of the freedesktop.org Trash specification,
+// with its "files" and "info" directories created. Windows has no such trash, a
+// trash with the same layout is kept next to backdrop's state instead.
+func getTrashPath() (string, error) {
+	var trashPath string
+	switch runtime.GOOS {
+	case "windows":
+		trashPath = filepath.Join(os.Getenv("LOCALAPPDATA"), "Backdrop", "Trash")
+	default:
+		var err error
+		if trashPath, err = os_Specifics.GetLinuxTrashPath(); err != nil {
+			return "", err
+		}
+	}
+
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trashPath, dir), 0700); err != nil {
+			return "", fmt.Errorf("failed to create trash directory %s: %w", trashPath, err)
+		}
+	}
+	return trashPath, nil
+}
+
+// moveToTrash moves the file into the trash with a .trashinfo file recording where it
+// came from, so file managers can restore it, and remembers that backdrop trashed it.
+// It returns the name in the trash.
+func moveToTrash(path, reason string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return "", err
+	}
+
+	trashPath, err := getTrashPath()
+	if err != nil {
+		return "", err
+	}
+
+	// Creating the info file first reserves the name, as the specification asks.
+	name, info, err := reserveTrashName(trashPath, filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: filepath.ToSlash(path)}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	if _, err := info.WriteString(content); err != nil {
+		info.Close()
+		os.Remove(info.Name())
+		return "", fmt.Errorf("failed to write trash info for %s: %w", path, err)
+	}
+	if err := info.Close(); err != nil {
+		os.Remove(info.Name())
+		return "", fmt.Errorf("failed to write trash info for %s: %w", path, err)
+	}
+
+	if err := movePath(path, filepath.Join(trashPath, "files", name)); err != nil {
+		os.Remove(info.Name())
+		return "", err
+	}
+
+	return name, updateTrashRecords(func(records []trashRecord) ([]trashRecord, bool) {
+		return append(records, trashRecord{Name: name, Reason: reason}), true
+	})
+}
+
+// reserveTrashName creates the info file of the first free name, "forest.jpg",
+// "forest.2.jpg" and so on.
+func reserveTrashName(trashPath, base string) (string, *os.File, error) {
+	extension := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, extension)
+
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s.%d%s", stem, i, extension)
+		}
+
+		if _, err := os.Lstat(filepath.Join(trashPath, "files", name)); err == nil {
+			continue
+		}
+		info, err := os.OpenFile(filepath.Join(trashPath, "info", name+trashInfoExtension), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to create trash info for %s: %w", base, err)
+		}
+		return name, info, nil
+	}
+}
+
+func getTrashRecordsFile() (string, error) {
+	statePath, err := getStatePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(statePath, "trash.json"), nil
+}
+
+func loadTrashRecords() ([]trashRecord, error) {
+	recordsFile, err := getTrashRecordsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(recordsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash file %s: %w", recordsFile, err)
+	}
+
+	var records []trashRecord
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse trash file %s: %w", recordsFile, err)
+	}
+	return records, nil
+}
+
+// trashMutex serializes trash record updates of one process, the file lock those of
+// other processes.
+var trashMutex sync.Mutex
+
+// updateTrashRecords saves the records update returns when it reports a change,
+// holding the trash lock so concurrent updates are not lost.
+func updateTrashRecords(update func(records []trashRecord) ([]trashRecord, bool)) error {
+	recordsFile, err := getTrashRecordsFile()
+	if err != nil {
+		return err
+	}
+
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+
+	return withFileLock(recordsFile, func() error {
+		records, err := loadTrashRecords()
+		if err != nil {
+			return err
+		}
+
+		records, changed := update(records)
+		if !changed {
+			return nil
+		}
+		return saveTrashRecords(records)
+	})
+}
+
+func saveTrashRecords(records []trashRecord) error {
+	recordsFile, err := getTrashRecordsFile()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(recordsFile, content, 0644)
+}
+
+// readTrashInfo reads the original path and deletion date of an item in the trash.
+func readTrashInfo(trashPath, name string) (string, time.Time, error) {
+	content, err := os.ReadFile(filepath.Join(trashPath, "info", name+trashInfoExtension))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var path string
+	var deletedAt time.Time
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+		switch key {
+		case "Path":
+			if path, err = url.PathUnescape(value); err != nil {
+				return "", time.Time{}, fmt.Errorf("failed to read trash info of %s: %w", name, err)
+			}
+			path = filepath.FromSlash(path)
+		case "DeletionDate":
+			deletedAt, _ = time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return "", time.Time{}, fmt.Errorf("trash info of %s has no absolute path", name)
+	}
+	return path, deletedAt, nil
+}
+
+// trashedImages returns the images backdrop moved to the trash that are still in it,
+// oldest first. Images restored or deleted from elsewhere are forgotten.
+func trashedImages() ([]TrashedImage, error) {
+	trashPath, err := getTrashPath()
+	if err != nil {
+		return nil, err
+	}
+	var images []TrashedImage
+	err = updateTrashRecords(func(records []trashRecord) ([]trashRecord, bool) {
+		var kept []trashRecord
+		for _, record := range records {
+			if _, err := os.Lstat(filepath.Join(trashPath, "files", record.Name)); err != nil {
+				continue
+			}
+			path, deletedAt, err := readTrashInfo(trashPath, record.Name)
+			if err != nil {
+				continue
+			}
+
+			kept = append(kept, record)
+			images = append(images, TrashedImage{Name: record.Name, Path: path, DeletedAt: deletedAt, Reason: record.Reason})
+		}
+		return kept, len(kept) != len(records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ListTrash prints the images backdrop moved to the trash, newest first.
+func ListTrash(out io.Writer) error {
+	images, err := trashedImages()
+	if err != nil {
+		return err
+	}
+	slices.Reverse(images)
+	if isStructuredOutput() {
+		if images == nil {
+			images = []TrashedImage{}
+		}
+		return writeStructured(out, images)
+	}
+
+	if len(images) == 0 {
+		fmt.Fprintln(out, "No images moved to the trash by backdrop.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDELETED AT\tREASON\tORIGINAL PATH")
+	for _, image := range images {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", image.Name, image.DeletedAt.Format("2006-01-02 15:04"), orDash(image.Reason), image.Path)
+	}
+	return w.Flush()
+}
+
+// RestoreTrash moves images backdrop moved to the trash back to where they were, by
+// their name in the trash.
+func RestoreTrash(out io.Writer, names []string) error {
+	trashPath, err := getTrashPath()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		images, err := trashedImages()
+		if err != nil {
+			return err
+		}
+		index := slices.IndexFunc(images, func(image TrashedImage) bool { return image.Name == name })
+		if index < 0 {
+			return fmt.Errorf("%w : %s", ErrTrashItemNotFound, name)
+		}
+		image := images[index]
+
+		if _, err := os.Lstat(image.Path); err == nil {
+			return fmt.Errorf("%w : %s", ErrImageExists, image.Path)
+		}
+		if err := os.MkdirAll(filepath.Dir(image.Path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", image.Path, err)
+		}
+		if err := movePath(filepath.Join(trashPath, "files", name), image.Path); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(trashPath, "info", name+trashInfoExtension)); err != nil {
+			return fmt.Errorf("failed to remove trash info of %s: %w", name, err)
+		}
+
+		fmt.Fprintf(out, "Restored %s to %s.\n", name, image.Path)
+	}
+
+	// Restored images are no longer in the trash, listing forgets them.
+	_, err = trashedImages()
+	return err
+}
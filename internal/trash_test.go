@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTrash(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	downloads := t.TempDir()
+	first, second := filepath.Join(downloads, "dune.jpg"), filepath.Join(downloads, "nested", "dune.jpg")
+	for _, file := range []string{first, second} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if name, err := moveToTrash(first, trashReasonRejected); err != nil || name != "dune.jpg" {
+		t.Fatalf("Expected 'dune.jpg' in the trash, but got '%s', '%v'", name, err)
+	}
+	if name, err := moveToTrash(second, trashReasonRemoved); err != nil || name != "dune.2.jpg" {
+		t.Fatalf("Expected 'dune.2.jpg' in the trash, but got '%s', '%v'", name, err)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("Expected the file to be moved to the trash, but got '%v'", err)
+	}
+
+	// Items trashed by something else are not listed.
+	trashPath, err := getTrashPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(trashPath, "files", "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := ListTrash(&out); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "dune.2.jpg") || !strings.Contains(lines[2], "rejected download") || !strings.HasSuffix(lines[2], first) {
+		t.Errorf("Expected both images newest first, but got '%s'", out.String())
+	}
+
+	if err := os.WriteFile(first, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreTrash(&out, []string{"dune.jpg"}); !errors.Is(err, ErrImageExists) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrImageExists, err)
+	}
+	if err := RestoreTrash(&out, []string{"notes.txt"}); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrTrashItemNotFound, err)
+	}
+
+	if err := RestoreTrash(&out, []string{"dune.2.jpg"}); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	if content, err := os.ReadFile(second); err != nil || string(content) != second {
+		t.Errorf("Expected the image restored where it was, but got '%s', '%v'", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(trashPath, "info", "dune.2.jpg"+trashInfoExtension)); !os.IsNotExist(err) {
+		t.Errorf("Expected the trash info to be removed, but got '%v'", err)
+	}
+	if images, err := trashedImages(); err != nil || len(images) != 1 || images[0].Name != "dune.jpg" {
+		t.Errorf("Expected only 'dune.jpg' left in the trash, but got %v, '%v'", images, err)
+	}
+}
+
+func TestMoveToTrashConcurrently(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	const files = 20
+	downloads := t.TempDir()
+	var wg sync.WaitGroup
+	errs := make(chan error, files)
+	for i := 0; i < files; i++ {
+		file := filepath.Join(downloads, fmt.Sprintf("%d.jpg", i))
+		if err := os.WriteFile(file, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := moveToTrash(file, trashReasonRemoved)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected NO error, but got '%v' instead", err)
+		}
+	}
+
+	images, err := trashedImages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != files {
+		t.Errorf("Expected %d images in the trash, but got %d instead", files, len(images))
+	}
+}
@@ -3,10 +3,14 @@ package internal
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/janmichaelse/backdrop/internal/os_Specifics"
@@ -16,11 +20,31 @@ import (
 const (
 	gnomeSchema = "org.gnome.desktop.background"
 	mateSchema  = "org.mate.desktop.background"
+
+	imagePathEnv = "BACKDROP_IMAGE_PATH"
 )
 
 func configureWallpaperPath(path string) error {
-	viper.Set("WallpapersPath", path)
+	viper.Set(wallpapersPathConfigKey, path)
+
+	if err := writeConfig(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotConfigureImagePath, err)
+	}
+
+	return nil
+}
+
+// writeConfig persists every viper setting to the platform config file.
+func writeConfig() error {
+	configPath, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	return viper.WriteConfigAs(configPath)
+}
 
+func getConfigFilePath() (string, error) {
 	var configPath string
 	var err error
 
@@ -30,70 +54,243 @@ func configureWallpaperPath(path string) error {
 	case "linux":
 		configPath, err = os_Specifics.GetLinuxConfigFilePath()
 	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to get config file path: %w", err)
+		return "", fmt.Errorf("failed to get config file path: %w", err)
 	}
+	return configPath, nil
+}
 
-	if err := viper.WriteConfigAs(configPath); err != nil {
-		return fmt.Errorf("%w : %v", ErrCouldNotConfigureImagePath, err)
+// getUserWallpapersPath returns the first library root, where downloaded and
+// generated images are saved.
+func getUserWallpapersPath() (string, error) {
+	roots, err := getLibraryRoots()
+	if err != nil {
+		return "", err
 	}
+	return roots[0].Path, nil
+}
 
-	return nil
+// LibraryRoot is a directory images are listed from. Origin tells where it was
+// configured.
+type LibraryRoot struct {
+	Name   string
+	Path   string
+	Origin string
 }
 
-func getUserWallpapersPath() (string, error) {
-	var configPath string
-	homePath, err := os.UserHomeDir()
+// libraryRootGroups returns the configured roots from the highest precedence to the
+// lowest: BACKDROP_IMAGE_PATH, the config file and the default paths. The first
+// group with an existing directory is used, roots of a group are merged.
+func libraryRootGroups() ([][]LibraryRoot, error) {
+	var groups [][]LibraryRoot
+
+	var envRoots []LibraryRoot
+	for _, root := range filepath.SplitList(os.Getenv(imagePathEnv)) {
+		if root != "" {
+			envRoots = append(envRoots, parseLibraryRoot(root, imagePathEnv))
+		}
+	}
+	groups = append(groups, envRoots)
+
+	settings, err := loadSettings()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	profileName, profile, err := settingsProfile(settings)
+	if err != nil {
+		return nil, err
 	}
 
-	wallpapersPath, ok := viper.Get("WallpapersPath").(string)
-	if ok {
-		if stat, err := os.Stat(wallpapersPath); err == nil && stat.IsDir() {
-			return wallpapersPath, nil
+	var configRoots []LibraryRoot
+	if len(profile.Paths) > 0 {
+		// The profile's paths take the place of the ones in the config file.
+		for _, root := range profile.Paths {
+			configRoots = append(configRoots, parseLibraryRoot(root, "profile "+profileName))
+		}
+	} else {
+		if settings.WallpapersPath != "" {
+			configRoots = append(configRoots, LibraryRoot{Path: settings.WallpapersPath, Origin: "config WallpapersPath"})
+		}
+		for _, root := range settings.LibraryRoots {
+			configRoots = append(configRoots, parseLibraryRoot(root, "config LibraryRoots"))
 		}
 	}
+	groups = append(groups, configRoots)
+
+	homePath, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
 
+	var defaultPaths []string
 	switch runtime.GOOS {
 	case "linux":
-		configPath, err = os_Specifics.GetLinuxConfigPath()
+		dataPath, err := os_Specifics.GetLinuxDataPath()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
+		defaultPaths = append(defaultPaths, dataPath)
 	case "windows":
-		configPath = os_Specifics.GetWindowsConfigPath()
+		defaultPaths = append(defaultPaths, os_Specifics.GetWindowsConfigPath())
+	}
+	defaultPaths = append(defaultPaths, filepath.Join(homePath, "Pictures", "wallpapers"))
+
+	// Defaults are not merged, the first one that exists has priority.
+	for _, path := range defaultPaths {
+		groups = append(groups, []LibraryRoot{{Path: path, Origin: "default"}})
+	}
+	return groups, nil
+}
+
+// parseLibraryRoot reads a root written as "path" or "name=path", the name is what
+// images of the root are prefixed with.
+func parseLibraryRoot(root, origin string) LibraryRoot {
+	if name, path, ok := strings.Cut(root, "="); ok && name != "" && !strings.ContainsAny(name, `/\`) {
+		return LibraryRoot{Name: name, Path: path, Origin: origin}
+	}
+	return LibraryRoot{Path: root, Origin: origin}
+}
+
+func getLibraryRoots() ([]LibraryRoot, error) {
+	groups, err := libraryRootGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		if roots := existingLibraryRoots(group); len(roots) > 0 {
+			return roots, nil
+		}
+	}
+	return nil, ErrNoValidImagesPath
+}
+
+// existingLibraryRoots drops missing and repeated directories and names the others
+// uniquely, after their directory unless a name was given.
+func existingLibraryRoots(group []LibraryRoot) []LibraryRoot {
+	roots := make([]LibraryRoot, 0, len(group))
+	names := make(map[string]bool, len(group))
+	for _, root := range group {
+		root.Path = filepath.Clean(expandHome(root.Path))
+		if !isDirectory(root.Path) {
+			continue
+		}
+		if slices.ContainsFunc(roots, func(existing LibraryRoot) bool { return existing.Path == root.Path }) {
+			continue
+		}
 
-		// Check if config directory exists
-		if stat, err := os.Stat(configPath); err == nil && stat.IsDir() {
-			return configPath, nil
+		if root.Name == "" {
+			root.Name = filepath.Base(root.Path)
 		}
+		name := root.Name
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s-%d", root.Name, i)
+		}
+		root.Name = name
+		names[name] = true
+		roots = append(roots, root)
+	}
+	return roots
+}
 
-		if stat, err := os.Stat(configPath); err == nil && stat.IsDir() {
-			return configPath, nil
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || rest[0] == '/' || rest[0] == filepath.Separator) {
+		if homePath, err := os.UserHomeDir(); err == nil {
+			return homePath + rest
 		}
+	}
+	return path
+}
+
+// getLibraryWallpapers lists the files of every library root. With more than one
+// root names start with the name of their root, e.g. "nas/forest.jpg".
+func getLibraryWallpapers() ([]string, error) {
+	roots, err := getLibraryRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	inProfile, err := profileImageFilter()
+	if err != nil {
+		return nil, err
+	}
 
-		picturesPath := filepath.Join(homePath, "Pictures", "wallpapers")
-		if stat, err := os.Stat(picturesPath); err == nil && stat.IsDir() {
-			return picturesPath, nil
+	var names []string
+	for _, root := range roots {
+		wallpapers, err := getWallpapers(root.Path)
+		if err != nil {
+			return nil, err
 		}
+		for _, wallpaper := range wallpapers {
+			if inProfile(filepath.Join(root.Path, wallpaper)) {
+				names = append(names, libraryRootName(roots, root, wallpaper))
+			}
+		}
+	}
+	return names, nil
+}
+
+func libraryRootName(roots []LibraryRoot, root LibraryRoot, file string) string {
+	if len(roots) == 1 {
+		return file
+	}
+	return root.Name + "/" + filepath.ToSlash(file)
+}
+
+// libraryImagePath turns a name from the library listing into the path of the image.
+// Absolute paths are kept and names without a known root are in the wallpapers path.
+func libraryImagePath(wallpapersPath, name string) string {
+	if filepath.IsAbs(name) {
+		return name
 	}
 
-	return "", ErrNoValidImagesPath
+	if roots, err := getLibraryRoots(); err == nil && len(roots) > 1 {
+		for _, root := range roots {
+			if file, ok := strings.CutPrefix(name, root.Name+"/"); ok {
+				return filepath.Join(root.Path, filepath.FromSlash(file))
+			}
+		}
+	}
+	return filepath.Join(wallpapersPath, name)
 }
 
+// getWallpapers lists the files in the directory and its folders, named by their
+// slash separated path in it. Hidden folders, such as ".thumbnails", are skipped.
 func getWallpapers(path string) ([]string, error) {
-	fileEntries, err := os.ReadDir(path)
+	// WalkDir doesn't follow a root that is a symlink, such as a migrated images path.
+	path, err := filepath.EvalSymlinks(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
 	}
 
-	files := make([]string, 0, len(fileEntries))
-	for _, file := range fileEntries {
-		files = append(files, file.Name())
+	var files []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file == path {
+			return nil
+		}
+		if entry.IsDir() {
+			if strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
 	}
 
 	return files, nil
@@ -114,33 +311,19 @@ func listSchemas() (*bytes.Buffer, error) {
 	return &outListSchemas, nil
 }
 
+// getPreviousWallpaper returns the image on screen, processed copies are reported as
+// the image they were made from.
 func getPreviousWallpaper() (string, error) {
-	switch runtime.GOOS {
-	case "linux":
-		return getPreviousWallpaperLinux()
-	case "windows":
-		return getPreviousWallpaperWindows()
-	}
-	return "", fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
-
-}
-
-func getPreviousWallpaperLinux() (string, error) {
-	schemas, err := listSchemas()
+	backend, err := getBackend()
 	if err != nil {
 		return "", err
 	}
 
-	if strings.Contains(schemas.String(), gnomeSchema) {
-		return getGsettingsWallpaper(gnomeSchema)
-	}
-
-	if strings.Contains(schemas.String(), mateSchema) {
-		return getGsettingsWallpaper(mateSchema)
+	wallpaper, err := backend.CurrentWallpaper()
+	if err != nil {
+		return "", err
 	}
-
-	return "", ErrNoCompatibleDesktopEnvironment
-
+	return sourceWallpaper(wallpaper), nil
 }
 
 func getPreviousWallpaperWindows() (string, error) {
@@ -159,15 +342,22 @@ func getPreviousWallpaperWindows() (string, error) {
 	return wallpaperPath, nil
 }
 
-func setWallpaper(wallpaper string) error {
-	switch runtime.GOOS {
-	case "linux":
-		return setWallpaperLinux(wallpaper)
-	case "windows":
-		return setWallpaperWindows(wallpaper)
+func setWallpaper(out io.Writer, wallpaper string) error {
+	backend, err := getBackend()
+	if err != nil {
+		return err
 	}
-	return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	return applyWallpaper(out, backend, allOutputs, wallpaper, Fit{}, nil)
+}
 
+// setWallpaperFit sets the wallpaper with a fit and effects chosen by the user,
+// falling back to the image's defaults when none were chosen.
+func setWallpaperFit(out io.Writer, wallpaper string, fit Fit, effects Effects) error {
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+	return applyWallpaper(out, backend, allOutputs, wallpaper, fit, effects)
 }
 
 func setWallpaperWindows(wallpaper string) error {
@@ -193,56 +383,202 @@ public class Wallpaper {
 	return nil
 }
 
-func setWallpaperLinux(wallpaper string) error {
-	schemas, err := listSchemas()
-	if err != nil {
-		return err
-	}
+func setGsettingsWallpaper(schema, wallpaper string) error {
+	return setGsettingsWallpaperPair(schema, wallpaper, wallpaper)
+}
 
-	wallpaperURI := fmt.Sprintf("file://%s", wallpaper)
+// setGsettingsWallpaperPair sets the image GNOME shows in light and in dark mode,
+// schemas without a dark variant only get the light image.
+func setGsettingsWallpaperPair(schema, light, dark string) error {
+	cmdSetPicture := exec.Command("gsettings", "set", schema, "picture-uri", wallpaperToURI(light))
+	if err := cmdSetPicture.Run(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	}
 
-	if strings.Contains(schemas.String(), "gnome.desktop.background") {
-		cmdSetPicture := exec.Command("gsettings", "set", "org.gnome.desktop.background", "picture-uri", wallpaperURI)
-		if err := cmdSetPicture.Run(); err != nil {
+	if schema == gnomeSchema {
+		cmdSetPictureDark := exec.Command("gsettings", "set", schema, "picture-uri-dark", wallpaperToURI(dark))
+		if err := cmdSetPictureDark.Run(); err != nil {
 			return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
 		}
+	}
 
-		cmdSetPictureDark := exec.Command("gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", wallpaperURI)
-		if err := cmdSetPictureDark.Run(); err != nil {
-			return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	return nil
+}
+
+func getGsettingsWallpaper(schema string) (string, error) {
+	cmd := exec.Command("gsettings", "get", schema, "picture-uri")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	uri := strings.Trim(strings.TrimSpace(out.String()), "'")
+	return wallpaperFromURI(uri), nil
+}
+
+// wallpaperToURI turns paths into file URIs, escaping characters like '#', '?' and '%'
+// so wallpaperFromURI gets the same path back.
+func wallpaperToURI(wallpaper string) string {
+	if strings.Contains(wallpaper, "://") {
+		return wallpaper
+	}
+	return (&url.URL{Scheme: "file", Path: wallpaper}).String()
+}
+
+// wallpaperFromURI turns file URIs into paths and keeps any other URI as it is,
+// so setting it again points at the same resource.
+func wallpaperFromURI(uri string) string {
+	if !strings.Contains(uri, "://") {
+		return uri
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		// URIs written unescaped, like earlier versions did, are still paths.
+		if path, found := strings.CutPrefix(uri, "file://"); found {
+			return path
 		}
+		return uri
+	}
+	if parsed.Scheme != "file" {
+		return uri
+	}
+	return parsed.Path
+}
 
-		return nil
+// gsettingsStateKeys are the background keys captured in a WallpaperState, keys a
+// schema does not have are skipped.
+var gsettingsStateKeys = []string{"picture-uri", "picture-uri-dark", "picture-filename", "picture-options", "primary-color", "secondary-color", "color-shading-type"}
+
+// captureGsettings returns the keys in GVariant text format, which gsettings set
+// accepts back as is.
+func captureGsettings(schema string) (map[string]string, error) {
+	keys, err := commandOutput("gsettings", "list-keys", schema)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.Contains(schemas.String(), "mate.desktop.background") {
-		cmdSetPicture := exec.Command("gsettings", "set", "org.mate.desktop.background", "picture-uri", wallpaperURI)
-		err := cmdSetPicture.Run()
+	settings := make(map[string]string)
+	for _, key := range strings.Fields(keys) {
+		if !slices.Contains(gsettingsStateKeys, key) {
+			continue
+		}
+
+		value, err := commandOutput("gsettings", "get", schema, key)
 		if err != nil {
-			return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+			return nil, err
+		}
+		settings[key] = strings.TrimSpace(value)
+	}
+	return settings, nil
+}
+
+func restoreGsettings(schema string, settings map[string]string) error {
+	for _, key := range gsettingsStateKeys {
+		value, ok := settings[key]
+		if !ok {
+			continue
 		}
 
-		return nil
+		if err := exec.Command("gsettings", "set", schema, key, value).Run(); err != nil {
+			return fmt.Errorf("%w : %s: %v", ErrCouldNotSetBackground, key, err)
+		}
 	}
+	return nil
+}
 
-	return ErrNoCompatibleDesktopEnvironment
+// gsettingsFitKeys maps every fit field to its key in the background schemas.
+var gsettingsFitKeys = []struct {
+	key   string
+	field func(*Fit) *string
+}{
+	{"picture-options", func(fit *Fit) *string { return &fit.Mode }},
+	{"primary-color", func(fit *Fit) *string { return &fit.PrimaryColor }},
+	{"secondary-color", func(fit *Fit) *string { return &fit.SecondaryColor }},
+	{"color-shading-type", func(fit *Fit) *string { return &fit.Shading }},
 }
 
-func getGsettingsWallpaper(schema string) (string, error) {
-	cmd := exec.Command("gsettings", "get", schema, "picture-uri")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		return "", err
+func setGsettingsFit(schema string, fit Fit) error {
+	for _, fitKey := range gsettingsFitKeys {
+		value := *fitKey.field(&fit)
+		if value == "" {
+			continue
+		}
+
+		if err := exec.Command("gsettings", "set", schema, fitKey.key, value).Run(); err != nil {
+			return fmt.Errorf("%w : %s: %v", ErrCouldNotSetBackground, fitKey.key, err)
+		}
+	}
+	return nil
+}
+
+func getGsettingsFit(schema string) (Fit, error) {
+	var fit Fit
+	for _, fitKey := range gsettingsFitKeys {
+		value, err := commandOutput("gsettings", "get", schema, fitKey.key)
+		if err != nil {
+			return Fit{}, err
+		}
+		*fitKey.field(&fit) = strings.Trim(strings.TrimSpace(value), "'")
+	}
+	return fit, nil
+}
+
+// setWindowsFit writes the style and background color to the registry, then sets
+// the current wallpaper again since Windows only reads them when it changes.
+func setWindowsFit(fit Fit) error {
+	if !commandExist("powershell") {
+		return fmt.Errorf("%w : %s", ErrCommandNotFound, "powershell")
 	}
 
-	uri := strings.ReplaceAll(strings.Trim(out.String(), "\n"), "'", "")
-	if strings.Contains(uri, "://") {
-		parts := strings.SplitN(uri, "://", 2)
-		if len(parts) == 2 {
-			return parts[1], nil
+	var script strings.Builder
+	if fit.Mode != "" {
+		style, tile := fit.WindowsStyle()
+		fmt.Fprintf(&script, "Set-ItemProperty -Path 'HKCU:\\Control Panel\\Desktop' -Name WallpaperStyle -Value '%d'\n", style)
+		fmt.Fprintf(&script, "Set-ItemProperty -Path 'HKCU:\\Control Panel\\Desktop' -Name TileWallpaper -Value '%d'\n", tile)
+	}
+	if fit.PrimaryColor != "" {
+		r, g, b, err := os_Specifics.ParseHexColor(fit.PrimaryColor)
+		if err != nil {
+			return err
 		}
-		return "", fmt.Errorf("unexpected URI format: %s", uri)
+		fmt.Fprintf(&script, "Set-ItemProperty -Path 'HKCU:\\Control Panel\\Colors' -Name Background -Value '%d %d %d'\n", r, g, b)
+	}
+
+	if err := exec.Command("powershell", "-Command", script.String()).Run(); err != nil {
+		return fmt.Errorf("%w : %v", ErrCouldNotSetBackground, err)
+	}
+
+	wallpaper, err := getPreviousWallpaperWindows()
+	if err != nil {
+		return err
+	}
+	return setWallpaperWindows(wallpaper)
+}
+
+func getWindowsFit() (Fit, error) {
+	if !commandExist("powershell") {
+		return Fit{}, fmt.Errorf("%w : %s", ErrCommandNotFound, "powershell")
 	}
-	return uri, nil
+
+	out, err := commandOutput("powershell", "-Command", `$desktop = Get-ItemProperty -Path 'HKCU:\Control Panel\Desktop'
+$colors = Get-ItemProperty -Path 'HKCU:\Control Panel\Colors'
+"$($desktop.WallpaperStyle) $($desktop.TileWallpaper) $($colors.Background)"`)
+	if err != nil {
+		return Fit{}, err
+	}
+
+	var style, tile, r, g, b int
+	if _, err := fmt.Sscanf(strings.TrimSpace(out), "%d %d %d %d %d", &style, &tile, &r, &g, &b); err != nil {
+		return Fit{}, fmt.Errorf("unexpected wallpaper style values '%s': %w", strings.TrimSpace(out), err)
+	}
+
+	color := fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	return Fit{
+		Mode:           os_Specifics.PictureModeFromWindowsStyle(style, tile),
+		PrimaryColor:   color,
+		SecondaryColor: color,
+		Shading:        "solid",
+	}, nil
 }
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janmichaelse/backdrop/internal/os_Specifics"
+)
+
+const defaultSnapshotName = "default"
+
+// WallpaperState is a snapshot of everything the desktop shows as background, so it
+// can be put back exactly as it was. Settings holds the raw values of backends that
+// have more state than an image and a fit, e.g. GNOME's dark variant or one image
+// per output on swww.
+type WallpaperState struct {
+	Backend   string            `json:"backend"`
+	Wallpaper string            `json:"wallpaper"`
+	Fit       Fit               `json:"fit,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"`
+	SavedAt   time.Time         `json:"saved_at"`
+}
+
+// settingsBackend is implemented by backends with state beyond the image and fit.
+type settingsBackend interface {
+	CaptureSettings() (map[string]string, error)
+	RestoreSettings(settings map[string]string) error
+}
+
+func captureWallpaperState() (*WallpaperState, error) {
+	backend, err := getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return captureBackendState(backend)
+}
+
+func captureBackendState(backend wallpaperBackend) (*WallpaperState, error) {
+	wallpaper, err := backend.CurrentWallpaper()
+	if err != nil {
+		return nil, err
+	}
+
+	fit, err := backend.CurrentFit()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &WallpaperState{
+		Backend:   backend.Name(),
+		Wallpaper: wallpaper,
+		Fit:       fit,
+		SavedAt:   time.Now(),
+	}
+
+	if settingsBackend, ok := backend.(settingsBackend); ok {
+		if state.Settings, err = settingsBackend.CaptureSettings(); err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// Restore puts the state back. Snapshots taken on another desktop only restore the
+// image and fit, their settings mean nothing to the current backend.
+func (s *WallpaperState) Restore() error {
+	backend, err := getBackend()
+	if err != nil {
+		return err
+	}
+	return s.restoreTo(backend)
+}
+
+func (s *WallpaperState) restoreTo(backend wallpaperBackend) error {
+	if settingsBackend, ok := backend.(settingsBackend); ok && backend.Name() == s.Backend && len(s.Settings) > 0 {
+		return settingsBackend.RestoreSettings(s.Settings)
+	}
+
+	if s.Wallpaper != "" {
+		if err := backend.SetWallpaper(allOutputs, s.Wallpaper); err != nil {
+			return err
+		}
+	}
+
+	if s.Fit.IsZero() {
+		return nil
+	}
+	return backend.SetFit(allOutputs, s.Fit)
+}
+
+func getSnapshotFile(name string) (string, error) {
+	if name == "" {
+		name = defaultSnapshotName
+	}
+
+	fileName := os_Specifics.SlideShowFileName(name)
+	if fileName == "" {
+		return "", fmt.Errorf("%w : %s", ErrInvalidSnapshotName, name)
+	}
+
+	statePath, err := getStatePath()
+	if err != nil {
+		return "", err
+	}
+
+	snapshotsPath := filepath.Join(statePath, "snapshots")
+	if err := os.MkdirAll(snapshotsPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshots directory %s: %w", snapshotsPath, err)
+	}
+	return filepath.Join(snapshotsPath, fileName+".json"), nil
+}
+
+func SaveSnapshot(out io.Writer, name string) error {
+	snapshotFile, err := getSnapshotFile(name)
+	if err != nil {
+		return err
+	}
+
+	state, err := captureWallpaperState()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(snapshotFile, content, 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Saved snapshot '%s' of %s.\n", snapshotName(snapshotFile), state.Wallpaper)
+	return nil
+}
+
+func RestoreSnapshot(out io.Writer, name string) error {
+	snapshotFile, err := getSnapshotFile(name)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(snapshotFile)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w : %s", ErrSnapshotNotFound, snapshotName(snapshotFile))
+	}
+	if err != nil {
+		return err
+	}
+
+	var state WallpaperState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return fmt.Errorf("failed to parse snapshot %s: %w", snapshotFile, err)
+	}
+
+	if err := state.Restore(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Restored snapshot '%s' of %s.\n", snapshotName(snapshotFile), state.Wallpaper)
+	return nil
+}
+
+func ListSnapshots(out io.Writer) error {
+	snapshotFile, err := getSnapshotFile(defaultSnapshotName)
+	if err != nil {
+		return err
+	}
+
+	snapshotFiles, err := filepath.Glob(filepath.Join(filepath.Dir(snapshotFile), "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(snapshotFiles)
+
+	if len(snapshotFiles) == 0 {
+		fmt.Fprintln(out, "No snapshots saved, save one with 'backdrop snapshot save'.")
+		return nil
+	}
+
+	for _, snapshotFile := range snapshotFiles {
+		var state WallpaperState
+		content, err := os.ReadFile(snapshotFile)
+		if err == nil {
+			err = json.Unmarshal(content, &state)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s\t(unreadable: %v)\n", snapshotName(snapshotFile), err)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", snapshotName(snapshotFile), state.SavedAt.Format(time.DateTime), state.Backend, state.Wallpaper)
+	}
+	return nil
+}
+
+func snapshotName(snapshotFile string) string {
+	return strings.TrimSuffix(filepath.Base(snapshotFile), ".json")
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// fakeSettingsBackend also keeps settings, like GNOME's dark variant.
+type fakeSettingsBackend struct {
+	*fakeBackend
+	settings map[string]string
+}
+
+func (b *fakeSettingsBackend) CaptureSettings() (map[string]string, error) {
+	settings := make(map[string]string, len(b.settings))
+	for key, value := range b.settings {
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+func (b *fakeSettingsBackend) RestoreSettings(settings map[string]string) error {
+	b.settings = settings
+	return nil
+}
+
+func TestWallpaperFromURI(t *testing.T) {
+	testCases := map[string]string{
+		"/home/user/image.jpg":                  "/home/user/image.jpg",
+		"file:///home/user/image.jpg":           "/home/user/image.jpg",
+		"file:///home/user/my%20image.jpg":      "/home/user/my image.jpg",
+		"resource:///org/gnome/shell/theme.png": "resource:///org/gnome/shell/theme.png",
+		"https://example.com/image.jpg":         "https://example.com/image.jpg",
+		"file:///home/user/100%.jpg":            "/home/user/100%.jpg",
+	}
+
+	for uri, expWallpaper := range testCases {
+		if got := wallpaperFromURI(uri); got != expWallpaper {
+			t.Errorf("Expected '%s' for '%s', but got '%s' instead", expWallpaper, uri, got)
+		}
+	}
+}
+
+func TestWallpaperURIRoundTrip(t *testing.T) {
+	testCases := map[string]string{
+		"/home/user/image.jpg":      "file:///home/user/image.jpg",
+		"/a/b #1.jpg":               "file:///a/b%20%231.jpg",
+		"/a/what?.jpg":              "file:///a/what%3F.jpg",
+		"/a/100%.jpg":               "file:///a/100%25.jpg",
+		"/a/My%20Pics/sea.jpg":      "file:///a/My%2520Pics/sea.jpg",
+		"/a/Tom & Jerry/ünï.jpg":    "file:///a/Tom%20&%20Jerry/%C3%BCn%C3%AF.jpg",
+		"https://example.com/a.jpg": "https://example.com/a.jpg",
+	}
+
+	for wallpaper, expURI := range testCases {
+		uri := wallpaperToURI(wallpaper)
+		if uri != expURI {
+			t.Errorf("Expected URI '%s' for '%s', but got '%s' instead", expURI, wallpaper, uri)
+		}
+		if got := wallpaperFromURI(uri); got != wallpaper {
+			t.Errorf("Expected '%s' back from '%s', but got '%s' instead", wallpaper, uri, got)
+		}
+	}
+}
+
+func TestWallpaperStateRestore(t *testing.T) {
+	backend := newFakeBackend(allOutputs)
+	previousFit := Fit{Mode: "centered", PrimaryColor: "#000000"}
+	backend.SetWallpaper(allOutputs, "/images/previous.jpg")
+	backend.SetFit(allOutputs, previousFit)
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/previous.jpg"})
+	<-backend.fitted
+
+	state, err := captureBackendState(backend)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	backend.SetWallpaper(allOutputs, "/images/new.jpg")
+	backend.SetFit(allOutputs, Fit{Mode: "zoom"})
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/new.jpg"})
+	<-backend.fitted
+
+	if err := state.restoreTo(backend); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/previous.jpg"})
+	if got := <-backend.fitted; got != previousFit {
+		t.Errorf("Expected fit '%+v' to be restored, but got '%+v' instead", previousFit, got)
+	}
+}
+
+func TestWallpaperStateRestoresSettings(t *testing.T) {
+	backend := &fakeSettingsBackend{
+		fakeBackend: newFakeBackend(allOutputs),
+		settings:    map[string]string{"picture-uri": "'file:///light.jpg'", "picture-uri-dark": "'file:///dark.jpg'"},
+	}
+
+	state, err := captureBackendState(backend)
+	if err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	backend.settings = map[string]string{"picture-uri": "'file:///new.jpg'", "picture-uri-dark": "'file:///new.jpg'"}
+	if err := state.restoreTo(backend); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	if backend.settings["picture-uri-dark"] != "'file:///dark.jpg'" {
+		t.Errorf("Expected the dark variant to be restored, but got '%v' instead", backend.settings)
+	}
+	backend.expectNothing(t)
+
+	state.Backend = "other"
+	state.Wallpaper = "/images/other.jpg"
+	if err := state.restoreTo(backend); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/other.jpg"})
+}
+
+func TestSnapshots(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	backend := newFakeBackend(allOutputs)
+	originalGetBackend := getBackend
+	getBackend = func() (wallpaperBackend, error) { return backend, nil }
+	defer func() { getBackend = originalGetBackend }()
+
+	backend.SetWallpaper(allOutputs, "/images/saved.jpg")
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/saved.jpg"})
+
+	if err := SaveSnapshot(&bytes.Buffer{}, "Before Party"); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+
+	backend.SetWallpaper(allOutputs, "/images/party.jpg")
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/party.jpg"})
+
+	var out bytes.Buffer
+	if err := ListSnapshots(&out); err != nil || !bytes.Contains(out.Bytes(), []byte("before_party")) {
+		t.Errorf("Expected snapshot 'before_party' to be listed, but got '%s' and '%v'", out.String(), err)
+	}
+
+	if err := RestoreSnapshot(&bytes.Buffer{}, "before party"); err != nil {
+		t.Fatalf("Expected NO error, but got '%v' instead", err)
+	}
+	backend.expect(t, fakeWallpaper{allOutputs, "/images/saved.jpg"})
+
+	if err := RestoreSnapshot(&bytes.Buffer{}, "missing"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Expected error '%v', but got '%v' instead", ErrSnapshotNotFound, err)
+	}
+}
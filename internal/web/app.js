@@ -0,0 +1,152 @@
+"use strict";
+
+// The token is handed over in the URL fragment so it never reaches server logs,
+// then kept in localStorage for the next visit.
+const hashToken = new URLSearchParams(location.hash.slice(1)).get("token");
+if (hashToken) {
+  localStorage.setItem("backdrop-token", hashToken);
+  history.replaceState(null, "", location.pathname);
+}
+const token = localStorage.getItem("backdrop-token") || "";
+
+const statusLine = document.getElementById("status");
+
+async function api(method, path, body) {
+  const response = await fetch("/api/" + path, {
+    method,
+    headers: {
+      "Authorization": "Bearer " + token,
+      "Content-Type": "application/json",
+    },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  if (response.status === 204) {
+    return null;
+  }
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+function showStatus(message) {
+  statusLine.textContent = message;
+}
+
+function imageUrl(name, suffix) {
+  return "/api/images/" + encodeURIComponent(name) + suffix + "?token=" + encodeURIComponent(token);
+}
+
+async function loadGallery() {
+  const [images, current] = await Promise.all([api("GET", "images"), api("GET", "wallpaper")]);
+  const gallery = document.getElementById("gallery");
+  gallery.replaceChildren();
+
+  for (const image of images) {
+    const tile = document.createElement("div");
+    tile.className = "tile" + (image.path === current.image ? " current" : "");
+
+    const img = document.createElement("img");
+    img.src = imageUrl(image.name, "/thumbnail");
+    img.alt = image.name;
+    img.loading = "lazy";
+    img.title = "Set as wallpaper";
+    img.addEventListener("click", () => setWallpaper(image.name));
+
+    const label = document.createElement("label");
+    const checkbox = document.createElement("input");
+    checkbox.type = "checkbox";
+    checkbox.value = image.name;
+    label.append(checkbox, " " + image.name);
+
+    tile.append(img, label);
+    gallery.append(tile);
+  }
+}
+
+async function loadSlideShows() {
+  const slideShows = await api("GET", "slideshows");
+  const list = document.getElementById("slideshows");
+  list.replaceChildren();
+
+  for (const slideShow of slideShows) {
+    const item = document.createElement("li");
+    const use = document.createElement("button");
+    use.textContent = "Use";
+    use.addEventListener("click", () => run(async () => {
+      await api("POST", "slideshows/" + encodeURIComponent(slideShow.name) + "/use");
+      showStatus("Slideshow '" + slideShow.name + "' is now active.");
+      await refresh();
+    }));
+
+    const remove = document.createElement("button");
+    remove.textContent = "Delete";
+    remove.addEventListener("click", () => run(async () => {
+      if (!confirm("Delete slideshow '" + slideShow.name + "'?")) {
+        return;
+      }
+      await api("DELETE", "slideshows/" + encodeURIComponent(slideShow.name));
+      showStatus("Slideshow '" + slideShow.name + "' has been deleted.");
+      await loadSlideShows();
+    }));
+
+    item.append(slideShow.name + " (" + slideShow.images.length + " images) ", use, " ", remove);
+    list.append(item);
+  }
+}
+
+async function loadHistory() {
+  const entries = await api("GET", "history");
+  const list = document.getElementById("history");
+  list.replaceChildren();
+
+  for (const entry of entries.slice(0, 20)) {
+    const item = document.createElement("li");
+    item.textContent = entry.image + " (" + entry.source + ", " + new Date(entry.set_at).toLocaleString() + ")";
+    list.append(item);
+  }
+}
+
+async function setWallpaper(name) {
+  await run(async () => {
+    await api("POST", "wallpaper", { image: name });
+    showStatus("Wallpaper set to " + name + ".");
+    await refresh();
+  });
+}
+
+async function run(action) {
+  try {
+    await action();
+  } catch (err) {
+    showStatus("Error: " + err.message);
+  }
+}
+
+async function refresh() {
+  await Promise.all([loadGallery(), loadSlideShows(), loadHistory()]);
+}
+
+document.getElementById("random").addEventListener("click", () => run(async () => {
+  const result = await api("POST", "wallpaper/random");
+  showStatus("Wallpaper set to " + result.image + ".");
+  await refresh();
+}));
+
+document.getElementById("slideshow-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  run(async () => {
+    const images = [...document.querySelectorAll("#gallery input:checked")].map((checkbox) => checkbox.value);
+    const name = document.getElementById("slideshow-name").value;
+    await api("POST", "slideshows", {
+      name,
+      images,
+      duration_minutes: Number(document.getElementById("slideshow-minutes").value),
+    });
+    showStatus("Slideshow '" + name + "' has been saved.");
+    await loadSlideShows();
+  });
+});
+
+run(refresh);
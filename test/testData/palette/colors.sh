@@ -0,0 +1,21 @@
+# Generated by backdrop from /images/it's.jpg
+export wallpaper='/images/it'\''s.jpg'
+export background='#101218'
+export foreground='#f0ece4'
+export cursor='#f0ece4'
+export color0='#00ff00'
+export color1='#10ef08'
+export color2='#20df10'
+export color3='#30cf18'
+export color4='#40bf20'
+export color5='#50af28'
+export color6='#609f30'
+export color7='#708f38'
+export color8='#807f40'
+export color9='#906f48'
+export color10='#a05f50'
+export color11='#b04f58'
+export color12='#c03f60'
+export color13='#d02f68'
+export color14='#e01f70'
+export color15='#f00f78'